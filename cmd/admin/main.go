@@ -0,0 +1,142 @@
+// admin 运维命令行工具，直接读写数据库
+//
+// BoltDB 同一时间只允许一个进程打开，需要在游戏服务器停止后执行。
+//
+// 用法:
+//
+//	go run ./cmd/admin unlock <用户名>
+//	go run ./cmd/admin -config configs/config.yaml unlock <用户名>
+//	go run ./cmd/admin migrate -dry-run
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"aigames/internal/config"
+	"aigames/internal/database"
+	"aigames/internal/repository"
+	"aigames/internal/services"
+)
+
+// command 运维子命令
+type command struct {
+	usage string
+	run   func(cfg *config.Config, args []string) error
+}
+
+var commands = map[string]command{
+	"unlock":  {usage: "unlock <用户名>    解除账户登录锁定并清除失败计数", run: unlock},
+	"migrate": {usage: "migrate [-dry-run]  把数据升级到最新版本，-dry-run 只列出将要修改的记录", run: migrate},
+}
+
+func main() {
+	configPath := flag.String("config", "", "配置文件路径，默认在 ./configs 下查找")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, exists := commands[flag.Arg(0)]
+	if !exists {
+		fmt.Fprintf(os.Stderr, "未知的命令: %s\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	if err := config.LoadConfig(*configPath); err != nil {
+		fmt.Fprintf(os.Stderr, "加载配置失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := cmd.run(config.GetConfig(), flag.Args()[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// usage 输出帮助信息
+func usage() {
+	fmt.Fprintln(os.Stderr, "用法: admin [-config 配置文件] <命令> [参数]")
+	fmt.Fprintln(os.Stderr, "命令:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(os.Stderr, "  "+commands[name].usage)
+	}
+}
+
+// openDB 打开数据库，服务器运行时会因文件被占用而超时
+func openDB(cfg *config.Config) (*database.DB, error) {
+	db, err := database.NewDB(cfg.Database.Path)
+	if err != nil {
+		return nil, fmt.Errorf("%w（请确认游戏服务器已停止）", err)
+	}
+	return db, nil
+}
+
+// unlock 解除账户登录锁定
+func unlock(cfg *config.Config, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("用法: admin unlock <用户名>")
+	}
+
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	userService := services.NewUserService(repository.NewBoltStore(db.GetBoltDB()), cfg.Security)
+	if err := userService.Unlock(args[0]); err != nil {
+		return fmt.Errorf("解除锁定失败: %w", err)
+	}
+
+	fmt.Printf("用户 %s 已解除锁定\n", args[0])
+	return nil
+}
+
+// migrate 把数据升级到最新版本，服务器启动时也会自动执行
+func migrate(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "只列出将要修改的记录，不修改数据")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return fmt.Errorf("用法: admin migrate [-dry-run]")
+	}
+
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	report, err := db.Migrate(cfg.Database.BackupDir, *dryRun)
+	if err != nil {
+		return err
+	}
+	if report.From == report.To {
+		fmt.Printf("数据已是最新版本 %d\n", report.To)
+		return nil
+	}
+
+	for _, result := range report.Results {
+		fmt.Printf("版本 %d（%s）: %d 条记录\n", result.Version, result.Description, len(result.Changes))
+		for _, change := range result.Changes {
+			fmt.Printf("  %s/%s\n", change.Bucket, change.Key)
+		}
+	}
+	if report.DryRun {
+		fmt.Printf("试运行: 数据将从版本 %d 迁移到 %d，未修改数据\n", report.From, report.To)
+		return nil
+	}
+	fmt.Printf("数据已从版本 %d 迁移到 %d，迁移前的备份: %s\n", report.From, report.To, report.Backup)
+	return nil
+}
@@ -0,0 +1,379 @@
+// simulate 使用 models 引擎在本地批量对局，不依赖网络和数据库
+//
+// 用法:
+//
+//	go run ./cmd/simulate -n 1000 -seed 42 -seats ai,random,random -format json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"aigames/internal/ai"
+	"aigames/internal/models"
+)
+
+// maxDecisions 单局最多决策次数，超过视为规则死循环
+const maxDecisions = 1000
+
+// Player 模拟玩家
+type Player interface {
+	Name() string
+	Call(game *models.Game, position models.PlayerPosition) bool
+	Play(game *models.Game, position models.PlayerPosition) []models.Card
+}
+
+// aiPlayer 使用服务器当前的AI逻辑
+type aiPlayer struct{}
+
+func (p *aiPlayer) Name() string { return "ai" }
+
+func (p *aiPlayer) Call(game *models.Game, position models.PlayerPosition) bool {
+	return ai.DecideCall(game, position)
+}
+
+func (p *aiPlayer) Play(game *models.Game, position models.PlayerPosition) []models.Card {
+	return ai.DecidePlay(game, position)
+}
+
+// randomPlayer 在所有合法操作中随机选择
+type randomPlayer struct {
+	rng *rand.Rand
+}
+
+func (p *randomPlayer) Name() string { return "random" }
+
+func (p *randomPlayer) Call(game *models.Game, position models.PlayerPosition) bool {
+	return p.rng.Intn(2) == 0
+}
+
+func (p *randomPlayer) Play(game *models.Game, position models.PlayerPosition) []models.Card {
+	player := game.GetPlayer(position)
+	lead := ai.MustLead(game, position)
+
+	var last []models.Card
+	if !lead {
+		last = game.LastPlayCards
+	}
+	moves := ai.LegalMoves(player.Cards, last)
+
+	// 非领出时过牌也是一种合法操作
+	choices := len(moves)
+	if !lead {
+		choices++
+	}
+	if choices == 0 {
+		return nil
+	}
+	pick := p.rng.Intn(choices)
+	if pick >= len(moves) {
+		return nil
+	}
+	return moves[pick]
+}
+
+// DecisionStats 决策耗时统计
+type DecisionStats struct {
+	Count     int     `json:"count"`      // 决策次数
+	AvgMicros float64 `json:"avg_micros"` // 平均耗时(微秒)
+	MaxMicros float64 `json:"max_micros"` // 最大耗时(微秒)
+	total     time.Duration
+	max       time.Duration
+}
+
+func (d *DecisionStats) add(elapsed time.Duration) {
+	d.Count++
+	d.total += elapsed
+	if elapsed > d.max {
+		d.max = elapsed
+	}
+}
+
+func (d *DecisionStats) finish() {
+	if d.Count > 0 {
+		d.AvgMicros = float64(d.total.Microseconds()) / float64(d.Count)
+	}
+	d.MaxMicros = float64(d.max.Microseconds())
+}
+
+// SeatStats 座位统计
+type SeatStats struct {
+	Strategy      string `json:"strategy"`       // 策略
+	LandlordGames int    `json:"landlord_games"` // 当地主局数
+	LandlordWins  int    `json:"landlord_wins"`  // 当地主获胜局数
+	FarmerGames   int    `json:"farmer_games"`   // 当农民局数
+	FarmerWins    int    `json:"farmer_wins"`    // 当农民获胜局数
+	Score         int    `json:"score"`          // 累计得分
+}
+
+// Report 模拟结果
+type Report struct {
+	Seed             int64                     `json:"seed"`                // 随机种子
+	Games            int                       `json:"games"`               // 模拟局数
+	Finished         int                       `json:"finished"`            // 正常结束局数
+	Abandoned        int                       `json:"abandoned"`           // 无人叫地主流局数
+	RuleErrors       int                       `json:"rule_errors"`         // 引擎拒绝合法操作等规则异常次数
+	LandlordWinRate  float64                   `json:"landlord_win_rate"`   // 地主胜率
+	FarmerWinRate    float64                   `json:"farmer_win_rate"`     // 农民胜率
+	AvgTurns         float64                   `json:"avg_turns"`           // 平均出牌阶段回合数
+	AvgPlays         float64                   `json:"avg_plays"`           // 平均出牌次数
+	BombsPerGame     float64                   `json:"bombs_per_game"`      // 平均每局炸弹数(含火箭)
+	GamesWithBombPct float64                   `json:"games_with_bomb_pct"` // 出现炸弹的局数占比
+	Seats            [3]*SeatStats             `json:"seats"`               // 各座位统计
+	Decisions        map[string]*DecisionStats `json:"decisions"`           // 各策略决策耗时
+	Errors           []string                  `json:"errors,omitempty"`    // 规则异常明细(最多20条)
+
+	landlordWins  int
+	totalTurns    int
+	totalPlays    int
+	totalBombs    int
+	gamesWithBomb int
+}
+
+func main() {
+	n := flag.Int("n", 1000, "模拟局数")
+	seed := flag.Int64("seed", 1, "随机种子，相同种子结果可复现")
+	seats := flag.String("seats", "ai,random,random", "三个座位的策略，可选 ai、random")
+	format := flag.String("format", "text", "输出格式: text, json")
+	flag.Parse()
+
+	report, err := simulate(*n, *seed, *seats)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	switch *format {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	case "text":
+		printText(report)
+	default:
+		fmt.Fprintf(os.Stderr, "未知的输出格式: %s\n", *format)
+		os.Exit(2)
+	}
+}
+
+// simulate 按座位策略模拟 n 局，相同的种子和座位策略得到相同的结果（决策耗时除外）
+func simulate(n int, seed int64, seats string) (*Report, error) {
+	rng := rand.New(rand.NewSource(seed))
+
+	players, err := parseSeats(seats, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Seed:      seed,
+		Games:     n,
+		Decisions: make(map[string]*DecisionStats),
+	}
+	for i, p := range players {
+		report.Seats[i] = &SeatStats{Strategy: p.Name()}
+		if report.Decisions[p.Name()] == nil {
+			report.Decisions[p.Name()] = &DecisionStats{}
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		if err := playGame(i, players, rng, report); err != nil {
+			report.RuleErrors++
+			if len(report.Errors) < 20 {
+				report.Errors = append(report.Errors, fmt.Sprintf("第%d局: %v", i+1, err))
+			}
+		}
+	}
+	report.finish()
+	return report, nil
+}
+
+// parseSeats 解析座位策略
+func parseSeats(spec string, rng *rand.Rand) ([3]Player, error) {
+	var players [3]Player
+	names := strings.Split(spec, ",")
+	if len(names) != 3 {
+		return players, fmt.Errorf("需要指定3个座位的策略: %s", spec)
+	}
+	for i, name := range names {
+		switch strings.TrimSpace(name) {
+		case "ai":
+			players[i] = &aiPlayer{}
+		case "random":
+			players[i] = &randomPlayer{rng: rand.New(rand.NewSource(rng.Int63()))}
+		default:
+			return players, fmt.Errorf("未知的策略: %s", name)
+		}
+	}
+	return players, nil
+}
+
+// playGame 进行一局完整的游戏
+func playGame(index int, players [3]Player, rng *rand.Rand, report *Report) error {
+	game := models.NewGame(fmt.Sprintf("sim_%d", index+1), "simulate")
+	for pos := models.Position1; pos <= models.Position3; pos++ {
+		game.AddPlayer(fmt.Sprintf("%s-%d", players[pos].Name(), pos+1), pos)
+		game.GetPlayer(pos).IsReady = true
+	}
+
+	game.Status = models.GameStatusReady
+	gameLogic := models.NewGameLogicWithRand(game, rand.New(rand.NewSource(rng.Int63())))
+	if err := gameLogic.DealCards(); err != nil {
+		return err
+	}
+	if err := checkCardCount(game); err != nil {
+		return err
+	}
+
+	decisions := 0
+
+	// 叫地主阶段
+	for game.Status == models.GameStatusCalling {
+		if decisions++; decisions > maxDecisions {
+			return fmt.Errorf("叫地主阶段超过%d次决策", maxDecisions)
+		}
+		pos := game.CurrentTurn
+		player := players[pos]
+
+		start := time.Now()
+		call := player.Call(game, pos)
+		report.Decisions[player.Name()].add(time.Since(start))
+
+		if err := gameLogic.CallLandlord(pos, call); err != nil {
+			return fmt.Errorf("叫地主失败: %w", err)
+		}
+	}
+
+	if game.Status == models.GameStatusAbandoned {
+		report.Abandoned++
+		return nil
+	}
+
+	// 出牌阶段
+	turns, plays, bombs := 0, 0, 0
+	for game.Status == models.GameStatusPlaying {
+		if decisions++; decisions > maxDecisions {
+			return fmt.Errorf("出牌阶段超过%d次决策", maxDecisions)
+		}
+		pos := game.CurrentTurn
+		player := players[pos]
+
+		start := time.Now()
+		cards := player.Play(game, pos)
+		report.Decisions[player.Name()].add(time.Since(start))
+
+		turns++
+		if len(cards) == 0 {
+			if err := gameLogic.Pass(pos); err != nil {
+				return fmt.Errorf("%s 过牌失败: %w", game.GetPlayer(pos).UserName, err)
+			}
+			continue
+		}
+
+		pattern := models.AnalyzeHand(cards)
+		if err := gameLogic.PlayCards(pos, cards); err != nil {
+			return fmt.Errorf("%s 出牌 %v 失败: %w", game.GetPlayer(pos).UserName, cards, err)
+		}
+		plays++
+		if pattern.Type == models.HandTypeBomb || pattern.Type == models.HandTypeRocket {
+			bombs++
+		}
+	}
+
+	if game.Status != models.GameStatusFinished {
+		return fmt.Errorf("游戏以异常状态结束: %s", models.GameStatusNames[game.Status])
+	}
+
+	report.Finished++
+	report.totalTurns += turns
+	report.totalPlays += plays
+	report.totalBombs += bombs
+	if bombs > 0 {
+		report.gamesWithBomb++
+	}
+
+	winner := game.GetPlayer(game.Winner)
+	if winner.Role == models.RoleLandlord {
+		report.landlordWins++
+	}
+	for pos, player := range game.Players {
+		seat := report.Seats[pos]
+		seat.Score += player.Score
+		won := player.Role == winner.Role
+		if player.Role == models.RoleLandlord {
+			seat.LandlordGames++
+			if won {
+				seat.LandlordWins++
+			}
+		} else {
+			seat.FarmerGames++
+			if won {
+				seat.FarmerWins++
+			}
+		}
+	}
+	return nil
+}
+
+// checkCardCount 检查发牌后总牌数是否为54张
+func checkCardCount(game *models.Game) error {
+	total := len(game.LandlordCards)
+	for _, player := range game.Players {
+		total += player.GetCardCount()
+	}
+	if total != 54 {
+		return fmt.Errorf("发牌后总牌数为%d，应为54", total)
+	}
+	return nil
+}
+
+// finish 汇总统计数据
+func (r *Report) finish() {
+	if r.Finished > 0 {
+		finished := float64(r.Finished)
+		r.LandlordWinRate = float64(r.landlordWins) / finished
+		r.FarmerWinRate = 1 - r.LandlordWinRate
+		r.AvgTurns = float64(r.totalTurns) / finished
+		r.AvgPlays = float64(r.totalPlays) / finished
+		r.BombsPerGame = float64(r.totalBombs) / finished
+		r.GamesWithBombPct = float64(r.gamesWithBomb) / finished * 100
+	}
+	for _, d := range r.Decisions {
+		d.finish()
+	}
+}
+
+// printText 以文本格式输出结果
+func printText(r *Report) {
+	fmt.Printf("模拟局数: %d (种子 %d)\n", r.Games, r.Seed)
+	fmt.Printf("正常结束: %d  流局: %d  规则异常: %d\n", r.Finished, r.Abandoned, r.RuleErrors)
+	fmt.Printf("地主胜率: %.2f%%  农民胜率: %.2f%%\n", r.LandlordWinRate*100, r.FarmerWinRate*100)
+	fmt.Printf("平均回合数: %.1f  平均出牌次数: %.1f\n", r.AvgTurns, r.AvgPlays)
+	fmt.Printf("每局炸弹数: %.3f  出现炸弹的局: %.2f%%\n", r.BombsPerGame, r.GamesWithBombPct)
+
+	fmt.Println("\n座位  策略     地主局  地主胜  农民局  农民胜  得分")
+	for i, seat := range r.Seats {
+		fmt.Printf("%-4d  %-7s  %6d  %6d  %6d  %6d  %4d\n", i+1, seat.Strategy,
+			seat.LandlordGames, seat.LandlordWins, seat.FarmerGames, seat.FarmerWins, seat.Score)
+	}
+
+	fmt.Println("\n策略     决策次数  平均耗时(µs)  最大耗时(µs)")
+	for _, name := range []string{"ai", "random"} {
+		if d, ok := r.Decisions[name]; ok {
+			fmt.Printf("%-7s  %8d  %12.2f  %12.0f\n", name, d.Count, d.AvgMicros, d.MaxMicros)
+		}
+	}
+
+	for _, e := range r.Errors {
+		fmt.Println("异常:", e)
+	}
+}
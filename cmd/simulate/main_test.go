@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// outcome 去掉决策耗时后的模拟结果，耗时每次运行都不同
+func outcome(t *testing.T, seed int64, seats string) Report {
+	t.Helper()
+	report, err := simulate(200, seed, seats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report.Decisions = nil
+	return *report
+}
+
+func TestSimulateIsReproducible(t *testing.T) {
+	for _, seats := range []string{"ai,random,random", "random,random,random"} {
+		first := outcome(t, 42, seats)
+		if first.Finished+first.Abandoned+first.RuleErrors != first.Games {
+			t.Fatalf("%s: 结束 %d + 流局 %d + 异常 %d 不等于 %d 局", seats,
+				first.Finished, first.Abandoned, first.RuleErrors, first.Games)
+		}
+		if first.RuleErrors != 0 {
+			t.Fatalf("%s: 出现规则异常 %v", seats, first.Errors)
+		}
+		if again := outcome(t, 42, seats); !reflect.DeepEqual(first, again) {
+			t.Fatalf("%s: 相同种子的结果不同\n%+v\n%+v", seats, first, again)
+		}
+	}
+
+	// 种子不同时各座位的战绩应该不同
+	a, b := outcome(t, 42, "random,random,random"), outcome(t, 7, "random,random,random")
+	if reflect.DeepEqual(a.Seats, b.Seats) {
+		t.Fatal("不同种子的结果完全相同")
+	}
+}
+
+func TestSimulateRejectsUnknownSeats(t *testing.T) {
+	for _, seats := range []string{"ai,random", "ai,random,smart"} {
+		if _, err := simulate(1, 1, seats); err == nil {
+			t.Fatalf("座位策略 %s 应该报错", seats)
+		}
+	}
+}
@@ -0,0 +1,401 @@
+package admin
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"aigames/internal/models"
+	"aigames/internal/services"
+	"aigames/pkg/logger"
+	"aigames/pkg/protocol"
+)
+
+// RoomPlayerData 房间中的玩家
+type RoomPlayerData struct {
+	Position  models.PlayerPosition `json:"position"`   // 座位
+	UserName  string                `json:"username"`   // 用户名
+	IsAI      bool                  `json:"is_ai"`      // 是否为AI玩家
+	Online    bool                  `json:"online"`     // 是否在线，AI玩家始终为false
+	IsReady   bool                  `json:"is_ready"`   // 是否准备
+	Role      string                `json:"role"`       // 角色
+	CardCount int                   `json:"card_count"` // 剩余手牌数
+	Score     int                   `json:"score"`      // 本局得分
+}
+
+// RoomSummaryData 房间概况
+type RoomSummaryData struct {
+	ID         string           `json:"id"`          // 房间ID
+	Code       string           `json:"code"`        // 房间码
+	Name       string           `json:"name"`        // 房间名称
+	Owner      string           `json:"owner"`       // 房主
+	Type       string           `json:"type"`        // 房间类型
+	Status     string           `json:"status"`      // 房间状态
+	Hands      int              `json:"hands"`       // 每次对战的局数
+	BaseStake  int64            `json:"base_stake"`  // 底注
+	GameID     string           `json:"game_id"`     // 当前游戏ID
+	GameStatus string           `json:"game_status"` // 当前游戏状态
+	Players    []RoomPlayerData `json:"players"`     // 玩家
+	CreatedAt  time.Time        `json:"created_at"`  // 创建时间
+	UpdatedAt  time.Time        `json:"updated_at"`  // 更新时间
+}
+
+// GameDetailData 游戏完整状态
+type GameDetailData struct {
+	RoomID string       `json:"room_id"` // 所在房间，已归档的游戏为空
+	Game   *models.Game `json:"game"`    // 游戏，包括各玩家手牌和底牌
+}
+
+// UserDetailData 用户信息，不包含密码
+type UserDetailData struct {
+	ID          string     `json:"id"`                     // 用户ID
+	Name        string     `json:"name"`                   // 用户名
+	Nickname    string     `json:"nickname,omitempty"`     // 昵称
+	Online      bool       `json:"online"`                 // 是否在线
+	RoomID      string     `json:"room_id,omitempty"`      // 所在房间
+	CreatedAt   time.Time  `json:"created_at"`             // 注册时间
+	LastLoginAt time.Time  `json:"last_login_at"`          // 最后登录时间
+	LockedUntil *time.Time `json:"locked_until,omitempty"` // 登录锁定截止时间
+	Banned      bool       `json:"banned"`                 // 是否被封禁
+	BanReason   string     `json:"ban_reason,omitempty"`   // 封禁原因
+	BannedAt    *time.Time `json:"banned_at,omitempty"`    // 封禁时间
+}
+
+// reasonRequest 带原因的操作请求
+type reasonRequest struct {
+	Reason string `json:"reason"` // 原因，会推送给相关玩家，为空时使用默认原因
+}
+
+// passwordRequest 重置密码请求
+type passwordRequest struct {
+	Password string `json:"password"` // 新密码，为空时随机生成
+}
+
+// passwordData 重置密码响应
+type passwordData struct {
+	Password string `json:"password,omitempty"` // 随机生成的新密码，指定密码时为空
+}
+
+// newRoomSummaryData 根据房间快照创建房间概况
+func (s *Server) newRoomSummaryData(room *models.Room) RoomSummaryData {
+	data := RoomSummaryData{
+		ID:        room.ID,
+		Code:      room.Code,
+		Name:      room.Name,
+		Owner:     room.Owner,
+		Type:      models.RoomTypeNames[room.Type],
+		Status:    models.RoomStatusNames[room.Status],
+		Hands:     room.Hands,
+		BaseStake: room.BaseStake,
+		Players:   make([]RoomPlayerData, 0, 3),
+		CreatedAt: room.CreatedAt,
+		UpdatedAt: room.UpdatedAt,
+	}
+	if game := room.CurrentGame; game != nil {
+		data.GameID = game.ID
+		data.GameStatus = models.GameStatusNames[game.Status]
+		for _, player := range game.Players {
+			if player == nil {
+				continue
+			}
+			data.Players = append(data.Players, RoomPlayerData{
+				Position:  player.Position,
+				UserName:  player.UserName,
+				IsAI:      player.IsAI,
+				Online:    !player.IsAI && s.presence.IsOnline(player.UserName),
+				IsReady:   player.IsReady,
+				Role:      models.RoleNames[player.Role],
+				CardCount: player.GetCardCount(),
+				Score:     player.Score,
+			})
+		}
+	}
+	return data
+}
+
+// listRooms 列出所有房间及其玩家，按创建时间排序
+func (s *Server) listRooms(w http.ResponseWriter, r *http.Request) {
+	rooms := s.roomService.Snapshots()
+	sort.Slice(rooms, func(i, j int) bool {
+		return rooms[i].CreatedAt.Before(rooms[j].CreatedAt)
+	})
+
+	data := make([]RoomSummaryData, 0, len(rooms))
+	for _, room := range rooms {
+		data = append(data, s.newRoomSummaryData(room))
+	}
+	writeResponse(w, protocol.Success(data))
+}
+
+// getRoom 获取房间的完整状态，包括当前游戏和多局对战，不包含房间密码
+func (s *Server) getRoom(w http.ResponseWriter, r *http.Request) {
+	room, err := s.roomService.GetRoom(r.PathValue("id"))
+	if err != nil {
+		writeResponse(w, protocol.NotFound(err.Error()))
+		return
+	}
+	room.Password = ""
+	writeResponse(w, protocol.Success(room))
+}
+
+// deleteRoom 关闭并删除房间，未结束的游戏标记为中止并归档
+func (s *Server) deleteRoom(w http.ResponseWriter, r *http.Request) {
+	var req reasonRequest
+	if err := readBody(r, &req); err != nil {
+		writeResponse(w, protocol.BadRequest("请求格式错误"))
+		return
+	}
+	if req.Reason == "" {
+		req.Reason = "房间已被管理员关闭"
+	}
+
+	roomID := r.PathValue("id")
+	if err := s.roomReaper.Close(roomID, req.Reason); err != nil {
+		if errors.Is(err, services.ErrRoomNotFound) {
+			writeResponse(w, protocol.NotFound(err.Error()))
+			return
+		}
+		logger.Error("运维删除房间 %s 失败: %v", roomID, err)
+		writeResponse(w, protocol.InternalServerError("删除房间失败"))
+		return
+	}
+
+	audit(r, "删除房间 %s: %s", roomID, req.Reason)
+	writeResponse(w, protocol.Success(nil))
+}
+
+// getGame 获取游戏的完整状态，包括已归档的游戏
+func (s *Server) getGame(w http.ResponseWriter, r *http.Request) {
+	game, roomID, err := s.gameService.GetGame(r.PathValue("id"))
+	if err != nil {
+		if err.Error() == "游戏不存在" {
+			writeResponse(w, protocol.NotFound(err.Error()))
+			return
+		}
+		logger.Error("运维查询游戏失败: %v", err)
+		writeResponse(w, protocol.InternalServerError("查询游戏失败"))
+		return
+	}
+	writeResponse(w, protocol.Success(GameDetailData{RoomID: roomID, Game: game}))
+}
+
+// endGame 强制结束游戏，多局对战一并结束
+func (s *Server) endGame(w http.ResponseWriter, r *http.Request) {
+	s.terminateGame(w, r, "管理员强制结束了游戏", true)
+}
+
+// abandonGame 中止当前一局，多局对战按流局处理并继续下一局
+func (s *Server) abandonGame(w http.ResponseWriter, r *http.Request) {
+	s.terminateGame(w, r, "管理员中止了本局", false)
+}
+
+// terminateGame 结束进行中的游戏并通知房间中的玩家
+func (s *Server) terminateGame(w http.ResponseWriter, r *http.Request, defaultReason string, endSession bool) {
+	var req reasonRequest
+	if err := readBody(r, &req); err != nil {
+		writeResponse(w, protocol.BadRequest("请求格式错误"))
+		return
+	}
+	if req.Reason == "" {
+		req.Reason = defaultReason
+	}
+
+	gameID := r.PathValue("id")
+	_, roomID, err := s.gameService.GetGame(gameID)
+	if err != nil {
+		if err.Error() == "游戏不存在" {
+			writeResponse(w, protocol.NotFound(err.Error()))
+			return
+		}
+		logger.Error("运维查询游戏失败: %v", err)
+		writeResponse(w, protocol.InternalServerError("查询游戏失败"))
+		return
+	}
+	if roomID == "" {
+		writeResponse(w, protocol.Conflict("游戏已结束"))
+		return
+	}
+
+	game, err := s.gameService.TerminateGame(roomID, gameID, req.Reason, endSession)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrRoomNotFound), err.Error() == "房间没有活跃的游戏":
+			writeResponse(w, protocol.NotFound(err.Error()))
+		case err.Error() == "游戏已结束", err.Error() == "游戏未开始":
+			writeResponse(w, protocol.Conflict(err.Error()))
+		default:
+			logger.Error("运维结束游戏 %s 失败: %v", gameID, err)
+			writeResponse(w, protocol.InternalServerError("结束游戏失败"))
+		}
+		return
+	}
+
+	audit(r, "结束房间 %s 的游戏 %s(结束对战=%v): %s", roomID, gameID, endSession, req.Reason)
+	data := protocol.GameTerminatedData{RoomID: roomID, GameID: gameID, Reason: req.Reason, SessionEnded: endSession}
+	for _, player := range game.Players {
+		if player != nil && !player.IsAI {
+			s.presence.Push(player.UserName, protocol.RouteGameTerminated, data)
+		}
+	}
+	writeResponse(w, protocol.Success(GameDetailData{RoomID: roomID, Game: game}))
+}
+
+// getUser 获取用户信息
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
+	user, err := s.userService.GetUser(r.PathValue("name"))
+	if err != nil {
+		writeResponse(w, protocol.NotFound("用户不存在"))
+		return
+	}
+
+	data := UserDetailData{
+		ID:          user.ID,
+		Name:        user.Name,
+		Nickname:    user.Nickname,
+		Online:      s.presence.IsOnline(user.Name),
+		CreatedAt:   user.CreatedAt,
+		LastLoginAt: user.LastLoginAt,
+		LockedUntil: user.LockedUntil,
+		Banned:      user.Banned,
+		BanReason:   user.BanReason,
+		BannedAt:    user.BannedAt,
+	}
+	if roomID, found := s.roomService.FindPlayerRoom(user.Name); found {
+		data.RoomID = roomID
+	}
+	writeResponse(w, protocol.Success(data))
+}
+
+// banUser 封禁用户：吊销已签发的凭证并断开其连接
+func (s *Server) banUser(w http.ResponseWriter, r *http.Request) {
+	var req reasonRequest
+	if err := readBody(r, &req); err != nil {
+		writeResponse(w, protocol.BadRequest("请求格式错误"))
+		return
+	}
+
+	name := r.PathValue("name")
+	if err := s.userService.Ban(name, req.Reason); err != nil {
+		s.userError(w, "封禁用户", name, err)
+		return
+	}
+	if err := s.tokenService.RevokeUser(name); err != nil {
+		logger.Error("吊销用户 %s 的凭证失败: %v", name, err)
+	}
+	s.presence.Disconnect(name, protocol.RouteAccountBanned, protocol.UserBannedData{Reason: req.Reason})
+
+	audit(r, "封禁用户 %s: %s", name, req.Reason)
+	writeResponse(w, protocol.Success(nil))
+}
+
+// unbanUser 解除用户封禁
+func (s *Server) unbanUser(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if err := s.userService.Unban(name); err != nil {
+		s.userError(w, "解除封禁", name, err)
+		return
+	}
+
+	audit(r, "解除用户 %s 的封禁", name)
+	writeResponse(w, protocol.Success(nil))
+}
+
+// resetPassword 重置用户密码并吊销已签发的凭证，未指定新密码时随机生成
+func (s *Server) resetPassword(w http.ResponseWriter, r *http.Request) {
+	var req passwordRequest
+	if err := readBody(r, &req); err != nil {
+		writeResponse(w, protocol.BadRequest("请求格式错误"))
+		return
+	}
+
+	var data passwordData
+	if req.Password == "" {
+		password, err := randomPassword()
+		if err != nil {
+			logger.Error("生成随机密码失败: %v", err)
+			writeResponse(w, protocol.InternalServerError("生成密码失败"))
+			return
+		}
+		req.Password = password
+		data.Password = password
+	}
+	// 与注册和修改密码的长度限制一致
+	if length := utf8.RuneCountInString(req.Password); length < 6 || length > 50 {
+		writeResponse(w, protocol.BadRequest("密码长度必须在6到50之间"))
+		return
+	}
+
+	name := r.PathValue("name")
+	if err := s.userService.ResetPassword(name, req.Password); err != nil {
+		s.userError(w, "重置密码", name, err)
+		return
+	}
+	if err := s.tokenService.RevokeUser(name); err != nil {
+		logger.Error("吊销用户 %s 的凭证失败: %v", name, err)
+	}
+
+	audit(r, "重置用户 %s 的密码", name)
+	writeResponse(w, protocol.Success(data))
+}
+
+// unlockUser 解除账户登录锁定并清除失败计数
+func (s *Server) unlockUser(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if err := s.userService.Unlock(name); err != nil {
+		s.userError(w, "解除锁定", name, err)
+		return
+	}
+
+	audit(r, "解除用户 %s 的登录锁定", name)
+	writeResponse(w, protocol.Success(nil))
+}
+
+// unlockAddress 解除来源地址的登录锁定，地址锁定只保存在内存中，只能通过运行中的服务器解除
+func (s *Server) unlockAddress(w http.ResponseWriter, r *http.Request) {
+	addr := r.PathValue("addr")
+	if !s.userService.UnlockAddress(addr) {
+		writeResponse(w, protocol.NotFound("该地址没有登录失败记录"))
+		return
+	}
+
+	audit(r, "解除来源地址 %s 的登录锁定", addr)
+	writeResponse(w, protocol.Success(nil))
+}
+
+// userError 输出用户操作的错误响应
+func (s *Server) userError(w http.ResponseWriter, action, name string, err error) {
+	if err.Error() == "用户不存在" {
+		writeResponse(w, protocol.NotFound(err.Error()))
+		return
+	}
+	logger.Error("运维%s %s 失败: %v", action, name, err)
+	writeResponse(w, protocol.InternalServerError(action+"失败"))
+}
+
+// recentErrors 获取最近的错误日志，按时间倒序，limit 默认100
+func (s *Server) recentErrors(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			writeResponse(w, protocol.BadRequest("limit 必须是正整数"))
+			return
+		}
+		limit = parsed
+	}
+	writeResponse(w, protocol.Success(logger.RecentErrors(limit)))
+}
+
+// randomPassword 生成12位随机密码
+func randomPassword() (string, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
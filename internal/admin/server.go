@@ -0,0 +1,143 @@
+// Package admin 运维HTTP接口
+//
+// 所有接口都需要在请求头中携带 Authorization: Bearer <admin.token>，
+// 响应体与游戏协议相同，为 protocol.BaseResponse 的JSON。
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"aigames/internal/config"
+	"aigames/internal/services"
+	"aigames/pkg/logger"
+	"aigames/pkg/protocol"
+)
+
+// Server 运维HTTP服务
+type Server struct {
+	token  string
+	server *http.Server
+
+	userService  *services.UserService
+	tokenService *services.TokenService
+	roomService  *services.RoomService
+	gameService  *services.GameService
+	roomReaper   *services.RoomReaper
+	presence     *services.PresenceService
+}
+
+// NewServer 创建运维HTTP服务
+func NewServer(cfg config.AdminConfig, userService *services.UserService, tokenService *services.TokenService,
+	roomService *services.RoomService, gameService *services.GameService, roomReaper *services.RoomReaper,
+	presence *services.PresenceService) *Server {
+	s := &Server{
+		token:        cfg.Token,
+		userService:  userService,
+		tokenService: tokenService,
+		roomService:  roomService,
+		gameService:  gameService,
+		roomReaper:   roomReaper,
+		presence:     presence,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/rooms", s.listRooms)
+	mux.HandleFunc("GET /admin/rooms/{id}", s.getRoom)
+	mux.HandleFunc("DELETE /admin/rooms/{id}", s.deleteRoom)
+	mux.HandleFunc("GET /admin/games/{id}", s.getGame)
+	mux.HandleFunc("POST /admin/games/{id}/end", s.endGame)
+	mux.HandleFunc("POST /admin/games/{id}/abandon", s.abandonGame)
+	mux.HandleFunc("GET /admin/users/{name}", s.getUser)
+	mux.HandleFunc("POST /admin/users/{name}/ban", s.banUser)
+	mux.HandleFunc("POST /admin/users/{name}/unban", s.unbanUser)
+	mux.HandleFunc("POST /admin/users/{name}/password", s.resetPassword)
+	mux.HandleFunc("POST /admin/users/{name}/unlock", s.unlockUser)
+	mux.HandleFunc("POST /admin/addresses/{addr}/unlock", s.unlockAddress)
+	mux.HandleFunc("GET /admin/errors", s.recentErrors)
+
+	if cfg.Addr != "" {
+		s.server = &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           s.authenticate(mux),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+	}
+	return s
+}
+
+// Start 启动运维服务，未配置监听地址或访问令牌时不启用
+func (s *Server) Start() {
+	if s.server == nil || s.token == "" {
+		logger.Warn("运维接口未启用，需要同时配置 admin.addr 和 admin.token")
+		return
+	}
+
+	go func() {
+		logger.Info("运维接口启动在 http://%s/admin", s.server.Addr)
+		if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("运维接口启动失败: %v", err)
+		}
+	}()
+}
+
+// Stop 停止运维服务，等待进行中的请求完成
+func (s *Server) Stop() {
+	if s.server == nil || s.token == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		logger.Error("停止运维接口失败: %v", err)
+	}
+}
+
+// authenticate 校验访问令牌
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			logger.Warn("运维接口认证失败: %s %s 来自 %s", r.Method, r.URL.Path, r.RemoteAddr)
+			writeResponse(w, protocol.Unauthorized("访问令牌无效"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeResponse 输出JSON响应，HTTP状态码与业务状态码一致，业务错误码统一为400
+func writeResponse(w http.ResponseWriter, resp protocol.BaseResponse) {
+	status := resp.Code
+	if status < 100 || status > 599 {
+		status = http.StatusBadRequest
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Error("输出运维接口响应失败: %v", err)
+	}
+}
+
+// readBody 解析JSON请求体，请求体为空时保持默认值
+func readBody(r *http.Request, v interface{}) error {
+	err := json.NewDecoder(io.LimitReader(r.Body, 1<<16)).Decode(v)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+// audit 记录运维操作
+func audit(r *http.Request, format string, args ...interface{}) {
+	args = append([]interface{}{r.RemoteAddr}, args...)
+	logger.Info("运维操作(%s): "+format, args...)
+}
@@ -0,0 +1,118 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"aigames/internal/config"
+	"aigames/internal/models"
+	"aigames/internal/repository"
+	"aigames/internal/services"
+	"aigames/pkg/protocol"
+)
+
+const testToken = "admin-token"
+
+// newTestServer 创建使用内存存储的运维服务，注册一个用户 alice
+func newTestServer(t *testing.T) (*Server, *services.UserService) {
+	t.Helper()
+	store := repository.NewMemoryStore()
+	presence := services.NewPresenceService()
+	users := services.NewUserService(store, config.SecurityConfig{BcryptCost: 4})
+	tokens := services.NewTokenService(store, config.JWTConfig{Secret: "secret", Issuer: "aigames"})
+	rooms := services.NewRoomService(store, config.GameConfig{})
+	games := services.NewGameService(store, rooms, services.NewAIWorkerPool(config.AIConfig{}))
+	reaper := services.NewRoomReaper(rooms, games, presence, config.GameConfig{})
+
+	user := &models.User{Name: "alice"}
+	if err := users.SetPassword(user, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.SaveUser(user); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.AdminConfig{Addr: "127.0.0.1:0", Token: testToken}
+	return NewServer(cfg, users, tokens, rooms, games, reaper, presence), users
+}
+
+// do 发送请求，authorization 为空时不携带认证头
+func do(t *testing.T, s *Server, method, path, authorization string) protocol.BaseResponse {
+	t.Helper()
+	r := httptest.NewRequest(method, path, strings.NewReader(""))
+	if authorization != "" {
+		r.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	s.server.Handler.ServeHTTP(w, r)
+
+	var resp protocol.BaseResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != resp.Code {
+		t.Fatalf("%s %s: HTTP状态码 %d 与业务状态码 %d 不一致", method, path, w.Code, resp.Code)
+	}
+	return resp
+}
+
+func TestRejectsMissingOrWrongToken(t *testing.T) {
+	s, users := newTestServer(t)
+
+	for _, authorization := range []string{
+		"",
+		testToken,
+		"Bearer wrong-token",
+		"Bearer " + testToken + "x",
+		"Basic " + testToken,
+		"bearer " + testToken,
+	} {
+		resp := do(t, s, http.MethodPost, "/admin/users/alice/ban", authorization)
+		if resp.Code != http.StatusUnauthorized {
+			t.Errorf("认证头 %q 返回 %d，应为 401", authorization, resp.Code)
+		}
+	}
+
+	// 认证失败的请求不会执行操作
+	user, err := users.GetUser("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Banned {
+		t.Fatal("认证失败的请求封禁了用户")
+	}
+}
+
+func TestValidTokenReachesHandlers(t *testing.T) {
+	s, users := newTestServer(t)
+	auth := "Bearer " + testToken
+
+	if resp := do(t, s, http.MethodGet, "/admin/users/alice", auth); resp.Code != http.StatusOK {
+		t.Fatalf("获取用户返回 %d: %s", resp.Code, resp.Message)
+	}
+	if resp := do(t, s, http.MethodPost, "/admin/users/alice/ban", auth); resp.Code != http.StatusOK {
+		t.Fatalf("封禁用户返回 %d: %s", resp.Code, resp.Message)
+	}
+	user, err := users.GetUser("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.Banned {
+		t.Fatal("封禁没有生效")
+	}
+
+	for _, req := range []struct{ method, path string }{
+		{http.MethodGet, "/admin/users/bob"},
+		{http.MethodPost, "/admin/users/bob/ban"},
+		{http.MethodGet, "/admin/rooms/missing"},
+		{http.MethodDelete, "/admin/rooms/missing"},
+		{http.MethodPost, "/admin/addresses/10.0.0.1/unlock"},
+	} {
+		if resp := do(t, s, req.method, req.path, auth); resp.Code != http.StatusNotFound {
+			t.Errorf("%s %s 返回 %d，应为 404", req.method, req.path, resp.Code)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package ai
+
+import (
+	"aigames/internal/models"
+)
+
+// DecideCall 决定是否叫地主
+// 当前策略：AI永远不叫地主
+func DecideCall(game *models.Game, position models.PlayerPosition) bool {
+	return false
+}
+
+// DecidePlay 决定要出的牌，返回空表示过牌
+// 当前策略：AI永远过牌
+func DecidePlay(game *models.Game, position models.PlayerPosition) []models.Card {
+	return nil
+}
+
+// MustLead 判断玩家是否必须出牌（新一轮由其领出）
+func MustLead(game *models.Game, position models.PlayerPosition) bool {
+	return len(game.LastPlayCards) == 0 || game.LastPlayer == position
+}
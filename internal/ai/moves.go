@@ -0,0 +1,160 @@
+package ai
+
+import (
+	"sort"
+
+	"aigames/internal/models"
+)
+
+// maxKickerCombos 飞机带翅膀时每种飞机最多枚举的带牌组合数
+const maxKickerCombos = 32
+
+// LegalMoves 列出手牌中所有能打出的牌组
+// last 为需要压过的上一手牌，为空时表示自由出牌。结果顺序是确定的，不包含过牌
+func LegalMoves(hand []models.Card, last []models.Card) [][]models.Card {
+	groups := groupByValue(hand)
+	values := make([]models.CardValue, 0, len(groups))
+	for value := range groups {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	var candidates [][]models.Card
+
+	// 单牌、对子、三张、炸弹
+	for _, value := range values {
+		cards := groups[value]
+		for n := 1; n <= len(cards) && n <= 4; n++ {
+			candidates = append(candidates, cloneCards(cards[:n]))
+		}
+	}
+
+	// 火箭
+	if len(groups[models.ValueSmallJoker]) > 0 && len(groups[models.ValueBigJoker]) > 0 {
+		candidates = append(candidates, []models.Card{
+			groups[models.ValueSmallJoker][0],
+			groups[models.ValueBigJoker][0],
+		})
+	}
+
+	// 三带一、三带二
+	for _, triple := range values {
+		if len(groups[triple]) < 3 {
+			continue
+		}
+		for _, kicker := range values {
+			if kicker == triple {
+				continue
+			}
+			candidates = append(candidates, joinCards(groups[triple][:3], groups[kicker][:1]))
+			if len(groups[kicker]) >= 2 {
+				candidates = append(candidates, joinCards(groups[triple][:3], groups[kicker][:2]))
+			}
+		}
+	}
+
+	// 顺子、连对、飞机
+	candidates = append(candidates, sequences(groups, 1, 5)...)
+	candidates = append(candidates, sequences(groups, 2, 3)...)
+	for _, plane := range sequences(groups, 3, 2) {
+		candidates = append(candidates, plane)
+		candidates = append(candidates, withKickers(plane, groups, values, 1)...)
+		candidates = append(candidates, withKickers(plane, groups, values, 2)...)
+	}
+
+	var lastPattern models.HandPattern
+	if len(last) > 0 {
+		lastPattern = models.AnalyzeHand(last)
+	}
+
+	moves := make([][]models.Card, 0, len(candidates))
+	for _, cards := range candidates {
+		pattern := models.AnalyzeHand(cards)
+		if !pattern.IsValid {
+			continue
+		}
+		if len(last) > 0 && !models.CanBeat(pattern, lastPattern) {
+			continue
+		}
+		moves = append(moves, cards)
+	}
+	return moves
+}
+
+// groupByValue 按牌值分组
+func groupByValue(hand []models.Card) map[models.CardValue][]models.Card {
+	groups := make(map[models.CardValue][]models.Card)
+	for _, card := range hand {
+		groups[card.Value] = append(groups[card.Value], card)
+	}
+	return groups
+}
+
+// sequences 列出每组取 width 张、至少 minLength 组的连续牌（不含2和王）
+func sequences(groups map[models.CardValue][]models.Card, width, minLength int) [][]models.Card {
+	var result [][]models.Card
+	for start := models.Value3; start < models.Value2; start++ {
+		var cards []models.Card
+		for value := start; value < models.Value2; value++ {
+			if len(groups[value]) < width {
+				break
+			}
+			cards = append(cards, groups[value][:width]...)
+			if int(value-start)+1 >= minLength {
+				result = append(result, cloneCards(cards))
+			}
+		}
+	}
+	return result
+}
+
+// withKickers 为飞机添加翅膀，width 为1时带单牌，为2时带对子
+func withKickers(plane []models.Card, groups map[models.CardValue][]models.Card, values []models.CardValue, width int) [][]models.Card {
+	inPlane := make(map[models.CardValue]bool)
+	for _, card := range plane {
+		inPlane[card.Value] = true
+	}
+
+	var kickers []models.CardValue
+	for _, value := range values {
+		if !inPlane[value] && len(groups[value]) >= width {
+			kickers = append(kickers, value)
+		}
+	}
+
+	need := len(plane) / 3
+	var result [][]models.Card
+	var pick func(from int, chosen []models.CardValue)
+	pick = func(from int, chosen []models.CardValue) {
+		if len(result) >= maxKickerCombos {
+			return
+		}
+		if len(chosen) == need {
+			cards := cloneCards(plane)
+			for _, value := range chosen {
+				cards = append(cards, groups[value][:width]...)
+			}
+			result = append(result, cards)
+			return
+		}
+		for i := from; i < len(kickers); i++ {
+			pick(i+1, append(chosen, kickers[i]))
+		}
+	}
+	pick(0, make([]models.CardValue, 0, need))
+	return result
+}
+
+// joinCards 拼接两组牌
+func joinCards(a, b []models.Card) []models.Card {
+	cards := make([]models.Card, 0, len(a)+len(b))
+	cards = append(cards, a...)
+	return append(cards, b...)
+}
+
+// cloneCards 复制一组牌
+func cloneCards(cards []models.Card) []models.Card {
+	out := make([]models.Card, len(cards))
+	copy(out, cards)
+	return out
+}
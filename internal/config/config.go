@@ -11,11 +11,16 @@ import (
 
 // Config 应用配置结构
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`   // 服务器配置
-	Database DatabaseConfig `mapstructure:"database"` // 数据库配置
-	AI       AIConfig       `mapstructure:"ai"`       // AI配置
-	Log      LogConfig      `mapstructure:"log"`      // 日志配置
-	Game     GameConfig     `mapstructure:"game"`     // 游戏配置
+	Server    ServerConfig    `mapstructure:"server"`     // 服务器配置
+	Database  DatabaseConfig  `mapstructure:"database"`   // 数据库配置
+	AI        AIConfig        `mapstructure:"ai"`         // AI配置
+	Log       LogConfig       `mapstructure:"log"`        // 日志配置
+	Game      GameConfig      `mapstructure:"game"`       // 游戏配置
+	Security  SecurityConfig  `mapstructure:"security"`   // 安全配置
+	JWT       JWTConfig       `mapstructure:"jwt"`        // 登录凭证配置
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"` // 请求限流配置
+	Economy   EconomyConfig   `mapstructure:"economy"`    // 金币配置
+	Admin     AdminConfig     `mapstructure:"admin"`      // 运维接口配置
 }
 
 // ServerConfig 服务器配置
@@ -23,12 +28,16 @@ type ServerConfig struct {
 	Host string `mapstructure:"host"` // 服务器主机
 	Port int    `mapstructure:"port"` // 服务器端口
 	Mode string `mapstructure:"mode"` // 运行模式：debug, release, test
+
+	DrainTimeout int    `mapstructure:"drain_timeout"` // 停机时等待进行中的游戏结束的宽限时间(秒)
+	MetricsAddr  string `mapstructure:"metrics_addr"`  // 监控指标的监听地址，不需要认证，不应对外开放；为空表示不启用
 }
 
 // DatabaseConfig 数据库配置
 type DatabaseConfig struct {
-	Path    string `mapstructure:"path"`    // BoltDB文件路径
-	Timeout int    `mapstructure:"timeout"` // 操作超时(秒)
+	Path      string `mapstructure:"path"`       // BoltDB文件路径
+	Timeout   int    `mapstructure:"timeout"`    // 操作超时(秒)
+	BackupDir string `mapstructure:"backup_dir"` // 迁移前备份文件的目录，为空时与数据库文件同目录
 }
 
 // AIConfig AI配置
@@ -37,6 +46,9 @@ type AIConfig struct {
 	MaxConcurrent      int     `mapstructure:"max_concurrent"`      // 最大并发请求数
 	Timeout            int     `mapstructure:"timeout"`             // 请求超时(秒)
 	DefaultThinkTime   int     `mapstructure:"default_think_time"`  // 默认思考时间(秒)
+	ThinkJitter        int     `mapstructure:"think_jitter"`        // 思考时间随机抖动上限(毫秒)
+	FastMode           bool    `mapstructure:"fast_mode"`           // 快速模式，跳过思考时间
+	StatsInterval      int     `mapstructure:"stats_interval"`      // 负载统计日志间隔(秒)，0表示不输出
 	DefaultTemperature float64 `mapstructure:"default_temperature"` // 默认创造性参数
 	MaxTokens          int     `mapstructure:"max_tokens"`          // 最大token数
 }
@@ -52,12 +64,71 @@ type LogConfig struct {
 
 // GameConfig 游戏配置
 type GameConfig struct {
-	DefaultRoomCapacity   int `mapstructure:"default_room_capacity"`   // 默认房间容量
-	DefaultGameTimeout    int `mapstructure:"default_game_timeout"`    // 默认游戏超时(秒)
-	DefaultBiddingTimeout int `mapstructure:"default_bidding_timeout"` // 默认叫地主超时(秒)
-	DefaultPlayTimeout    int `mapstructure:"default_play_timeout"`    // 默认出牌超时(秒)
-	MaxRoomsPerUser       int `mapstructure:"max_rooms_per_user"`      // 用户最大房间数
-	MaxAIPlayersPerRoom   int `mapstructure:"max_ai_players_per_room"` // 房间最大AI玩家数
+	DefaultRoomCapacity     int    `mapstructure:"default_room_capacity"`     // 默认房间容量
+	DefaultGameTimeout      int    `mapstructure:"default_game_timeout"`      // 默认游戏超时(秒)
+	DefaultBiddingTimeout   int    `mapstructure:"default_bidding_timeout"`   // 默认叫地主超时(秒)
+	DefaultPlayTimeout      int    `mapstructure:"default_play_timeout"`      // 默认出牌超时(秒)
+	MaxRoomsPerUser         int    `mapstructure:"max_rooms_per_user"`        // 用户最大房间数
+	MaxAIPlayersPerRoom     int    `mapstructure:"max_ai_players_per_room"`   // 房间最大AI玩家数
+	InvitationTTL           int    `mapstructure:"invitation_ttl"`            // 房间邀请有效期(秒)
+	KickBanDuration         int    `mapstructure:"kick_ban_duration"`         // 被房主踢出后禁止重新加入的时长(秒)
+	RoomIdleTTL             int    `mapstructure:"room_idle_ttl"`             // 没有进行中游戏的房间无操作超过该时长后回收(秒)
+	ActiveRoomTTL           int    `mapstructure:"active_room_ttl"`           // 游戏进行中的房间无操作超过该时长后回收(秒)，0表示不回收
+	FinishedRoomTTL         int    `mapstructure:"finished_room_ttl"`         // 游戏结束或中止的房间无操作超过该时长后回收(秒)
+	ReaperInterval          int    `mapstructure:"reaper_interval"`           // 房间回收检查间隔(秒)，0表示不回收
+	NextHandDelay           int    `mapstructure:"next_hand_delay"`           // 多局对战中下一局自动开始前的倒计时(秒)
+	TournamentCheckInterval int    `mapstructure:"tournament_check_interval"` // 比赛收集各桌结果的间隔(秒)
+	DefaultBaseStake        int    `mapstructure:"default_base_stake"`        // 创建房间未指定底注时的默认底注(金币)
+	MinBalanceFactor        int    `mapstructure:"min_balance_factor"`        // 未指定入场金币时，入场金币为底注的倍数
+	AchievementsFile        string `mapstructure:"achievements_file"`         // 成就和任务定义文件，为空表示不启用
+	RecoveryTimeout         int    `mapstructure:"recovery_timeout"`          // 重启后等待玩家重新连接以继续进行中游戏的时长(秒)，0表示不恢复
+}
+
+// SecurityConfig 安全配置
+type SecurityConfig struct {
+	BcryptCost         int `mapstructure:"bcrypt_cost"`          // bcrypt密码哈希强度(4-31)，调整后用户下次登录时自动重新哈希
+	MaxLoginFailures   int `mapstructure:"max_login_failures"`   // 账户连续登录失败多少次后锁定，0表示不锁定
+	MaxAddressFailures int `mapstructure:"max_address_failures"` // 同一来源地址登录失败多少次后锁定，0表示不锁定
+	FailureWindow      int `mapstructure:"failure_window"`       // 登录失败计数窗口(秒)，超过窗口未再失败则重新计数
+	LockDuration       int `mapstructure:"lock_duration"`        // 首次锁定时长(秒)，之后每次锁定时长翻倍
+	MaxLockDuration    int `mapstructure:"max_lock_duration"`    // 最长锁定时长(秒)
+}
+
+// JWTConfig 登录凭证配置
+type JWTConfig struct {
+	Secret     string `mapstructure:"secret"`      // 签名密钥
+	ExpireTime int    `mapstructure:"expire_time"` // 有效期(小时)
+	Issuer     string `mapstructure:"issuer"`      // 签发者
+}
+
+// EconomyConfig 金币配置
+type EconomyConfig struct {
+	InitialBalance int64 `mapstructure:"initial_balance"` // 新用户的初始金币
+	DailyGrant     int64 `mapstructure:"daily_grant"`     // 每天首次登录赠送的金币，0表示不赠送
+}
+
+// AdminConfig 运维HTTP接口配置
+type AdminConfig struct {
+	Addr         string `mapstructure:"addr"`           // 监听地址，为空表示不启用
+	Token        string `mapstructure:"token"`          // 访问令牌，请求头 Authorization: Bearer <token>，为空表示不启用
+	ErrorLogSize int    `mapstructure:"error_log_size"` // 内存中保留的最近错误日志条数
+}
+
+// RateLimitConfig 请求限流配置
+// 每个用户（未登录时为每个会话）在每个路由上拥有独立的令牌桶
+type RateLimitConfig struct {
+	Enabled         bool            `mapstructure:"enabled"`          // 是否启用限流
+	Rate            float64         `mapstructure:"rate"`             // 默认每秒补充的令牌数
+	Burst           int             `mapstructure:"burst"`            // 默认令牌桶容量
+	CleanupInterval int             `mapstructure:"cleanup_interval"` // 空闲令牌桶清理及统计日志间隔(秒)
+	Routes          []RateLimitRule `mapstructure:"routes"`           // 按路由覆盖默认限制
+}
+
+// RateLimitRule 单个路由的限流规则，Rate不大于0表示该路由不限流
+type RateLimitRule struct {
+	Route string  `mapstructure:"route"` // 路由，如 room.CreateRoom
+	Rate  float64 `mapstructure:"rate"`  // 每秒补充的令牌数
+	Burst int     `mapstructure:"burst"` // 令牌桶容量
 }
 
 var (
@@ -110,6 +181,10 @@ func LoadConfig(configPath string) error {
 		config.Database.Path = filepath.Join(".", config.Database.Path)
 	}
 
+	if config.Database.BackupDir != "" && !filepath.IsAbs(config.Database.BackupDir) {
+		config.Database.BackupDir = filepath.Join(".", config.Database.BackupDir)
+	}
+
 	if config.Log.FilePath != "" && !filepath.IsAbs(config.Log.FilePath) {
 		config.Log.FilePath = filepath.Join(".", config.Log.FilePath)
 	}
@@ -124,6 +199,8 @@ func setDefaults() {
 	viper.SetDefault("server.host", "0.0.0.0")
 	viper.SetDefault("server.port", 8080)
 	viper.SetDefault("server.mode", "debug")
+	viper.SetDefault("server.drain_timeout", 120)
+	viper.SetDefault("server.metrics_addr", "127.0.0.1:9100")
 	viper.SetDefault("server.read_timeout", 30)
 	viper.SetDefault("server.write_timeout", 30)
 	viper.SetDefault("server.idle_timeout", 120)
@@ -131,6 +208,7 @@ func setDefaults() {
 	// 数据库默认配置
 	viper.SetDefault("database.path", "./data/game.db")
 	viper.SetDefault("database.timeout", 5)
+	viper.SetDefault("database.backup_dir", "")
 
 	// JWT默认配置
 	viper.SetDefault("jwt.secret", "aigame-secret-key-change-in-production")
@@ -142,6 +220,9 @@ func setDefaults() {
 	viper.SetDefault("ai.max_concurrent", 10)
 	viper.SetDefault("ai.timeout", 30)
 	viper.SetDefault("ai.default_think_time", 3)
+	viper.SetDefault("ai.think_jitter", 1000)
+	viper.SetDefault("ai.fast_mode", false)
+	viper.SetDefault("ai.stats_interval", 60)
 	viper.SetDefault("ai.default_temperature", 0.7)
 	viper.SetDefault("ai.max_tokens", 1000)
 
@@ -160,6 +241,20 @@ func setDefaults() {
 	viper.SetDefault("log.max_age", 30)
 	viper.SetDefault("log.compress", true)
 
+	// 安全默认配置
+	viper.SetDefault("security.bcrypt_cost", 10)
+	viper.SetDefault("security.max_login_failures", 5)
+	viper.SetDefault("security.max_address_failures", 20)
+	viper.SetDefault("security.failure_window", 900)
+	viper.SetDefault("security.lock_duration", 60)
+	viper.SetDefault("security.max_lock_duration", 3600)
+
+	// 限流默认配置
+	viper.SetDefault("rate_limit.enabled", true)
+	viper.SetDefault("rate_limit.rate", 10)
+	viper.SetDefault("rate_limit.burst", 20)
+	viper.SetDefault("rate_limit.cleanup_interval", 60)
+
 	// 游戏默认配置
 	viper.SetDefault("game.default_room_capacity", 3)
 	viper.SetDefault("game.default_game_timeout", 1800)
@@ -167,6 +262,27 @@ func setDefaults() {
 	viper.SetDefault("game.default_play_timeout", 60)
 	viper.SetDefault("game.max_rooms_per_user", 10)
 	viper.SetDefault("game.max_ai_players_per_room", 2)
+	viper.SetDefault("game.invitation_ttl", 300)
+	viper.SetDefault("game.kick_ban_duration", 120)
+	viper.SetDefault("game.room_idle_ttl", 1800)
+	viper.SetDefault("game.active_room_ttl", 7200)
+	viper.SetDefault("game.finished_room_ttl", 600)
+	viper.SetDefault("game.reaper_interval", 60)
+	viper.SetDefault("game.next_hand_delay", 10)
+	viper.SetDefault("game.tournament_check_interval", 5)
+	viper.SetDefault("game.default_base_stake", 10)
+	viper.SetDefault("game.min_balance_factor", 4)
+	viper.SetDefault("game.achievements_file", "./configs/achievements.yaml")
+	viper.SetDefault("game.recovery_timeout", 300)
+
+	// 金币默认配置
+	viper.SetDefault("economy.initial_balance", 1000)
+	viper.SetDefault("economy.daily_grant", 200)
+
+	// 运维接口默认配置
+	viper.SetDefault("admin.addr", "127.0.0.1:8081")
+	viper.SetDefault("admin.token", "")
+	viper.SetDefault("admin.error_log_size", 200)
 }
 
 // GetConfig 获取配置实例
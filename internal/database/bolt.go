@@ -62,12 +62,19 @@ func (db *DB) initBuckets() error {
 	}
 
 	return db.conn.Update(func(tx *bolt.Tx) error {
+		// configs存储桶不存在说明是新建的数据库，没有需要迁移的旧数据
+		created := tx.Bucket([]byte(BucketConfigs)) == nil
+
 		for _, bucket := range buckets {
 			_, err := tx.CreateBucketIfNotExists([]byte(bucket))
 			if err != nil {
 				return fmt.Errorf("创建存储桶 %s 失败: %w", bucket, err)
 			}
 		}
+
+		if created {
+			return putSchemaVersion(tx, LatestSchemaVersion())
+		}
 		return nil
 	})
 }
@@ -283,6 +290,23 @@ func (db *DB) GetStats() bolt.Stats {
 	return db.conn.Stats()
 }
 
+// CheckWritable 检查数据库已打开且可以提交写事务
+// 空的写事务同样需要获取写锁并写入元数据页，能发现只读挂载、磁盘错误和长时间占用写锁
+func (db *DB) CheckWritable() error {
+	if db.conn == nil {
+		return fmt.Errorf("数据库未打开")
+	}
+	if db.conn.IsReadOnly() {
+		return fmt.Errorf("数据库为只读模式")
+	}
+	return db.conn.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(BucketConfigs)) == nil {
+			return fmt.Errorf("存储桶 %s 不存在", BucketConfigs)
+		}
+		return nil
+	})
+}
+
 // Backup 备份数据库
 func (db *DB) Backup(dest string) error {
 	return db.conn.View(func(tx *bolt.Tx) error {
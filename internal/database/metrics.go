@@ -0,0 +1,52 @@
+package database
+
+import (
+	"time"
+
+	"aigames/pkg/metrics"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// txSeconds bolt事务耗时
+var txSeconds = metrics.NewHistogram("aigames_bolt_tx_seconds",
+	"bolt事务的耗时(秒)，包括等待写锁和提交", nil, "type")
+
+// Update 执行bolt写事务并记录耗时
+func Update(db *bolt.DB, fn func(tx *bolt.Tx) error) error {
+	defer txSeconds.Since(time.Now(), "write")
+	return db.Update(fn)
+}
+
+// View 执行bolt读事务并记录耗时
+func View(db *bolt.DB, fn func(tx *bolt.Tx) error) error {
+	defer txSeconds.Since(time.Now(), "read")
+	return db.View(fn)
+}
+
+// RegisterMetrics 注册bolt数据库的事务统计
+// bolt只在写事务提交时统计耗时，按阶段累计：rebalance 节点合并，spill 节点分裂写入页，write 写入磁盘
+func (db *DB) RegisterMetrics() {
+	metrics.CounterFunc("aigames_bolt_commit_seconds_total", "bolt写事务提交的累计耗时(秒)，按提交阶段",
+		[]string{"phase"}, func() []metrics.Sample {
+			stats := db.conn.Stats().TxStats
+			return []metrics.Sample{
+				{Values: []string{"rebalance"}, Value: stats.GetRebalanceTime().Seconds()},
+				{Values: []string{"spill"}, Value: stats.GetSpillTime().Seconds()},
+				{Values: []string{"write"}, Value: stats.GetWriteTime().Seconds()},
+			}
+		})
+
+	metrics.CounterFunc("aigames_bolt_page_writes_total", "bolt写入磁盘的次数", nil, func() []metrics.Sample {
+		stats := db.conn.Stats().TxStats
+		return []metrics.Sample{{Value: float64(stats.GetWrite())}}
+	})
+
+	metrics.CounterFunc("aigames_bolt_read_tx_total", "bolt已开始的读事务数", nil, func() []metrics.Sample {
+		return []metrics.Sample{{Value: float64(db.conn.Stats().TxN)}}
+	})
+
+	metrics.GaugeFunc("aigames_bolt_open_read_tx", "bolt当前打开的读事务数", nil, func() []metrics.Sample {
+		return []metrics.Sample{{Value: float64(db.conn.Stats().OpenTxN)}}
+	})
+}
@@ -0,0 +1,251 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+)
+
+// schemaVersionKey configs存储桶中保存数据结构版本的键
+const schemaVersionKey = "schema_version"
+
+// errDryRun 试运行结束时返回，使迁移事务回滚
+var errDryRun = errors.New("试运行")
+
+// migration 一次数据迁移，把数据从 version-1 升级到 version
+type migration struct {
+	version     int
+	description string
+	migrate     func(mt *migrationTx) error
+}
+
+// migrations 按版本排序的迁移，只能在末尾追加，已发布的迁移不能修改
+// 迁移直接读写JSON字段，不使用 models 中的结构体：模型以后的修改不应改变旧迁移的行为
+var migrations = []migration{
+	{version: 1, description: "为没有ID的旧用户分配UUID", migrate: migrateUserIDs},
+}
+
+// LatestSchemaVersion 程序支持的最新数据结构版本
+func LatestSchemaVersion() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].version
+}
+
+// RecordChange 迁移修改的一条记录
+type RecordChange struct {
+	Bucket string // 存储桶
+	Key    string // 记录的键
+}
+
+// MigrationResult 一次迁移的结果
+type MigrationResult struct {
+	Version     int            // 迁移后的版本
+	Description string         // 迁移说明
+	Changes     []RecordChange // 修改的记录，试运行时为将要修改的记录
+}
+
+// MigrationReport 迁移报告
+type MigrationReport struct {
+	From    int               // 迁移前的版本
+	To      int               // 迁移后的版本，试运行时为将要升级到的版本
+	DryRun  bool              // 是否为试运行，试运行不修改数据
+	Backup  string            // 迁移前的备份文件，没有执行迁移或试运行时为空
+	Results []MigrationResult // 各次迁移的结果
+}
+
+// SchemaVersion 获取数据库的数据结构版本，没有记录版本的旧数据库为0
+func (db *DB) SchemaVersion() (int, error) {
+	version := 0
+	err := db.conn.View(func(tx *bolt.Tx) error {
+		var err error
+		version, err = getSchemaVersion(tx)
+		return err
+	})
+	return version, err
+}
+
+// Migrate 把数据升级到最新版本
+// 所有待执行的迁移和新的版本号在同一个事务中提交，任一迁移失败时全部回滚。
+// 有待执行的迁移时先把数据库备份到 backupDir，为空时备份到数据库文件所在目录。
+// dryRun 时执行迁移后回滚事务，只报告将要修改的记录，不备份也不修改数据
+func (db *DB) Migrate(backupDir string, dryRun bool) (*MigrationReport, error) {
+	from, err := db.SchemaVersion()
+	if err != nil {
+		return nil, fmt.Errorf("读取数据版本失败: %w", err)
+	}
+	latest := LatestSchemaVersion()
+	if from > latest {
+		return nil, fmt.Errorf("数据版本 %d 高于程序支持的版本 %d，请使用新版本的程序", from, latest)
+	}
+
+	report := &MigrationReport{From: from, To: from, DryRun: dryRun}
+	var pending []migration
+	for _, m := range migrations {
+		if m.version > from {
+			pending = append(pending, m)
+		}
+	}
+	if len(pending) == 0 {
+		return report, nil
+	}
+
+	if !dryRun {
+		if report.Backup, err = db.backupBeforeMigrate(backupDir, from); err != nil {
+			return nil, err
+		}
+	}
+
+	err = Update(db.conn, func(tx *bolt.Tx) error {
+		for _, m := range pending {
+			mt := &migrationTx{tx: tx}
+			if err := m.migrate(mt); err != nil {
+				return fmt.Errorf("迁移到版本 %d（%s）失败: %w", m.version, m.description, err)
+			}
+			report.Results = append(report.Results, MigrationResult{
+				Version:     m.version,
+				Description: m.description,
+				Changes:     mt.changes,
+			})
+		}
+		if err := putSchemaVersion(tx, latest); err != nil {
+			return err
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	report.To = latest
+	return report, nil
+}
+
+// backupBeforeMigrate 迁移前备份数据库，文件名包含迁移前的版本和时间，返回备份文件路径
+func (db *DB) backupBeforeMigrate(dir string, version int) (string, error) {
+	if dir == "" {
+		dir = filepath.Dir(db.path)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("创建备份目录失败: %w", err)
+	}
+
+	name := fmt.Sprintf("%s.v%d-%s.bak", filepath.Base(db.path), version, time.Now().Format("20060102-150405"))
+	dest := filepath.Join(dir, name)
+	if err := db.Backup(dest); err != nil {
+		return "", fmt.Errorf("迁移前备份数据库失败: %w", err)
+	}
+	return dest, nil
+}
+
+// getSchemaVersion 读取数据结构版本，没有记录时为0
+func getSchemaVersion(tx *bolt.Tx) (int, error) {
+	b := tx.Bucket([]byte(BucketConfigs))
+	if b == nil {
+		return 0, nil
+	}
+	data := b.Get([]byte(schemaVersionKey))
+	if data == nil {
+		return 0, nil
+	}
+
+	var version int
+	if err := json.Unmarshal(data, &version); err != nil {
+		return 0, fmt.Errorf("解析数据版本失败: %w", err)
+	}
+	return version, nil
+}
+
+// putSchemaVersion 保存数据结构版本
+func putSchemaVersion(tx *bolt.Tx, version int) error {
+	b, err := tx.CreateBucketIfNotExists([]byte(BucketConfigs))
+	if err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(version)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(schemaVersionKey), encoded)
+}
+
+// migrationTx 迁移使用的事务，记录修改过的记录
+type migrationTx struct {
+	tx      *bolt.Tx
+	changes []RecordChange
+}
+
+// updateRecords 遍历存储桶中的JSON记录，fn 修改记录的字段并返回是否有修改，有修改的记录写回
+// 存储桶不存在时忽略，有记录无法解析时迁移失败
+func (mt *migrationTx) updateRecords(bucket string, fn func(key string, record map[string]json.RawMessage) (bool, error)) error {
+	b := mt.tx.Bucket([]byte(bucket))
+	if b == nil {
+		return nil
+	}
+
+	type update struct {
+		key     string
+		encoded []byte
+	}
+	var updates []update
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		var record map[string]json.RawMessage
+		if err := json.Unmarshal(v, &record); err != nil {
+			return fmt.Errorf("解析 %s/%s 失败: %w", bucket, k, err)
+		}
+		changed, err := fn(string(k), record)
+		if err != nil {
+			return fmt.Errorf("迁移 %s/%s 失败: %w", bucket, k, err)
+		}
+		if !changed {
+			continue
+		}
+
+		encoded, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("序列化 %s/%s 失败: %w", bucket, k, err)
+		}
+		updates = append(updates, update{key: string(k), encoded: encoded})
+	}
+
+	// 遍历结束后再写入，避免在游标遍历中修改存储桶
+	for _, u := range updates {
+		if err := b.Put([]byte(u.key), u.encoded); err != nil {
+			return err
+		}
+		mt.changes = append(mt.changes, RecordChange{Bucket: bucket, Key: u.key})
+	}
+	return nil
+}
+
+// migrateUserIDs 版本1：为没有ID的旧用户分配UUID
+func migrateUserIDs(mt *migrationTx) error {
+	return mt.updateRecords(BucketUsers, func(key string, record map[string]json.RawMessage) (bool, error) {
+		var id string
+		if raw, ok := record["id"]; ok {
+			if err := json.Unmarshal(raw, &id); err != nil {
+				return false, err
+			}
+		}
+		if id != "" {
+			return false, nil
+		}
+
+		encoded, err := json.Marshal(uuid.New().String())
+		if err != nil {
+			return false, err
+		}
+		record["id"] = encoded
+		return true, nil
+	})
+}
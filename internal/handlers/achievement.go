@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"aigames/internal/services"
+	"aigames/pkg/logger"
+	"aigames/pkg/protocol"
+
+	"github.com/lonng/nano/component"
+	"github.com/lonng/nano/session"
+)
+
+// Achievement 成就和任务处理器
+type Achievement struct {
+	component.Base
+	achievementService *services.AchievementService
+}
+
+// NewAchievement 创建成就和任务处理器实例
+func NewAchievement(achievementService *services.AchievementService) *Achievement {
+	return &Achievement{achievementService: achievementService}
+}
+
+// List 获取全部成就和任务定义
+func (h *Achievement) List(s *session.Session, req *protocol.AchievementListRequest) error {
+	resp := protocol.AchievementListSuccess(h.achievementService.Goals())
+	resp.SetRequestId(req.RequestId)
+	return s.Response(resp)
+}
+
+// Progress 获取自己的成就和任务进度
+func (h *Achievement) Progress(s *session.Session, req *protocol.AchievementProgressRequest) error {
+	username := s.String("username")
+	if username == "" {
+		resp := protocol.Unauthorized("请先登录")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	progress, err := h.achievementService.Progress(username)
+	if err != nil {
+		logger.Error("查询成就进度失败: %v", err)
+		resp := protocol.InternalServerError("查询失败，请稍后重试")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	resp := protocol.AchievementProgressSuccess(h.achievementService.Goals(), progress)
+	resp.SetRequestId(req.RequestId)
+	return s.Response(resp)
+}
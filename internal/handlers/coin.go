@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"aigames/internal/services"
+	"aigames/pkg/logger"
+	"aigames/pkg/protocol"
+
+	"github.com/lonng/nano/component"
+	"github.com/lonng/nano/session"
+)
+
+// Coin 金币处理器
+type Coin struct {
+	component.Base
+	coinService *services.CoinService
+}
+
+// NewCoin 创建金币处理器实例
+func NewCoin(coinService *services.CoinService) *Coin {
+	return &Coin{coinService: coinService}
+}
+
+// Balance 查询自己的金币余额
+func (h *Coin) Balance(s *session.Session, req *protocol.BalanceRequest) error {
+	username := s.String("username")
+	if username == "" {
+		resp := protocol.Unauthorized("请先登录")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	balance, err := h.coinService.Balance(username)
+	if err != nil {
+		logger.Error("查询金币余额失败: %v", err)
+		resp := protocol.InternalServerError("查询失败，请稍后重试")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	resp := protocol.BalanceSuccess(balance)
+	resp.SetRequestId(req.RequestId)
+	return s.Response(resp)
+}
+
+// Ledger 查询自己的金币流水，按时间倒序分页
+func (h *Coin) Ledger(s *session.Session, req *protocol.LedgerRequest) error {
+	username := s.String("username")
+	if username == "" {
+		resp := protocol.Unauthorized("请先登录")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Size <= 0 {
+		req.Size = 20
+	} else if req.Size > 100 {
+		req.Size = 100
+	}
+
+	entries, total, err := h.coinService.Ledger(username, (req.Page-1)*req.Size, req.Size)
+	if err != nil {
+		logger.Error("查询金币流水失败: %v", err)
+		resp := protocol.InternalServerError("查询失败，请稍后重试")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	resp := protocol.LedgerSuccess(entries, total, req.Page, req.Size)
+	resp.SetRequestId(req.RequestId)
+	return s.Response(resp)
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"aigames/internal/models"
+	"aigames/internal/services"
+	"aigames/pkg/logger"
+	"aigames/pkg/protocol"
+
+	"github.com/lonng/nano/component"
+	"github.com/lonng/nano/session"
+)
+
+// Friend 好友处理器
+type Friend struct {
+	component.Base
+	friendService *services.FriendService
+}
+
+// NewFriend 创建好友处理器实例
+func NewFriend(friendService *services.FriendService) *Friend {
+	return &Friend{friendService: friendService}
+}
+
+// friendErrorResponse 将好友操作错误转换为响应
+func friendErrorResponse(err error) protocol.BaseResponse {
+	switch err.Error() {
+	case "用户不存在":
+		return protocol.UserNotFound()
+	case "已经是好友", "已发送过好友请求":
+		return protocol.Conflict(err.Error())
+	case "无法添加该用户为好友":
+		return protocol.Forbidden(err.Error())
+	case "不能添加自己为好友", "不能屏蔽自己", "请先取消屏蔽该用户":
+		return protocol.BadRequest(err.Error())
+	case "没有该好友请求", "好友关系不存在", "没有屏蔽该用户":
+		return protocol.NotFound(err.Error())
+	default:
+		logger.Error("好友操作失败: %v", err)
+		return protocol.InternalServerError("操作失败，请稍后重试")
+	}
+}
+
+// SendRequest 发送好友请求，对方已向自己发出请求时直接成为好友
+func (h *Friend) SendRequest(s *session.Session, req *protocol.FriendRequest) error {
+	// 验证请求参数
+	if err := protocol.ValidateRequest(req); err != nil {
+		resp := protocol.BadRequest(err.Error())
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	username := s.String("username")
+	if username == "" {
+		resp := protocol.Unauthorized("请先登录")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	status, err := h.friendService.SendRequest(username, req.Name)
+	if err != nil {
+		resp := friendErrorResponse(err)
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	message := "好友请求已发送"
+	if status == models.FriendStatusAccepted {
+		message = "已添加为好友"
+	}
+	resp := protocol.FriendActionSuccess(req.Name, status, message)
+	resp.SetRequestId(req.RequestId)
+
+	logger.Info("用户 %s 向 %s 发送好友请求", username, req.Name)
+	return s.Response(resp)
+}
+
+// Accept 接受好友请求
+func (h *Friend) Accept(s *session.Session, req *protocol.FriendRequest) error {
+	// 验证请求参数
+	if err := protocol.ValidateRequest(req); err != nil {
+		resp := protocol.BadRequest(err.Error())
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	username := s.String("username")
+	if username == "" {
+		resp := protocol.Unauthorized("请先登录")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	if err := h.friendService.Accept(username, req.Name); err != nil {
+		resp := friendErrorResponse(err)
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	resp := protocol.FriendActionSuccess(req.Name, models.FriendStatusAccepted, "已添加为好友")
+	resp.SetRequestId(req.RequestId)
+
+	logger.Info("用户 %s 接受了 %s 的好友请求", username, req.Name)
+	return s.Response(resp)
+}
+
+// Remove 删除好友，也用于拒绝或撤回好友请求
+func (h *Friend) Remove(s *session.Session, req *protocol.FriendRequest) error {
+	// 验证请求参数
+	if err := protocol.ValidateRequest(req); err != nil {
+		resp := protocol.BadRequest(err.Error())
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	username := s.String("username")
+	if username == "" {
+		resp := protocol.Unauthorized("请先登录")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	if err := h.friendService.Remove(username, req.Name); err != nil {
+		resp := friendErrorResponse(err)
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	resp := protocol.FriendActionSuccess(req.Name, 0, "已删除")
+	resp.SetRequestId(req.RequestId)
+
+	logger.Info("用户 %s 删除了与 %s 的好友关系", username, req.Name)
+	return s.Response(resp)
+}
+
+// Block 屏蔽用户，同时解除好友关系
+func (h *Friend) Block(s *session.Session, req *protocol.FriendRequest) error {
+	// 验证请求参数
+	if err := protocol.ValidateRequest(req); err != nil {
+		resp := protocol.BadRequest(err.Error())
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	username := s.String("username")
+	if username == "" {
+		resp := protocol.Unauthorized("请先登录")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	if err := h.friendService.Block(username, req.Name); err != nil {
+		resp := friendErrorResponse(err)
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	resp := protocol.FriendActionSuccess(req.Name, models.FriendStatusBlocked, "已屏蔽")
+	resp.SetRequestId(req.RequestId)
+
+	logger.Info("用户 %s 屏蔽了 %s", username, req.Name)
+	return s.Response(resp)
+}
+
+// Unblock 取消屏蔽
+func (h *Friend) Unblock(s *session.Session, req *protocol.FriendRequest) error {
+	// 验证请求参数
+	if err := protocol.ValidateRequest(req); err != nil {
+		resp := protocol.BadRequest(err.Error())
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	username := s.String("username")
+	if username == "" {
+		resp := protocol.Unauthorized("请先登录")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	if err := h.friendService.Unblock(username, req.Name); err != nil {
+		resp := friendErrorResponse(err)
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	resp := protocol.FriendActionSuccess(req.Name, 0, "已取消屏蔽")
+	resp.SetRequestId(req.RequestId)
+
+	logger.Info("用户 %s 取消屏蔽 %s", username, req.Name)
+	return s.Response(resp)
+}
+
+// List 获取好友、好友请求和屏蔽列表
+func (h *Friend) List(s *session.Session, req *protocol.ListFriendsRequest) error {
+	username := s.String("username")
+	if username == "" {
+		resp := protocol.Unauthorized("请先登录")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	entries, err := h.friendService.List(username)
+	if err != nil {
+		logger.Error("获取好友列表失败: %v", err)
+		resp := protocol.InternalServerError("获取好友列表失败")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	friends := make([]protocol.FriendData, len(entries))
+	for i, entry := range entries {
+		friends[i] = protocol.FriendData{
+			Name:       entry.Friend,
+			Status:     entry.Status,
+			StatusName: models.FriendStatusNames[entry.Status],
+			Online:     entry.Online,
+			Since:      entry.CreatedAt,
+		}
+	}
+
+	resp := protocol.FriendListSuccess(friends)
+	resp.SetRequestId(req.RequestId)
+	return s.Response(resp)
+}
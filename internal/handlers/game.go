@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+
 	"aigames/internal/models"
 	"aigames/internal/services"
 	"aigames/pkg/logger"
@@ -206,7 +208,7 @@ func (h *Game) GetGameState(s *session.Session, req *protocol.GetGameStateReques
 		var resp protocol.BaseResponse
 		if err.Error() == "房间没有活跃的游戏" {
 			resp = protocol.GameNotFound()
-		} else if err.Error() == "房间不存在" {
+		} else if errors.Is(err, services.ErrRoomNotFound) {
 			resp = protocol.RoomNotFound()
 		} else {
 			resp = protocol.InternalServerError("获取游戏状态失败")
@@ -247,7 +249,7 @@ func (h *Game) GetPlayerHand(s *session.Session, req *protocol.GetPlayerHandRequ
 		var resp protocol.BaseResponse
 		if err.Error() == "房间没有活跃的游戏" {
 			resp = protocol.GameNotFound()
-		} else if err.Error() == "房间不存在" {
+		} else if errors.Is(err, services.ErrRoomNotFound) {
 			resp = protocol.RoomNotFound()
 		} else if err.Error() == "玩家不在游戏中" {
 			resp = protocol.PlayerNotInRoom()
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"aigames/pkg/metrics"
+	"aigames/pkg/protocol"
+
+	"github.com/lonng/nano/pipeline"
+	"github.com/lonng/nano/session"
+)
+
+// 处理器指标
+var (
+	handlerSeconds = metrics.NewHistogram("aigames_handler_duration_seconds",
+		"请求从进入管道到发出响应的耗时(秒)，按路由和状态码", nil, "route", "code")
+	handlerErrors = metrics.NewCounter("aigames_handler_errors_total",
+		"状态码不是200的响应数，按路由和状态码", "route", "code")
+)
+
+// pendingKey 等待响应的请求，消息ID只在会话内唯一
+type pendingKey struct {
+	session int64
+	mid     uint64
+}
+
+// pendingRequest 等待响应的请求的路由和开始时间
+type pendingRequest struct {
+	route string
+	start time.Time
+}
+
+// HandlerMetrics 统计处理器的请求耗时、错误数和连接的会话数
+// 入站管道记录请求开始时间，出站管道在响应发出时按响应中的状态码记录耗时
+type HandlerMetrics struct {
+	mutex    sync.Mutex
+	pending  map[pendingKey]pendingRequest
+	sessions map[int64]struct{} // 发送过请求且尚未断开的会话
+}
+
+// NewHandlerMetrics 创建处理器指标统计并注册连接会话数指标
+func NewHandlerMetrics() *HandlerMetrics {
+	m := &HandlerMetrics{
+		pending:  make(map[pendingKey]pendingRequest),
+		sessions: make(map[int64]struct{}),
+	}
+	metrics.GaugeFunc("aigames_sessions", "连接中的会话数", nil, func() []metrics.Sample {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		return []metrics.Sample{{Value: float64(len(m.sessions))}}
+	})
+	return m
+}
+
+// Inbound 入站管道，放在限流之前，被限流的请求也会统计
+func (m *HandlerMetrics) Inbound() pipeline.Func {
+	return func(s *session.Session, msg *pipeline.Message) error {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+
+		m.sessions[s.ID()] = struct{}{}
+		// 通知消息没有响应，不统计耗时
+		if msg.ID > 0 {
+			m.pending[pendingKey{session: s.ID(), mid: msg.ID}] = pendingRequest{route: msg.Route, start: time.Now()}
+		}
+		return nil
+	}
+}
+
+// Outbound 出站管道，带消息ID的出站消息是请求的响应，推送消息不统计
+func (m *HandlerMetrics) Outbound() pipeline.Func {
+	return func(s *session.Session, msg *pipeline.Message) error {
+		if msg.ID == 0 {
+			return nil
+		}
+
+		key := pendingKey{session: s.ID(), mid: msg.ID}
+		m.mutex.Lock()
+		request, ok := m.pending[key]
+		delete(m.pending, key)
+		m.mutex.Unlock()
+		if !ok {
+			return nil
+		}
+
+		var resp struct {
+			Code int `json:"code"`
+		}
+		json.Unmarshal(msg.Data, &resp)
+		code := strconv.Itoa(resp.Code)
+
+		handlerSeconds.Since(request.start, request.route, code)
+		if resp.Code != protocol.StatusOK {
+			handlerErrors.Inc(request.route, code)
+		}
+		return nil
+	}
+}
+
+// SessionClosed 会话断开时清理该会话的统计，包括处理器出错未响应的请求
+func (m *HandlerMetrics) SessionClosed(s *session.Session) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.sessions, s.ID())
+	for key := range m.pending {
+		if key.session == s.ID() {
+			delete(m.pending, key)
+		}
+	}
+}
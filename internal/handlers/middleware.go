@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"aigames/internal/services"
+	"aigames/pkg/protocol"
+
+	"github.com/lonng/nano/pipeline"
+	"github.com/lonng/nano/session"
+)
+
+// errRateLimited 请求被限流，消息不再交给处理器
+var errRateLimited = errors.New("请求过于频繁")
+
+// RateLimit 创建请求限流管道，作用于所有处理器
+// 已登录的用户按用户名限流，同一用户的多个会话共享令牌；未登录时按会话限流
+func RateLimit(limiter *services.RateLimiter) pipeline.Func {
+	return func(s *session.Session, msg *pipeline.Message) error {
+		if limiter.Allow(msg.Route, rateLimitCaller(s)) {
+			return nil
+		}
+
+		// 通知消息没有响应，直接丢弃
+		if msg.ID > 0 {
+			var req protocol.BaseRequest
+			json.Unmarshal(msg.Data, &req)
+
+			resp := protocol.TooManyRequests("")
+			resp.SetRequestId(req.RequestId)
+			s.ResponseMID(msg.ID, resp)
+		}
+		return errRateLimited
+	}
+}
+
+// rateLimitCaller 获取限流的调用方标识
+func rateLimitCaller(s *session.Session) string {
+	if username := s.String("username"); username != "" {
+		return "user:" + username
+	}
+	return "session:" + strconv.FormatInt(s.ID(), 10)
+}
@@ -1,8 +1,7 @@
 package handlers
 
 import (
-	"fmt"
-	"time"
+	"errors"
 
 	"aigames/internal/models"
 	"aigames/internal/services"
@@ -16,15 +15,20 @@ import (
 // Room 房间处理器
 type Room struct {
 	component.Base
-	roomService *services.RoomService
-	gameService *services.GameService
+	roomService   *services.RoomService
+	gameService   *services.GameService
+	friendService *services.FriendService
+	presence      *services.PresenceService
 }
 
 // NewRoom 创建房间处理器实例
-func NewRoom(roomService *services.RoomService, gameService *services.GameService) *Room {
+func NewRoom(roomService *services.RoomService, gameService *services.GameService,
+	friendService *services.FriendService, presence *services.PresenceService) *Room {
 	return &Room{
-		roomService: roomService,
-		gameService: gameService,
+		roomService:   roomService,
+		gameService:   gameService,
+		friendService: friendService,
+		presence:      presence,
 	}
 }
 
@@ -47,25 +51,28 @@ func (h *Room) CreateRoom(s *session.Session, req *protocol.CreateRoomRequest) e
 		return s.Response(resp)
 	}
 
-	// 生成房间ID
-	roomID := fmt.Sprintf("room_%d", time.Now().Unix())
-
-	// 创建房间
-	room, err := h.roomService.CreateRoom(roomID, req.Name, username, req.Type, req.Password, req.AICount)
+	// 创建房间，房间ID和房间码由服务生成
+	room, err := h.roomService.CreateRoom(req.Name, username, req.Type, req.Password,
+		req.AICount, req.Hands, req.BaseStake, req.MinBalance)
 	if err != nil {
 		logger.Error("创建房间失败: %v", err)
 		resp := protocol.InternalServerError("创建房间失败")
+		if isMaintenance(err) {
+			resp = protocol.ServiceUnavailable(err.Error())
+		}
 		resp.SetRequestId(req.RequestId)
 		return s.Response(resp)
 	}
 
+	roomID := room.ID
+
 	// 房主自动加入房间
-	_, err = h.roomService.JoinRoom(roomID, username, req.Password)
+	room, err = h.roomService.JoinRoom(roomID, username, req.Password)
 	if err != nil {
 		logger.Error("房主加入房间失败: %v", err)
 		// 删除刚创建的房间
 		h.roomService.DeleteRoom(roomID)
-		resp := protocol.InternalServerError("加入房间失败")
+		resp := joinErrorResponse(err)
 		resp.SetRequestId(req.RequestId)
 		return s.Response(resp)
 	}
@@ -96,34 +103,162 @@ func (h *Room) JoinRoom(s *session.Session, req *protocol.JoinRoomRequest) error
 		return s.Response(resp)
 	}
 
-	// 加入房间
-	room, err := h.roomService.JoinRoom(req.RoomID, username, req.Password)
+	// 加入房间，携带邀请时无需密码
+	var room *models.Room
+	var err error
+	if req.InviteID != "" {
+		room, err = h.roomService.JoinRoomByInvitation(req.RoomID, req.InviteID, username)
+	} else {
+		room, err = h.roomService.JoinRoom(req.RoomID, username, req.Password)
+	}
 	if err != nil {
 		logger.Error("加入房间失败: %v", err)
+		resp := joinErrorResponse(err)
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
 
-		// 根据错误类型返回不同响应
+	// 保存房间ID到session
+	s.Set("room_id", req.RoomID)
+
+	resp := protocol.JoinRoomSuccess(room)
+	resp.SetRequestId(req.RequestId)
+
+	logger.Info("用户 %s 加入房间成功: %s", username, req.RoomID)
+	return s.Response(resp)
+}
+
+// JoinByCode 通过房间码加入房间
+func (h *Room) JoinByCode(s *session.Session, req *protocol.JoinByCodeRequest) error {
+	logger.Info("房间码加入请求: %s", req.Code)
+
+	// 验证请求参数
+	if err := protocol.ValidateRequest(req); err != nil {
+		resp := protocol.BadRequest(err.Error())
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	// 获取用户名
+	username := s.String("username")
+	if username == "" {
+		resp := protocol.Unauthorized("请先登录")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	roomID, err := h.roomService.ResolveCode(req.Code)
+	if err != nil {
+		resp := protocol.RoomNotFound()
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	room, err := h.roomService.JoinRoom(roomID, username, req.Password)
+	if err != nil {
+		logger.Error("加入房间失败: %v", err)
+		resp := joinErrorResponse(err)
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	// 保存房间ID到session
+	s.Set("room_id", roomID)
+
+	resp := protocol.JoinRoomSuccess(room)
+	resp.SetRequestId(req.RequestId)
+
+	logger.Info("用户 %s 通过房间码 %s 加入房间成功: %s", username, req.Code, roomID)
+	return s.Response(resp)
+}
+
+// isMaintenance 是否为停机排空期间被拒绝的操作
+func isMaintenance(err error) bool {
+	return errors.Is(err, services.ErrServerDraining)
+}
+
+// joinErrorResponse 根据加入房间的错误类型返回不同响应
+func joinErrorResponse(err error) protocol.BaseResponse {
+	switch {
+	case errors.Is(err, services.ErrRoomNotFound):
+		return protocol.RoomNotFound()
+	case errors.Is(err, services.ErrRoomFull):
+		return protocol.RoomFull()
+	case errors.Is(err, services.ErrRoomPassword):
+		return protocol.Unauthorized(err.Error())
+	case errors.Is(err, services.ErrInvitationNotFound):
+		return protocol.NotFound(err.Error())
+	case errors.Is(err, services.ErrInvitationExpired), errors.Is(err, services.ErrInvitationWithdrawn):
+		return protocol.BadRequest(err.Error())
+	case errors.Is(err, services.ErrKickBanned), errors.Is(err, services.ErrInsufficientBalance):
+		return protocol.Forbidden(err.Error())
+	default:
+		return protocol.InternalServerError("加入房间失败")
+	}
+}
+
+// Invite 邀请在线好友加入房间，好友凭邀请加入时无需密码
+func (h *Room) Invite(s *session.Session, req *protocol.InviteRequest) error {
+	logger.Info("房间邀请请求: %s -> %s", req.RoomID, req.Friend)
+
+	// 验证请求参数
+	if err := protocol.ValidateRequest(req); err != nil {
+		resp := protocol.BadRequest(err.Error())
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	// 获取用户名
+	username := s.String("username")
+	if username == "" {
+		resp := protocol.Unauthorized("请先登录")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	if !h.friendService.AreFriends(username, req.Friend) {
+		resp := protocol.Forbidden("只能邀请好友")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	if !h.presence.IsOnline(req.Friend) {
+		resp := protocol.BadRequest("好友不在线")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	invitation, err := h.roomService.CreateInvitation(req.RoomID, username, req.Friend)
+	if err != nil {
 		var resp protocol.BaseResponse
-		if err.Error() == "房间不存在" {
+		switch {
+		case errors.Is(err, services.ErrRoomNotFound):
 			resp = protocol.RoomNotFound()
-		} else if err.Error() == "房间已满" {
+		case errors.Is(err, services.ErrRoomFull):
 			resp = protocol.RoomFull()
-		} else if err.Error() == "房间密码错误" {
-			resp = protocol.Unauthorized("房间密码错误")
-		} else {
-			resp = protocol.InternalServerError("加入房间失败")
+		case errors.Is(err, services.ErrPlayerNotInRoom):
+			resp = protocol.PlayerNotInRoom()
+		case err.Error() == "对方已在房间中":
+			resp = protocol.Conflict(err.Error())
+		default:
+			logger.Error("创建邀请失败: %v", err)
+			resp = protocol.InternalServerError("邀请失败")
 		}
-
 		resp.SetRequestId(req.RequestId)
 		return s.Response(resp)
 	}
 
-	// 保存房间ID到session
-	s.Set("room_id", req.RoomID)
+	if err := h.presence.Push(req.Friend, protocol.RouteRoomInvite, protocol.NewInvitationData(invitation)); err != nil {
+		logger.Error("推送房间邀请失败: %v", err)
+		resp := protocol.BadRequest("好友不在线")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
 
-	resp := protocol.JoinRoomSuccess(room)
+	resp := protocol.InviteSuccess(invitation)
 	resp.SetRequestId(req.RequestId)
 
-	logger.Info("用户 %s 加入房间成功: %s", username, req.RoomID)
+	logger.Info("用户 %s 邀请 %s 加入房间 %s", username, req.Friend, req.RoomID)
 	return s.Response(resp)
 }
 
@@ -147,7 +282,7 @@ func (h *Room) LeaveRoom(s *session.Session, req *protocol.LeaveRoomRequest) err
 	}
 
 	// 离开房间
-	err := h.roomService.LeaveRoom(req.RoomID, username)
+	newOwner, closed, err := h.roomService.LeaveRoom(req.RoomID, username)
 	if err != nil {
 		logger.Error("离开房间失败: %v", err)
 		resp := protocol.InternalServerError("离开房间失败")
@@ -158,6 +293,16 @@ func (h *Room) LeaveRoom(s *session.Session, req *protocol.LeaveRoomRequest) err
 	// 清除session中的房间ID
 	s.Remove("room_id")
 
+	// 房间已关闭，停止留下的AI玩家
+	if closed {
+		h.gameService.StopAIControllers(req.RoomID)
+	}
+
+	// 房主离开，通知房间中的玩家新房主
+	if newOwner != "" {
+		h.notifyOwnerChanged(req.RoomID, newOwner)
+	}
+
 	resp := protocol.LeaveRoomSuccess()
 	resp.SetRequestId(req.RequestId)
 
@@ -165,32 +310,49 @@ func (h *Room) LeaveRoom(s *session.Session, req *protocol.LeaveRoomRequest) err
 	return s.Response(resp)
 }
 
-// GetRoomList 获取房间列表
+// GetRoomList 获取房间列表，支持过滤、排序和游标分页
 func (h *Room) GetRoomList(s *session.Session, req *protocol.GetRoomListRequest) error {
 	logger.Info("获取房间列表请求")
 
-	// 获取房间列表
-	var rooms []*models.Room
-	if req.Type == models.RoomTypePublic {
-		rooms = h.roomService.GetPublicRooms()
-	} else {
-		rooms = h.roomService.GetAllRooms()
+	// 验证请求参数
+	if err := protocol.ValidateRequest(req); err != nil {
+		resp := protocol.BadRequest(err.Error())
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Size <= 0 {
+		req.Size = 10
+	} else if req.Size > 100 {
+		req.Size = 100
 	}
 
-	// 分页处理
-	total := len(rooms)
-	start := (req.Page - 1) * req.Size
-	end := start + req.Size
+	query := services.RoomQuery{
+		Type:        req.Type,
+		Status:      req.Status,
+		OpenSeats:   req.OpenSeats,
+		HasPassword: req.HasPassword,
+		Keyword:     req.Keyword,
+		SortBy:      req.SortBy,
+		Order:       req.Order,
+		Cursor:      req.Cursor,
+		Limit:       req.Size,
+	}
+	if req.Cursor == "" {
+		query.Offset = (req.Page - 1) * req.Size
+	}
 
-	if start > total {
-		rooms = []*models.Room{}
-	} else if end > total {
-		rooms = rooms[start:]
-	} else {
-		rooms = rooms[start:end]
+	page, err := h.roomService.ListRooms(query)
+	if err != nil {
+		resp := protocol.BadRequest(err.Error())
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
 	}
 
-	resp := protocol.RoomListSuccess(rooms, total, req.Page, req.Size)
+	resp := protocol.RoomListSuccess(page.Rooms, page.Total, req.Page, req.Size, page.NextCursor)
 	resp.SetRequestId(req.RequestId)
 
 	return s.Response(resp)
@@ -239,6 +401,9 @@ func (h *Room) DeleteRoom(s *session.Session, req *protocol.DeleteRoomRequest) e
 		return s.Response(resp)
 	}
 
+	// 停止房间中的AI控制器
+	h.gameService.StopAIControllers(req.RoomID)
+
 	resp := protocol.DeleteRoomSuccess()
 	resp.SetRequestId(req.RequestId)
 
@@ -271,7 +436,7 @@ func (h *Room) SetReady(s *session.Session, req *protocol.SetReadyRequest) error
 		logger.Error("设置准备状态失败: %v", err)
 
 		var resp protocol.BaseResponse
-		if err.Error() == "房间不存在" {
+		if errors.Is(err, services.ErrRoomNotFound) {
 			resp = protocol.RoomNotFound()
 		} else if err.Error() == "玩家不在游戏中" {
 			resp = protocol.PlayerNotInRoom()
@@ -323,32 +488,228 @@ func (h *Room) StartGame(s *session.Session, req *protocol.StartGameRequest) err
 		return s.Response(resp)
 	}
 
-	// 开始游戏
-	_, err = h.roomService.StartGame(req.RoomID)
-	if err != nil {
+	// 开始游戏，同时启动AI控制器
+	if _, err := h.gameService.StartGame(req.RoomID); err != nil {
 		logger.Error("开始游戏失败: %v", err)
+		resp := protocol.BadRequest(err.Error())
+		if isMaintenance(err) {
+			resp = protocol.ServiceUnavailable(err.Error())
+		}
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	resp := protocol.StartGameSuccess()
+	resp.SetRequestId(req.RequestId)
+
+	logger.Info("房间 %s 游戏开始", req.RoomID)
+	return s.Response(resp)
+}
+
+// KickPlayer 房主踢出玩家，被踢出的玩家短时间内不能重新加入
+func (h *Room) KickPlayer(s *session.Session, req *protocol.KickPlayerRequest) error {
+	logger.Info("踢出玩家请求: %s, player=%s", req.RoomID, req.Player)
+
+	// 验证请求参数
+	if err := protocol.ValidateRequest(req); err != nil {
 		resp := protocol.BadRequest(err.Error())
 		resp.SetRequestId(req.RequestId)
 		return s.Response(resp)
 	}
 
-	// 启动AI控制器
-	if err := h.gameService.StartAIControllers(req.RoomID); err != nil {
-		logger.Error("启动AI控制器失败: %v", err)
+	// 获取用户名
+	username := s.String("username")
+	if username == "" {
+		resp := protocol.Unauthorized("请先登录")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
 	}
 
-	// 检查是否第一个玩家是AI，如果是则通知其行动
-	game, err := h.gameService.GetGameByRoom(req.RoomID)
-	if err == nil {
-		currentPlayer := game.GetPlayer(game.CurrentTurn)
-		if currentPlayer != nil && currentPlayer.IsAI {
-			h.gameService.NotifyAITurn(req.RoomID, currentPlayer.UserName)
-		}
+	rejoinAfter, err := h.roomService.KickPlayer(req.RoomID, username, req.Player)
+	if err != nil {
+		resp := ownerErrorResponse(err, "踢出玩家失败")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
 	}
 
-	resp := protocol.StartGameSuccess()
+	// 与离开房间一致，清除被踢出玩家session中的房间ID
+	if ks, online := h.presence.Session(req.Player); online && ks.String("room_id") == req.RoomID {
+		ks.Remove("room_id")
+	}
+
+	// 通知被踢出的玩家，玩家不在线时忽略
+	h.presence.Push(req.Player, protocol.RouteRoomKicked, protocol.RoomKickedData{
+		RoomID:      req.RoomID,
+		By:          username,
+		RejoinAfter: rejoinAfter.Format("2006-01-02 15:04:05"),
+	})
+
+	resp := protocol.KickPlayerSuccess(req.Player, rejoinAfter)
 	resp.SetRequestId(req.RequestId)
+	return s.Response(resp)
+}
 
-	logger.Info("房间 %s 游戏开始", req.RoomID)
+// TransferOwner 房主将房主身份转给房间中的另一位玩家
+func (h *Room) TransferOwner(s *session.Session, req *protocol.TransferOwnerRequest) error {
+	logger.Info("转让房主请求: %s, player=%s", req.RoomID, req.Player)
+
+	// 验证请求参数
+	if err := protocol.ValidateRequest(req); err != nil {
+		resp := protocol.BadRequest(err.Error())
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	// 获取用户名
+	username := s.String("username")
+	if username == "" {
+		resp := protocol.Unauthorized("请先登录")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	room, err := h.roomService.TransferOwnership(req.RoomID, username, req.Player)
+	if err != nil {
+		resp := ownerErrorResponse(err, "转让房主失败")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	h.notifyOwnerChanged(req.RoomID, room.Owner)
+
+	resp := protocol.TransferOwnerSuccess(room)
+	resp.SetRequestId(req.RequestId)
 	return s.Response(resp)
 }
+
+// AddAI 房主在游戏开始前添加一个AI座位
+func (h *Room) AddAI(s *session.Session, req *protocol.AddAIRequest) error {
+	logger.Info("添加AI座位请求: %s", req.RoomID)
+
+	// 验证请求参数
+	if err := protocol.ValidateRequest(req); err != nil {
+		resp := protocol.BadRequest(err.Error())
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	// 获取用户名
+	username := s.String("username")
+	if username == "" {
+		resp := protocol.Unauthorized("请先登录")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	room, err := h.roomService.AddAISeat(req.RoomID, username)
+	if err != nil {
+		resp := ownerErrorResponse(err, "添加AI玩家失败")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	resp := protocol.AddAISuccess(room)
+	resp.SetRequestId(req.RequestId)
+	return s.Response(resp)
+}
+
+// RemoveAI 房主在游戏开始前移除一个AI座位
+func (h *Room) RemoveAI(s *session.Session, req *protocol.RemoveAIRequest) error {
+	logger.Info("移除AI座位请求: %s, player=%s", req.RoomID, req.Player)
+
+	// 验证请求参数
+	if err := protocol.ValidateRequest(req); err != nil {
+		resp := protocol.BadRequest(err.Error())
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	// 获取用户名
+	username := s.String("username")
+	if username == "" {
+		resp := protocol.Unauthorized("请先登录")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	room, err := h.roomService.RemoveAISeat(req.RoomID, username, req.Player)
+	if err != nil {
+		resp := ownerErrorResponse(err, "移除AI玩家失败")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	resp := protocol.RemoveAISuccess(room)
+	resp.SetRequestId(req.RequestId)
+	return s.Response(resp)
+}
+
+// EndSession 房主结束多局对战，正在进行的一局打完后结束
+func (h *Room) EndSession(s *session.Session, req *protocol.EndSessionRequest) error {
+	logger.Info("结束对战请求: %s", req.RoomID)
+
+	// 验证请求参数
+	if err := protocol.ValidateRequest(req); err != nil {
+		resp := protocol.BadRequest(err.Error())
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	// 获取用户名
+	username := s.String("username")
+	if username == "" {
+		resp := protocol.Unauthorized("请先登录")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	room, err := h.roomService.EndSession(req.RoomID, username)
+	if err != nil {
+		resp := ownerErrorResponse(err, "结束对战失败")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	resp := protocol.EndSessionSuccess(room)
+	resp.SetRequestId(req.RequestId)
+
+	logger.Info("房主 %s 结束房间 %s 的对战", username, req.RoomID)
+	return s.Response(resp)
+}
+
+// ownerErrorResponse 根据房主操作的错误类型返回不同响应
+func ownerErrorResponse(err error, fallback string) protocol.BaseResponse {
+	switch {
+	case errors.Is(err, services.ErrRoomNotFound):
+		return protocol.RoomNotFound()
+	case errors.Is(err, services.ErrRoomFull):
+		return protocol.RoomFull()
+	case errors.Is(err, services.ErrPlayerNotInRoom):
+		return protocol.PlayerNotInRoom()
+	}
+	switch err.Error() {
+	case "只有房主可以操作":
+		return protocol.Forbidden(err.Error())
+	case "游戏已开始", "不能踢出自己", "请使用移除AI座位", "你已经是房主",
+		"不能将房主转给AI玩家", "该玩家不是AI玩家", "没有进行中的对战", "对战进行中":
+		return protocol.BadRequest(err.Error())
+	default:
+		logger.Error("%s: %v", fallback, err)
+		return protocol.InternalServerError(fallback)
+	}
+}
+
+// notifyOwnerChanged 通知房间中的真人玩家房主已变更
+func (h *Room) notifyOwnerChanged(roomID, owner string) {
+	room, err := h.roomService.GetRoom(roomID)
+	if err != nil || room.CurrentGame == nil {
+		return
+	}
+
+	data := protocol.RoomOwnerData{RoomID: roomID, Owner: owner}
+	for _, player := range room.CurrentGame.Players {
+		if player != nil && !player.IsAI {
+			h.presence.Push(player.UserName, protocol.RouteRoomOwnerChanged, data)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"aigames/internal/models"
+	"aigames/internal/services"
+	"aigames/pkg/logger"
+	"aigames/pkg/protocol"
+
+	"github.com/lonng/nano/component"
+	"github.com/lonng/nano/session"
+)
+
+// Tournament 比赛处理器
+type Tournament struct {
+	component.Base
+	tournamentService *services.TournamentService
+}
+
+// NewTournament 创建比赛处理器实例
+func NewTournament(tournamentService *services.TournamentService) *Tournament {
+	return &Tournament{tournamentService: tournamentService}
+}
+
+// tournamentErrorResponse 将比赛操作错误转换为响应
+func tournamentErrorResponse(err error) protocol.BaseResponse {
+	switch err.Error() {
+	case "比赛不存在", "未报名该比赛":
+		return protocol.NotFound(err.Error())
+	case "只有组织者可以操作":
+		return protocol.Forbidden(err.Error())
+	case "已报名该比赛", "报名人数已满":
+		return protocol.Conflict(err.Error())
+	case "赛制无效", "瑞士制至少需要一轮", "每桌至少一局", "报名已截止", "比赛已开始",
+		"比赛状态不正确", "现在不能签到", "签到人数不足":
+		return protocol.BadRequest(err.Error())
+	default:
+		logger.Error("比赛操作失败: %v", err)
+		return protocol.InternalServerError("操作失败，请稍后重试")
+	}
+}
+
+// Create 创建比赛，创建者为组织者
+func (h *Tournament) Create(s *session.Session, req *protocol.CreateTournamentRequest) error {
+	// 验证请求参数
+	if err := protocol.ValidateRequest(req); err != nil {
+		resp := protocol.BadRequest(err.Error())
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	username := s.String("username")
+	if username == "" {
+		resp := protocol.Unauthorized("请先登录")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	tournament, err := h.tournamentService.Create(username, req.Name, req.Format,
+		req.Rounds, req.HandsPerTable, req.MaxPlayers)
+	if err != nil {
+		resp := tournamentErrorResponse(err)
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	resp := protocol.TournamentSuccess(tournament, "创建比赛成功")
+	resp.SetRequestId(req.RequestId)
+	return s.Response(resp)
+}
+
+// Register 报名参赛
+func (h *Tournament) Register(s *session.Session, req *protocol.TournamentRequest) error {
+	return h.handle(s, req, h.tournamentService.Register, "报名成功")
+}
+
+// Unregister 取消报名
+func (h *Tournament) Unregister(s *session.Session, req *protocol.TournamentRequest) error {
+	return h.handle(s, req, h.tournamentService.Unregister, "已取消报名")
+}
+
+// OpenCheckIn 组织者开放签到
+func (h *Tournament) OpenCheckIn(s *session.Session, req *protocol.TournamentRequest) error {
+	return h.handle(s, req, h.tournamentService.OpenCheckIn, "已开放签到")
+}
+
+// CheckIn 选手签到
+func (h *Tournament) CheckIn(s *session.Session, req *protocol.TournamentRequest) error {
+	return h.handle(s, req, h.tournamentService.CheckIn, "签到成功")
+}
+
+// Start 组织者开始比赛，签到的选手会收到第一轮编桌推送
+func (h *Tournament) Start(s *session.Session, req *protocol.TournamentRequest) error {
+	return h.handle(s, req, h.tournamentService.StartTournament, "比赛开始")
+}
+
+// Cancel 组织者取消比赛
+func (h *Tournament) Cancel(s *session.Session, req *protocol.TournamentRequest) error {
+	return h.handle(s, req, h.tournamentService.Cancel, "比赛已取消")
+}
+
+// Get 获取比赛详情，包括排名和各轮编桌
+func (h *Tournament) Get(s *session.Session, req *protocol.TournamentRequest) error {
+	// 验证请求参数
+	if err := protocol.ValidateRequest(req); err != nil {
+		resp := protocol.BadRequest(err.Error())
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	tournament, rounds, err := h.tournamentService.Get(req.TournamentID)
+	if err != nil {
+		resp := tournamentErrorResponse(err)
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	resp := protocol.TournamentDetailSuccess(tournament, rounds)
+	resp.SetRequestId(req.RequestId)
+	return s.Response(resp)
+}
+
+// List 获取比赛列表
+func (h *Tournament) List(s *session.Session, req *protocol.ListTournamentsRequest) error {
+	resp := protocol.TournamentListSuccess(h.tournamentService.List())
+	resp.SetRequestId(req.RequestId)
+	return s.Response(resp)
+}
+
+// handle 处理针对某个比赛的操作，需要登录
+func (h *Tournament) handle(s *session.Session, req *protocol.TournamentRequest,
+	action func(id, username string) (*models.Tournament, error), message string) error {
+	// 验证请求参数
+	if err := protocol.ValidateRequest(req); err != nil {
+		resp := protocol.BadRequest(err.Error())
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	username := s.String("username")
+	if username == "" {
+		resp := protocol.Unauthorized("请先登录")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	tournament, err := action(req.TournamentID, username)
+	if err != nil {
+		resp := tournamentErrorResponse(err)
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	resp := protocol.TournamentSuccess(tournament, message)
+	resp.SetRequestId(req.RequestId)
+
+	logger.Info("用户 %s 比赛操作 %s: %s", username, tournament.Name, message)
+	return s.Response(resp)
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"net"
 	"time"
 
 	"aigames/internal/models"
@@ -16,12 +18,31 @@ type (
 	// Handler 处理器结构体
 	User struct {
 		component.Base
-		userService *services.UserService
+		userService   *services.UserService
+		tokenService  *services.TokenService
+		roomService   *services.RoomService
+		friendService *services.FriendService
+		presence      *services.PresenceService
 	}
 )
 
-func NewUser(userService *services.UserService) *User {
-	return &User{userService: userService}
+func NewUser(userService *services.UserService, tokenService *services.TokenService, roomService *services.RoomService,
+	friendService *services.FriendService, presence *services.PresenceService) *User {
+	return &User{
+		userService:   userService,
+		tokenService:  tokenService,
+		roomService:   roomService,
+		friendService: friendService,
+		presence:      presence,
+	}
+}
+
+// tokenErrorResponse 将凭证校验错误转换为响应
+func tokenErrorResponse(err error) protocol.BaseResponse {
+	if errors.Is(err, services.ErrTokenExpired) {
+		return protocol.TokenExpired()
+	}
+	return protocol.TokenInvalid()
 }
 
 // Login 登录处理方法
@@ -35,38 +56,122 @@ func (h *User) Login(s *session.Session, req *protocol.LoginRequest) error {
 		return s.Response(resp)
 	}
 
+	addr := remoteHost(s)
+
 	// 从数据库获取用户
 	user, err := h.userService.GetUser(req.Name)
 	if err != nil {
+		user = nil
+	}
+
+	// 检查账户和来源地址是否被锁定
+	if unlockAt, allowed := h.userService.CheckLoginAllowed(user, addr); !allowed {
+		logger.Warn("用户 %s 登录被拒绝，账户或地址 %s 已锁定至 %v", req.Name, addr, unlockAt)
+		resp := protocol.UserLocked(unlockAt)
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	if user == nil {
+		h.recordLoginFailure("", addr)
 		resp := protocol.UserNotFound()
 		resp.SetRequestId(req.RequestId)
 		return s.Response(resp)
 	}
 
 	// 验证密码
-	hashedPassword := h.userService.HashPassword(req.Password)
-	if user.Password != hashedPassword {
+	if !h.userService.VerifyPassword(user, req.Password) {
+		if unlockAt, locked := h.recordLoginFailure(user.Name, addr); locked {
+			logger.Warn("用户 %s 登录失败次数过多，账户或地址 %s 锁定至 %v", user.Name, addr, unlockAt)
+			resp := protocol.UserLocked(unlockAt)
+			resp.SetRequestId(req.RequestId)
+			return s.Response(resp)
+		}
 		resp := protocol.PasswordIncorrect()
 		resp.SetRequestId(req.RequestId)
 		return s.Response(resp)
 	}
 
-	// 更新最后登录时间
-	if err := h.userService.UpdateLastLogin(req.Name); err != nil {
+	// 密码正确后才提示封禁，避免泄露账户状态
+	if user.Banned {
+		logger.Warn("用户 %s 已被封禁，登录被拒绝", user.Name)
+		resp := protocol.UserBanned(user.BanReason)
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	// 旧算法或旧参数的密码哈希，登录成功后使用当前算法重新哈希
+	if h.userService.NeedsRehash(user) {
+		if err := h.userService.SetPassword(user, req.Password); err != nil {
+			logger.Error("重新哈希密码失败: %v", err)
+		} else if err := h.userService.SaveUser(user); err != nil {
+			logger.Error("保存重新哈希的密码失败: %v", err)
+		} else {
+			logger.Info("用户 %s 的密码已迁移到 %s", user.Name, user.PasswordAlgo)
+		}
+	}
+
+	// 清除失败计数并更新最后登录时间
+	if err := h.userService.RecordLoginSuccess(req.Name); err != nil {
 		logger.Error("更新登录时间失败: %v", err)
 	}
 
+	// 签发登录凭证，用于断线后恢复会话
+	token, expiresAt, err := h.tokenService.Issue(user.Name)
+	if err != nil {
+		logger.Error("签发凭证失败: %v", err)
+		resp := protocol.InternalServerError("登录失败，请稍后重试")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
 	// 登录成功，保存用户信息到session
-	s.Set("username", user.Name)
+	h.switchUser(s, user.Name)
 
 	// 登录成功
-	resp := protocol.LoginSuccess(user.Name, user.Age)
+	resp := protocol.LoginSuccess(user, token, expiresAt)
 	resp.SetRequestId(req.RequestId)
 
 	logger.Info("用户 %s 登录成功", req.Name)
 	return s.Response(resp)
 }
 
+// switchUser 切换会话中的用户并更新在线状态，username为空表示退出登录
+func (h *User) switchUser(s *session.Session, username string) {
+	if previous := s.String("username"); previous != "" && previous != username {
+		h.presence.SetOffline(previous, s)
+	}
+
+	if username == "" {
+		s.Remove("username")
+		return
+	}
+	s.Set("username", username)
+	h.presence.SetOnline(username, s)
+}
+
+// recordLoginFailure 记录登录失败，返回是否因此被锁定
+func (h *User) recordLoginFailure(name, addr string) (time.Time, bool) {
+	unlockAt, locked, err := h.userService.RecordLoginFailure(name, addr)
+	if err != nil {
+		logger.Error("记录登录失败次数失败: %v", err)
+	}
+	return unlockAt, locked
+}
+
+// remoteHost 获取会话的来源地址，不包含端口
+func remoteHost(s *session.Session) string {
+	addr := s.RemoteAddr()
+	if addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
+
 // RestoreSession 恢复会话处理方法
 func (h *User) RestoreSession(s *session.Session, req *protocol.RestoreSessionRequest) error {
 	logger.Info("恢复会话请求: %s", req.Name)
@@ -78,22 +183,107 @@ func (h *User) RestoreSession(s *session.Session, req *protocol.RestoreSessionRe
 		return s.Response(resp)
 	}
 
+	// 校验登录凭证
+	claims, err := h.tokenService.Verify(req.Token)
+	if err != nil {
+		logger.Info("恢复会话失败: %v", err)
+		resp := tokenErrorResponse(err)
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	// 用户名只能由凭证决定
+	if req.Name != "" && req.Name != claims.Subject {
+		resp := protocol.TokenInvalid()
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
 	// 从数据库获取用户
-	user, err := h.userService.GetUser(req.Name)
+	user, err := h.userService.GetUser(claims.Subject)
 	if err != nil {
 		resp := protocol.UserNotFound()
 		resp.SetRequestId(req.RequestId)
 		return s.Response(resp)
 	}
+	if user.Banned {
+		resp := protocol.UserBanned(user.BanReason)
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
 
 	// 恢复会话，保存用户信息到session
-	s.Set("username", user.Name)
+	h.switchUser(s, user.Name)
+
+	// 恢复成功，继续使用原凭证
+	resp := protocol.RestoreSessionSuccess(user, req.Token, claims.ExpiresAt.Time)
+	resp.SetRequestId(req.RequestId)
+
+	logger.Info("用户 %s 会话恢复成功", user.Name)
+	return s.Response(resp)
+}
+
+// RefreshToken 刷新凭证处理方法，旧凭证随即失效
+func (h *User) RefreshToken(s *session.Session, req *protocol.RefreshTokenRequest) error {
+	// 验证请求参数
+	if err := protocol.ValidateRequest(req); err != nil {
+		resp := protocol.BadRequest(err.Error())
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	token, expiresAt, claims, err := h.tokenService.Refresh(req.Token)
+	if err != nil {
+		if errors.Is(err, services.ErrTokenExpired) || errors.Is(err, services.ErrTokenInvalid) {
+			resp := tokenErrorResponse(err)
+			resp.SetRequestId(req.RequestId)
+			return s.Response(resp)
+		}
+		logger.Error("刷新凭证失败: %v", err)
+		resp := protocol.InternalServerError("刷新凭证失败，请稍后重试")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	// 已登录的会话只能刷新自己的凭证
+	if username := s.String("username"); username != "" && username != claims.Subject {
+		resp := protocol.TokenInvalid()
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	resp := protocol.RefreshTokenSuccess(token, expiresAt)
+	resp.SetRequestId(req.RequestId)
 
-	// 恢复成功
-	resp := protocol.RestoreSessionSuccess(user.Name, user.Age)
+	logger.Info("用户 %s 刷新凭证成功", claims.Subject)
+	return s.Response(resp)
+}
+
+// Logout 退出登录处理方法，吊销凭证并清除会话中的用户信息
+func (h *User) Logout(s *session.Session, req *protocol.LogoutRequest) error {
+	// 验证请求参数
+	if err := protocol.ValidateRequest(req); err != nil {
+		resp := protocol.BadRequest(err.Error())
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	// 凭证已过期或无效时无需吊销，直接退出
+	if err := h.tokenService.Revoke(req.Token); err != nil &&
+		!errors.Is(err, services.ErrTokenExpired) && !errors.Is(err, services.ErrTokenInvalid) {
+		logger.Error("吊销凭证失败: %v", err)
+		resp := protocol.InternalServerError("退出登录失败，请稍后重试")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	username := s.String("username")
+	h.switchUser(s, "")
+
+	resp := protocol.LogoutSuccess()
 	resp.SetRequestId(req.RequestId)
 
-	logger.Info("用户 %s 会话恢复成功", req.Name)
+	logger.Info("用户 %s 退出登录", username)
 	return s.Response(resp)
 }
 
@@ -108,6 +298,13 @@ func (h *User) Signup(s *session.Session, req *protocol.SignupRequest) error {
 		return s.Response(resp)
 	}
 
+	// AI玩家和已注销用户的名称前缀保留给系统使用
+	if models.IsReservedName(req.Name) {
+		resp := protocol.BadRequest("用户名不能以" + models.AINamePrefix + "或" + models.DeletedUserPrefix + "开头")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
 	// 检查用户是否已存在
 	if h.userService.UserExists(req.Name) {
 		resp := protocol.UserExists()
@@ -118,11 +315,16 @@ func (h *User) Signup(s *session.Session, req *protocol.SignupRequest) error {
 	// 创建新用户
 	user := &models.User{
 		Name:        req.Name,
-		Password:    h.userService.HashPassword(req.Password),
 		Age:         req.Age,
 		CreatedAt:   time.Now(),
 		LastLoginAt: time.Now(),
 	}
+	if err := h.userService.SetPassword(user, req.Password); err != nil {
+		logger.Error("加密密码失败: %v", err)
+		resp := protocol.InternalServerError("注册失败，请稍后重试")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
 
 	// 保存用户到数据库
 	if err := h.userService.SaveUser(user); err != nil {
@@ -139,3 +341,175 @@ func (h *User) Signup(s *session.Session, req *protocol.SignupRequest) error {
 	logger.Info("用户 %s 注册成功", req.Name)
 	return s.Response(resp)
 }
+
+// ChangePassword 修改密码处理方法，修改后此前签发的凭证全部失效
+func (h *User) ChangePassword(s *session.Session, req *protocol.ChangePasswordRequest) error {
+	// 验证请求参数
+	if err := protocol.ValidateRequest(req); err != nil {
+		resp := protocol.BadRequest(err.Error())
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	username := s.String("username")
+	if username == "" {
+		resp := protocol.Unauthorized("请先登录")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	// 与登录共用失败计数，避免绕过锁定猜测密码
+	addr := remoteHost(s)
+	user, err := h.userService.GetUser(username)
+	if err != nil {
+		resp := protocol.UserNotFound()
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+	if unlockAt, allowed := h.userService.CheckLoginAllowed(user, addr); !allowed {
+		resp := protocol.UserLocked(unlockAt)
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	if err := h.userService.ChangePassword(username, req.OldPassword, req.NewPassword); err != nil {
+		var resp protocol.BaseResponse
+		switch {
+		case errors.Is(err, services.ErrPasswordIncorrect):
+			resp = protocol.PasswordIncorrect()
+			if unlockAt, locked := h.recordLoginFailure(username, addr); locked {
+				logger.Warn("用户 %s 修改密码时原密码错误次数过多，账户或地址 %s 锁定至 %v", username, addr, unlockAt)
+				resp = protocol.UserLocked(unlockAt)
+			}
+		case errors.Is(err, services.ErrUserNotFound):
+			resp = protocol.UserNotFound()
+		default:
+			logger.Error("修改密码失败: %v", err)
+			resp = protocol.InternalServerError("修改密码失败，请稍后重试")
+		}
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	// 吊销旧凭证并为当前会话签发新凭证
+	if err := h.tokenService.RevokeUser(username); err != nil {
+		logger.Error("吊销用户 %s 的凭证失败: %v", username, err)
+	}
+	token, expiresAt, err := h.tokenService.Issue(username)
+	if err != nil {
+		logger.Error("签发凭证失败: %v", err)
+		resp := protocol.InternalServerError("密码已修改，请重新登录")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	resp := protocol.ChangePasswordSuccess(token, expiresAt)
+	resp.SetRequestId(req.RequestId)
+
+	logger.Info("用户 %s 修改密码成功", username)
+	return s.Response(resp)
+}
+
+// UpdateProfile 修改资料处理方法
+func (h *User) UpdateProfile(s *session.Session, req *protocol.UpdateProfileRequest) error {
+	// 验证请求参数
+	if err := protocol.ValidateRequest(req); err != nil {
+		resp := protocol.BadRequest(err.Error())
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	username := s.String("username")
+	if username == "" {
+		resp := protocol.Unauthorized("请先登录")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	user, err := h.userService.UpdateProfile(username, req.Nickname, req.Avatar, req.Age)
+	if err != nil {
+		var resp protocol.BaseResponse
+		if errors.Is(err, services.ErrUserNotFound) {
+			resp = protocol.UserNotFound()
+		} else {
+			logger.Error("修改资料失败: %v", err)
+			resp = protocol.InternalServerError("修改资料失败，请稍后重试")
+		}
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	resp := protocol.UpdateProfileSuccess(user)
+	resp.SetRequestId(req.RequestId)
+
+	logger.Info("用户 %s 修改资料成功", username)
+	return s.Response(resp)
+}
+
+// DeleteAccount 注销账户处理方法，历史对局以匿名名称保留
+func (h *User) DeleteAccount(s *session.Session, req *protocol.DeleteAccountRequest) error {
+	// 验证请求参数
+	if err := protocol.ValidateRequest(req); err != nil {
+		resp := protocol.BadRequest(err.Error())
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	username := s.String("username")
+	if username == "" {
+		resp := protocol.Unauthorized("请先登录")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	user, err := h.userService.GetUser(username)
+	if err != nil {
+		resp := protocol.UserNotFound()
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	// 与登录共用失败计数，避免绕过锁定猜测密码
+	addr := remoteHost(s)
+	if unlockAt, allowed := h.userService.CheckLoginAllowed(user, addr); !allowed {
+		resp := protocol.UserLocked(unlockAt)
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	if !h.userService.VerifyPassword(user, req.Password) {
+		if unlockAt, locked := h.recordLoginFailure(user.Name, addr); locked {
+			logger.Warn("用户 %s 注销账户密码错误次数过多，账户或地址 %s 锁定至 %v", user.Name, addr, unlockAt)
+			resp := protocol.UserLocked(unlockAt)
+			resp.SetRequestId(req.RequestId)
+			return s.Response(resp)
+		}
+		resp := protocol.PasswordIncorrect()
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	// 游戏中的玩家需要先离开房间
+	if roomID, found := h.roomService.FindPlayerRoom(username); found {
+		resp := protocol.Conflict("请先离开房间 " + roomID)
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	alias, err := h.userService.DeleteUser(username)
+	if err != nil {
+		logger.Error("注销账户失败: %v", err)
+		resp := protocol.InternalServerError("注销账户失败，请稍后重试")
+		resp.SetRequestId(req.RequestId)
+		return s.Response(resp)
+	}
+
+	h.roomService.RenameOwner(username, alias)
+	h.switchUser(s, "")
+
+	resp := protocol.DeleteAccountSuccess()
+	resp.SetRequestId(req.RequestId)
+
+	logger.Info("用户 %s 已注销，历史记录匿名为 %s", username, alias)
+	return s.Response(resp)
+}
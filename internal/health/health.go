@@ -0,0 +1,197 @@
+// Package health 存活和就绪检查接口，供负载均衡探测
+//
+// /healthz 只要进程能响应就返回200；/readyz 在所有就绪检查通过时返回200，否则返回503。
+// 两个接口都返回每项检查的结果。
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"aigames/pkg/logger"
+)
+
+// 检查结果状态
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// checkTimeout 单项检查的超时时间，超时视为失败
+const checkTimeout = 2 * time.Second
+
+// CheckResult 单项检查的结果
+type CheckResult struct {
+	Status     string  `json:"status"`            // ok 或 fail
+	Message    string  `json:"message,omitempty"` // 失败原因或补充信息
+	DurationMs float64 `json:"duration_ms"`       // 检查耗时(毫秒)
+}
+
+// Report 检查报告
+type Report struct {
+	Status    string                  `json:"status"`     // 所有检查都通过时为 ok
+	StartedAt time.Time               `json:"started_at"` // 进程启动时间
+	Uptime    float64                 `json:"uptime"`     // 运行时长(秒)
+	Checks    map[string]*CheckResult `json:"checks"`     // 各项检查结果
+}
+
+// check 一项就绪检查
+type check struct {
+	name string
+	fn   func() error
+}
+
+// Checker 存活和就绪检查
+type Checker struct {
+	startedAt time.Time
+	draining  atomic.Bool
+
+	mutex  sync.RWMutex
+	checks []check
+}
+
+// NewChecker 创建检查器，内置"未处于排空状态"的就绪检查
+func NewChecker() *Checker {
+	c := &Checker{startedAt: time.Now()}
+	c.Add("draining", func() error {
+		if c.Draining() {
+			return fmt.Errorf("服务器正在排空，不再接受新的连接")
+		}
+		return nil
+	})
+	return c
+}
+
+// Add 添加就绪检查，fn 返回错误表示未就绪
+func (c *Checker) Add(name string, fn func() error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// SetDraining 设置排空状态，排空时就绪检查失败，负载均衡不再分配新连接
+func (c *Checker) SetDraining(draining bool) {
+	if c.draining.Swap(draining) != draining {
+		logger.Info("就绪检查排空状态: %v", draining)
+	}
+}
+
+// Draining 是否处于排空状态
+func (c *Checker) Draining() bool {
+	return c.draining.Load()
+}
+
+// Register 在 mux 上注册 /healthz 和 /readyz
+func (c *Checker) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", c.serveHealth)
+	mux.HandleFunc("/readyz", c.serveReady)
+}
+
+// serveHealth 存活检查，进程能响应即为存活
+func (c *Checker) serveHealth(w http.ResponseWriter, r *http.Request) {
+	report := c.newReport()
+	report.Checks["process"] = &CheckResult{
+		Status:  StatusOK,
+		Message: fmt.Sprintf("goroutines=%d", runtime.NumGoroutine()),
+	}
+	writeReport(w, report)
+}
+
+// serveReady 就绪检查，各项检查并行执行
+func (c *Checker) serveReady(w http.ResponseWriter, r *http.Request) {
+	c.mutex.RLock()
+	checks := append([]check(nil), c.checks...)
+	c.mutex.RUnlock()
+
+	report := c.newReport()
+	results := make([]*CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, ck := range checks {
+		wg.Add(1)
+		go func(i int, ck check) {
+			defer wg.Done()
+			results[i] = run(r.Context(), ck.fn)
+		}(i, ck)
+	}
+	wg.Wait()
+
+	for i, ck := range checks {
+		report.Checks[ck.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	writeReport(w, report)
+}
+
+// newReport 创建状态为 ok 的报告
+func (c *Checker) newReport() *Report {
+	return &Report{
+		Status:    StatusOK,
+		StartedAt: c.startedAt,
+		Uptime:    time.Since(c.startedAt).Seconds(),
+		Checks:    make(map[string]*CheckResult),
+	}
+}
+
+// run 执行一项检查，超时后不再等待
+func run(ctx context.Context, fn func() error) *CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("检查超时")
+	}
+
+	result := &CheckResult{
+		Status:     StatusOK,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Message = err.Error()
+	}
+	return result
+}
+
+// writeReport 输出JSON报告，未通过检查时状态码为503
+func writeReport(w http.ResponseWriter, report *Report) {
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		logger.Error("输出健康检查结果失败: %v", err)
+	}
+}
+
+// DialCheck 检查地址能否建立TCP连接，用于确认服务端口正在接受连接
+func DialCheck(addr string) func() error {
+	return func() error {
+		conn, err := net.DialTimeout("tcp", addr, checkTimeout)
+		if err != nil {
+			return fmt.Errorf("无法连接 %s: %w", addr, err)
+		}
+		return conn.Close()
+	}
+}
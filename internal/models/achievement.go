@@ -0,0 +1,158 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// 成就和任务统计的事件，每局结束后按玩家累计
+const (
+	EventGames        = "games"         // 完成对局
+	EventWins         = "wins"          // 获胜
+	EventLandlordWins = "landlord_wins" // 以地主身份获胜
+	EventFarmerWins   = "farmer_wins"   // 以农民身份获胜
+	EventSprings      = "springs"       // 获胜且打出春天或反春
+	EventBombs        = "bombs"         // 打出炸弹
+	EventRockets      = "rockets"       // 打出火箭
+	EventRocketWins   = "rocket_wins"   // 打出火箭并获胜
+	EventCoinsWon     = "coins_won"     // 赢得的金币
+)
+
+var EventNames = map[string]string{
+	EventGames:        "完成对局",
+	EventWins:         "获胜",
+	EventLandlordWins: "地主获胜",
+	EventFarmerWins:   "农民获胜",
+	EventSprings:      "春天",
+	EventBombs:        "打出炸弹",
+	EventRockets:      "打出火箭",
+	EventRocketWins:   "火箭获胜",
+	EventCoinsWon:     "赢得金币",
+}
+
+// Goal 成就或任务的定义，从数据文件加载
+// 某个事件的累计次数达到目标时完成，完成后发放金币奖励
+type Goal struct {
+	ID          string `mapstructure:"id" json:"id"`                   // 唯一标识
+	Name        string `mapstructure:"name" json:"name"`               // 名称
+	Description string `mapstructure:"description" json:"description"` // 描述
+	Event       string `mapstructure:"event" json:"event"`             // 统计的事件
+	Target      int64  `mapstructure:"target" json:"target"`           // 目标次数
+	Reward      int64  `mapstructure:"reward" json:"reward"`           // 完成奖励(金币)
+}
+
+// GoalSet 全部成就和任务的定义
+type GoalSet struct {
+	Achievements []Goal `mapstructure:"achievements"` // 成就，终身累计
+	Daily        []Goal `mapstructure:"daily"`        // 每日任务，每天零点重置
+	Weekly       []Goal `mapstructure:"weekly"`       // 每周任务，每周一零点重置
+}
+
+// Validate 检查定义是否有效：标识唯一、事件已知、目标大于0
+func (gs *GoalSet) Validate() error {
+	ids := make(map[string]bool)
+	for _, goals := range [][]Goal{gs.Achievements, gs.Daily, gs.Weekly} {
+		for _, goal := range goals {
+			if goal.ID == "" {
+				return fmt.Errorf("成就或任务缺少id")
+			}
+			if ids[goal.ID] {
+				return fmt.Errorf("成就或任务id重复: %s", goal.ID)
+			}
+			ids[goal.ID] = true
+			if _, ok := EventNames[goal.Event]; !ok {
+				return fmt.Errorf("%s 的事件无效: %s", goal.ID, goal.Event)
+			}
+			if goal.Target <= 0 {
+				return fmt.Errorf("%s 的目标必须大于0", goal.ID)
+			}
+			if goal.Reward < 0 {
+				return fmt.Errorf("%s 的奖励不能为负数", goal.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// MissionProgress 一个周期内的任务进度
+type MissionProgress struct {
+	Period    string               `json:"period"`    // 周期，如 2006-01-02 或 2006-W01
+	Progress  map[string]int64     `json:"progress"`  // 事件 -> 本周期累计次数
+	Completed map[string]time.Time `json:"completed"` // 任务ID -> 完成时间
+}
+
+// Roll 进入新周期时清空进度
+func (m *MissionProgress) Roll(period string) {
+	if m.Period == period && m.Progress != nil {
+		return
+	}
+	m.Period = period
+	m.Progress = make(map[string]int64)
+	m.Completed = make(map[string]time.Time)
+}
+
+// PlayerProgress 玩家的成就和任务进度
+type PlayerProgress struct {
+	Counters   map[string]int64     `json:"counters"`     // 事件 -> 终身累计次数
+	Unlocked   map[string]time.Time `json:"unlocked"`     // 成就ID -> 获得时间
+	Daily      MissionProgress      `json:"daily"`        // 每日任务
+	Weekly     MissionProgress      `json:"weekly"`       // 每周任务
+	LastGameID string               `json:"last_game_id"` // 最近统计的一局，避免重复统计
+	UpdatedAt  time.Time            `json:"updated_at"`   // 更新时间
+}
+
+// NewPlayerProgress 创建空的进度
+func NewPlayerProgress() *PlayerProgress {
+	return &PlayerProgress{
+		Counters: make(map[string]int64),
+		Unlocked: make(map[string]time.Time),
+	}
+}
+
+// DailyPeriod 每日任务的周期
+func DailyPeriod(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// WeeklyPeriod 每周任务的周期（ISO周）
+func WeeklyPeriod(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// GameEvents 统计玩家在一局已结束的游戏中触发的事件次数
+func GameEvents(game *Game, position PlayerPosition) map[string]int64 {
+	events := map[string]int64{EventGames: 1}
+	player := game.GetPlayer(position)
+	if player == nil {
+		return events
+	}
+
+	for _, entry := range game.GameLog {
+		if entry.Type != "play_cards" || entry.Player != position {
+			continue
+		}
+		switch AnalyzeHand(entry.Cards).Type {
+		case HandTypeBomb:
+			events[EventBombs]++
+		case HandTypeRocket:
+			events[EventRockets]++
+		}
+	}
+
+	if player.Score > 0 {
+		events[EventWins] = 1
+		if player.Role == RoleLandlord {
+			events[EventLandlordWins] = 1
+		} else {
+			events[EventFarmerWins] = 1
+		}
+		if game.Spring {
+			events[EventSprings] = 1
+		}
+		if events[EventRockets] > 0 {
+			events[EventRocketWins] = 1
+		}
+	}
+	return events
+}
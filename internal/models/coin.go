@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// LedgerType 金币流水类型
+type LedgerType int
+
+const (
+	LedgerTypeInitial    LedgerType = 1 // 开户赠送
+	LedgerTypeDailyGrant LedgerType = 2 // 每日登录赠送
+	LedgerTypeSettlement LedgerType = 3 // 对局结算
+	LedgerTypeReward     LedgerType = 4 // 成就和任务奖励
+)
+
+var LedgerTypeNames = map[LedgerType]string{
+	LedgerTypeInitial:    "开户赠送",
+	LedgerTypeDailyGrant: "每日登录赠送",
+	LedgerTypeSettlement: "对局结算",
+	LedgerTypeReward:     "成就和任务奖励",
+}
+
+// HouseAccount 庄家账户，AI玩家在对局结算中的输赢都记在庄家账户上
+// AI输掉的金币由庄家支付，最多付出庄家的全部余额，所以结算不会凭空产生金币。名称带AI前缀，真人用户不能注册
+const HouseAccount = AINamePrefix + "庄家"
+
+// Wallet 用户金币账户
+type Wallet struct {
+	Balance      int64     `json:"balance"`        // 余额
+	LastGrantDay string    `json:"last_grant_day"` // 最近一次领取每日赠送的日期(2006-01-02)
+	CreatedAt    time.Time `json:"created_at"`     // 开户时间
+	UpdatedAt    time.Time `json:"updated_at"`     // 更新时间
+}
+
+// LedgerEntry 一笔金币流水，记录谁向谁转了多少金币
+// From 为空表示由系统发放；AI玩家的输赢记在庄家账户上，作为对手方时余额记为0
+type LedgerEntry struct {
+	ID          uint64     `json:"id"`           // 流水号，按时间递增
+	Type        LedgerType `json:"type"`         // 类型
+	From        string     `json:"from"`         // 付款方
+	To          string     `json:"to"`           // 收款方
+	Amount      int64      `json:"amount"`       // 金额
+	FromBalance int64      `json:"from_balance"` // 付款方转账后的余额
+	ToBalance   int64      `json:"to_balance"`   // 收款方转账后的余额
+	RoomID      string     `json:"room_id"`      // 对局所在房间
+	GameID      string     `json:"game_id"`      // 对局ID
+	Note        string     `json:"note"`         // 备注
+	CreatedAt   time.Time  `json:"created_at"`   // 时间
+}
+
+// CoinChange 一名玩家在一局中的金币变化
+type CoinChange struct {
+	UserName string `json:"username"` // 玩家
+	Delta    int64  `json:"delta"`    // 变化量
+	Balance  int64  `json:"balance"`  // 结算后的余额，AI玩家为0
+}
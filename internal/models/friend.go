@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// FriendStatus 好友关系状态，从关系所有者的角度描述
+type FriendStatus int
+
+const (
+	FriendStatusRequested FriendStatus = 1 // 已发出好友请求，等待对方接受
+	FriendStatusIncoming  FriendStatus = 2 // 收到好友请求
+	FriendStatusAccepted  FriendStatus = 3 // 好友
+	FriendStatusBlocked   FriendStatus = 4 // 已屏蔽对方
+)
+
+var FriendStatusNames = map[FriendStatus]string{
+	FriendStatusRequested: "等待对方接受",
+	FriendStatusIncoming:  "待处理的好友请求",
+	FriendStatusAccepted:  "好友",
+	FriendStatusBlocked:   "已屏蔽",
+}
+
+// Friendship 好友关系，双方各保存一条记录（屏蔽只保存在屏蔽方）
+type Friendship struct {
+	Owner     string       `json:"owner"`      // 关系所有者
+	Friend    string       `json:"friend"`     // 对方用户名
+	Status    FriendStatus `json:"status"`     // 关系状态
+	CreatedAt time.Time    `json:"created_at"` // 创建时间
+	UpdatedAt time.Time    `json:"updated_at"` // 更新时间
+}
+
+// Invitation 私人房间邀请，被邀请人凭邀请加入房间时无需密码
+type Invitation struct {
+	ID        string    `json:"id"`         // 邀请ID
+	RoomID    string    `json:"room_id"`    // 房间ID
+	RoomName  string    `json:"room_name"`  // 房间名称
+	From      string    `json:"from"`       // 邀请人
+	To        string    `json:"to"`         // 被邀请人
+	CreatedAt time.Time `json:"created_at"` // 创建时间
+	ExpiresAt time.Time `json:"expires_at"` // 过期时间
+}
+
+// IsExpired 判断邀请在指定时间是否已过期
+func (i *Invitation) IsExpired(now time.Time) bool {
+	return !now.Before(i.ExpiresAt)
+}
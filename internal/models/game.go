@@ -139,6 +139,16 @@ func (p *GamePlayer) HasCards(cards []Card) bool {
 	return true
 }
 
+// Clone 深拷贝玩家
+func (p *GamePlayer) Clone() *GamePlayer {
+	if p == nil {
+		return nil
+	}
+	clone := *p
+	clone.Cards = cloneCards(p.Cards)
+	return &clone
+}
+
 // Game 游戏对象
 type Game struct {
 	ID             string            `json:"id"`              // 游戏ID
@@ -153,6 +163,8 @@ type Game struct {
 	StartedAt      *time.Time        `json:"started_at"`      // 开始时间
 	FinishedAt     *time.Time        `json:"finished_at"`     // 结束时间
 	Winner         PlayerPosition    `json:"winner"`          // 获胜者
+	Multiplier     int               `json:"multiplier"`      // 金币结算倍数：每个炸弹或火箭翻倍，春天或反春翻倍，不影响得分
+	Spring         bool              `json:"spring"`          // 是否春天或反春
 	GameLog        []GameLogEntry    `json:"game_log"`        // 游戏日志
 }
 
@@ -284,6 +296,45 @@ func (g *Game) AddLog(logType string, player PlayerPosition, cards []Card, messa
 	g.GameLog = append(g.GameLog, entry)
 }
 
+// Clone 深拷贝游戏对象，日志条目中的卡牌写入后不再修改，与原对象共享
+func (g *Game) Clone() *Game {
+	if g == nil {
+		return nil
+	}
+	clone := *g
+	for i, player := range g.Players {
+		clone.Players[i] = player.Clone()
+	}
+	clone.LandlordCards = cloneCards(g.LandlordCards)
+	clone.LastPlayCards = cloneCards(g.LastPlayCards)
+	clone.StartedAt = cloneTime(g.StartedAt)
+	clone.FinishedAt = cloneTime(g.FinishedAt)
+	if g.GameLog != nil {
+		clone.GameLog = make([]GameLogEntry, len(g.GameLog))
+		copy(clone.GameLog, g.GameLog)
+	}
+	return &clone
+}
+
+// cloneCards 复制卡牌切片，保留nil
+func cloneCards(cards []Card) []Card {
+	if cards == nil {
+		return nil
+	}
+	out := make([]Card, len(cards))
+	copy(out, cards)
+	return out
+}
+
+// cloneTime 复制时间指针
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := *t
+	return &v
+}
+
 // ToJSON 转换为JSON字符串
 func (g *Game) ToJSON() (string, error) {
 	data, err := json.Marshal(g)
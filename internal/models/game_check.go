@@ -0,0 +1,88 @@
+package models
+
+import "fmt"
+
+// CheckConsistency 检查已发牌的游戏状态是否一致，用于从数据库恢复进行中的游戏
+// 要求三个座位都有玩家、轮到的玩家有效，手牌、底牌和已出的牌合起来正好是一副完整的牌
+func (g *Game) CheckConsistency() error {
+	if g.Status != GameStatusCalling && g.Status != GameStatusPlaying {
+		return fmt.Errorf("游戏状态 %s 无法恢复", GameStatusNames[g.Status])
+	}
+	for i, player := range g.Players {
+		if player == nil {
+			return fmt.Errorf("座位 %d 没有玩家", i+1)
+		}
+	}
+	if g.GetPlayer(g.CurrentTurn) == nil {
+		return fmt.Errorf("当前回合的座位 %d 无效", g.CurrentTurn)
+	}
+
+	cards := make([]Card, 0, 54)
+	for _, player := range g.Players {
+		cards = append(cards, player.Cards...)
+	}
+
+	switch g.Status {
+	case GameStatusCalling:
+		// 叫地主阶段底牌还没有交给地主
+		for i, player := range g.Players {
+			if len(player.Cards) != 17 {
+				return fmt.Errorf("座位 %d 的手牌数为 %d，应为17", i+1, len(player.Cards))
+			}
+			if player.Role == RoleLandlord {
+				return fmt.Errorf("叫地主阶段已有地主")
+			}
+		}
+		if len(g.LandlordCards) != 3 {
+			return fmt.Errorf("底牌数为 %d，应为3", len(g.LandlordCards))
+		}
+		cards = append(cards, g.LandlordCards...)
+
+	case GameStatusPlaying:
+		landlords := 0
+		for i, player := range g.Players {
+			if player.Role == RoleLandlord {
+				landlords++
+			}
+			if len(player.Cards) == 0 {
+				return fmt.Errorf("座位 %d 已出完牌但游戏未结束", i+1)
+			}
+		}
+		if landlords != 1 {
+			return fmt.Errorf("地主人数为 %d，应为1", landlords)
+		}
+		if g.GetPlayer(g.LastPlayer) == nil {
+			return fmt.Errorf("上次出牌的座位 %d 无效", g.LastPlayer)
+		}
+		// 底牌已经并入地主手牌，已出的牌从游戏日志中统计
+		for _, entry := range g.GameLog {
+			if entry.Type == "play_cards" {
+				cards = append(cards, entry.Cards...)
+			}
+		}
+	}
+
+	return checkFullDeck(cards)
+}
+
+// checkFullDeck 检查牌正好是一副完整的牌，没有缺少或重复
+func checkFullDeck(cards []Card) error {
+	counts := make(map[Card]int, 54)
+	for _, card := range cards {
+		counts[card]++
+	}
+	for _, card := range NewDeck() {
+		switch counts[card] {
+		case 1:
+			delete(counts, card)
+		case 0:
+			return fmt.Errorf("缺少 %s", card)
+		default:
+			return fmt.Errorf("%s 重复出现 %d 次", card, counts[card])
+		}
+	}
+	if len(counts) > 0 {
+		return fmt.Errorf("共 %d 张牌，多出 %d 种无效的牌", len(cards), len(counts))
+	}
+	return nil
+}
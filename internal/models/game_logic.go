@@ -58,6 +58,7 @@ type HandPattern struct {
 // GameLogic 游戏逻辑
 type GameLogic struct {
 	game *Game
+	rng  *mrand.Rand // 发牌使用的随机源，为空时使用全局随机源
 }
 
 // NewGameLogic 创建游戏逻辑实例
@@ -65,6 +66,11 @@ func NewGameLogic(game *Game) *GameLogic {
 	return &GameLogic{game: game}
 }
 
+// NewGameLogicWithRand 使用指定随机源创建游戏逻辑实例，相同种子发出相同的牌
+func NewGameLogicWithRand(game *Game, rng *mrand.Rand) *GameLogic {
+	return &GameLogic{game: game, rng: rng}
+}
+
 // ShuffleDeck 洗牌
 func ShuffleDeck(deck []Card) {
 	mrand.Seed(time.Now().UnixNano())
@@ -134,7 +140,13 @@ func (gl *GameLogic) DealCards() error {
 
 	// 创建并洗牌
 	deck := NewDeck()
-	ShuffleDeck(deck)
+	if gl.rng != nil {
+		gl.rng.Shuffle(len(deck), func(i, j int) {
+			deck[i], deck[j] = deck[j], deck[i]
+		})
+	} else {
+		ShuffleDeck(deck)
+	}
 
 	// 给每个玩家发17张牌
 	for i := 0; i < 17; i++ {
@@ -225,6 +237,185 @@ func (gl *GameLogic) CallLandlord(position PlayerPosition, call bool) error {
 	return nil
 }
 
+// PlayCards 出牌
+func (gl *GameLogic) PlayCards(position PlayerPosition, cards []Card) error {
+	game := gl.game
+	if game.Status != GameStatusPlaying {
+		return fmt.Errorf("游戏状态不正确")
+	}
+
+	if position != game.CurrentTurn {
+		return fmt.Errorf("不是该玩家的回合")
+	}
+
+	player := game.GetPlayer(position)
+	if player == nil {
+		return fmt.Errorf("玩家不存在")
+	}
+
+	// 检查玩家是否有这些牌
+	if !player.HasCards(cards) {
+		return fmt.Errorf("玩家没有这些牌")
+	}
+
+	// 分析牌型
+	handPattern := AnalyzeHand(cards)
+	if !handPattern.IsValid {
+		return fmt.Errorf("无效的牌型")
+	}
+
+	// 检查是否能压过上一手牌
+	if len(game.LastPlayCards) > 0 && game.LastPlayer != position {
+		lastPattern := AnalyzeHand(game.LastPlayCards)
+		if !CanBeat(handPattern, lastPattern) {
+			return fmt.Errorf("无法压过上一手牌")
+		}
+	}
+
+	// 出牌
+	if !player.RemoveCards(cards) {
+		return fmt.Errorf("移除手牌失败")
+	}
+
+	// 更新游戏状态
+	game.LastPlayCards = cards
+	game.LastPlayer = position
+	game.NextTurn()
+
+	// 添加游戏日志
+	game.AddLog("play_cards", position, cards, fmt.Sprintf("%s 出牌 %s", player.UserName, HandTypeNames[handPattern.Type]))
+
+	// 检查是否获胜
+	if player.GetCardCount() == 0 {
+		game.Status = GameStatusFinished
+		game.Winner = position
+		now := time.Now()
+		game.FinishedAt = &now
+
+		// 计算分数
+		gl.CalculateScore()
+
+		game.AddLog("win", position, nil, fmt.Sprintf("%s 获胜", player.UserName))
+	}
+
+	return nil
+}
+
+// Pass 过牌
+func (gl *GameLogic) Pass(position PlayerPosition) error {
+	game := gl.game
+	if game.Status != GameStatusPlaying {
+		return fmt.Errorf("游戏状态不正确")
+	}
+
+	if position != game.CurrentTurn {
+		return fmt.Errorf("不是该玩家的回合")
+	}
+
+	// 如果上一次出牌的是自己，不能过牌
+	if game.LastPlayer == position {
+		return fmt.Errorf("上次出牌是你自己，不能过牌")
+	}
+
+	player := game.GetPlayer(position)
+	if player == nil {
+		return fmt.Errorf("玩家不存在")
+	}
+	game.NextTurn()
+
+	// 添加游戏日志
+	game.AddLog("pass", position, nil, fmt.Sprintf("%s 过牌", player.UserName))
+
+	// 检查是否一圈都过了
+	// 这里简化处理，如果当前回合回到了上次出牌的玩家，说明其他人都过了
+	if game.CurrentTurn == game.LastPlayer {
+		// 清除上一手牌，让最后出牌的玩家继续出牌
+		game.LastPlayCards = nil
+		game.AddLog("new_round", game.LastPlayer, nil, "新一轮开始")
+	}
+
+	return nil
+}
+
+// CalculateScore 计算分数
+func (gl *GameLogic) CalculateScore() {
+	game := gl.game
+	if game.Status != GameStatusFinished {
+		return
+	}
+
+	winner := game.GetPlayer(game.Winner)
+	if winner == nil {
+		return
+	}
+
+	// 倍数只用于金币结算，得分按底分计算
+	gl.calculateMultiplier()
+	baseScore := 1
+
+	// 地主获胜
+	if winner.Role == RoleLandlord {
+		// 地主获胜，地主得2倍底分，农民各扣1倍底分
+		winner.Score = baseScore * 2
+		for _, player := range game.Players {
+			if player != nil && player.Role == RoleFarmer {
+				player.Score = -baseScore
+			}
+		}
+	} else {
+		// 农民获胜，每个农民得1倍底分，地主扣2倍底分
+		for _, player := range game.Players {
+			if player != nil {
+				if player.Role == RoleFarmer {
+					player.Score = baseScore
+				} else if player.Role == RoleLandlord {
+					player.Score = -baseScore * 2
+				}
+			}
+		}
+	}
+}
+
+// calculateMultiplier 根据出牌记录计算结算倍数
+// 每个炸弹或火箭翻倍；地主获胜且农民一张牌都没出为春天，农民获胜且地主只出过一手为反春，同样翻倍
+func (gl *GameLogic) calculateMultiplier() {
+	game := gl.game
+	multiplier := 1
+	var plays [3]int
+	for _, entry := range game.GameLog {
+		if entry.Type != "play_cards" || entry.Player < Position1 || entry.Player > Position3 {
+			continue
+		}
+		plays[entry.Player]++
+		if pattern := AnalyzeHand(entry.Cards); pattern.Type == HandTypeBomb || pattern.Type == HandTypeRocket {
+			multiplier *= 2
+		}
+	}
+
+	landlordPlays, farmerPlays := 0, 0
+	for _, player := range game.Players {
+		if player == nil {
+			continue
+		}
+		if player.Role == RoleLandlord {
+			landlordPlays += plays[player.Position]
+		} else {
+			farmerPlays += plays[player.Position]
+		}
+	}
+
+	winner := game.GetPlayer(game.Winner)
+	if winner.Role == RoleLandlord {
+		game.Spring = farmerPlays == 0
+	} else {
+		game.Spring = landlordPlays == 1
+	}
+	if game.Spring {
+		multiplier *= 2
+	}
+	game.Multiplier = multiplier
+}
+
 // AnalyzeHand 分析手牌牌型
 func AnalyzeHand(cards []Card) HandPattern {
 	if len(cards) == 0 {
@@ -0,0 +1,74 @@
+package models
+
+import "testing"
+
+// scoredGame 创建1号位为地主的已结束游戏，plays 按顺序给出每手牌的出牌座位和牌
+func scoredGame(winner PlayerPosition, plays []GameLogEntry) *Game {
+	game := &Game{Status: GameStatusFinished, Winner: winner}
+	for i := range game.Players {
+		role := RoleFarmer
+		if i == 0 {
+			role = RoleLandlord
+		}
+		game.Players[i] = &GamePlayer{Position: PlayerPosition(i), Role: role}
+	}
+	for _, play := range plays {
+		game.AddLog("play_cards", play.Player, play.Cards, "")
+	}
+	NewGameLogic(game).CalculateScore()
+	return game
+}
+
+func TestCalculateScoreKeepsMultiplierForSettlement(t *testing.T) {
+	single := []Card{NewCard(SuitSpades, Value3)}
+	bomb := []Card{
+		NewCard(SuitSpades, Value9), NewCard(SuitHearts, Value9),
+		NewCard(SuitDiamonds, Value9), NewCard(SuitClubs, Value9),
+	}
+
+	tests := []struct {
+		name       string
+		winner     PlayerPosition
+		plays      []GameLogEntry
+		multiplier int
+		spring     bool
+		scores     [3]int
+	}{
+		{
+			name:       "地主获胜",
+			winner:     Position1,
+			plays:      []GameLogEntry{{Player: Position1, Cards: single}, {Player: Position2, Cards: single}, {Player: Position1, Cards: single}},
+			multiplier: 1,
+			scores:     [3]int{2, -1, -1},
+		},
+		{
+			name:       "春天加炸弹",
+			winner:     Position1,
+			plays:      []GameLogEntry{{Player: Position1, Cards: bomb}, {Player: Position1, Cards: single}},
+			multiplier: 4,
+			spring:     true,
+			scores:     [3]int{2, -1, -1},
+		},
+		{
+			name:       "反春",
+			winner:     Position2,
+			plays:      []GameLogEntry{{Player: Position1, Cards: single}, {Player: Position2, Cards: single}, {Player: Position2, Cards: single}},
+			multiplier: 2,
+			spring:     true,
+			scores:     [3]int{-2, 1, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		game := scoredGame(tt.winner, tt.plays)
+		if game.Multiplier != tt.multiplier || game.Spring != tt.spring {
+			t.Errorf("%s: 倍数为 %d，春天为 %v，应为 %d、%v", tt.name, game.Multiplier, game.Spring, tt.multiplier, tt.spring)
+		}
+		// 得分按底分计算，不受倍数影响
+		for i, player := range game.Players {
+			if player.Score != tt.scores[i] {
+				t.Errorf("%s: %d 号位得分为 %d，应为 %d", tt.name, i+1, player.Score, tt.scores[i])
+			}
+		}
+	}
+}
@@ -35,18 +35,26 @@ var RoomTypeNames = map[RoomType]string{
 
 // Room 房间对象
 type Room struct {
-	ID          string     `json:"id"`           // 房间ID
-	Name        string     `json:"name"`         // 房间名称
-	Owner       string     `json:"owner"`        // 房主
-	Type        RoomType   `json:"type"`         // 房间类型
-	Status      RoomStatus `json:"status"`       // 房间状态
-	MaxPlayers  int        `json:"max_players"`  // 最大玩家数
-	Password    string     `json:"password"`     // 房间密码（私人房间）
-	CreatedAt   time.Time  `json:"created_at"`   // 创建时间
-	UpdatedAt   time.Time  `json:"updated_at"`   // 更新时间
-	CurrentGame *Game      `json:"current_game"` // 当前游戏
+	ID          string       `json:"id"`           // 房间ID
+	Code        string       `json:"code"`         // 房间码，6位，便于口头分享
+	Name        string       `json:"name"`         // 房间名称
+	Owner       string       `json:"owner"`        // 房主
+	Type        RoomType     `json:"type"`         // 房间类型
+	Status      RoomStatus   `json:"status"`       // 房间状态
+	MaxPlayers  int          `json:"max_players"`  // 最大玩家数
+	Password    string       `json:"password"`     // 房间密码（私人房间）
+	CreatedAt   time.Time    `json:"created_at"`   // 创建时间
+	UpdatedAt   time.Time    `json:"updated_at"`   // 更新时间
+	CurrentGame *Game        `json:"current_game"` // 当前游戏
+	Hands       int          `json:"hands"`        // 每次对战的局数，0为单局，HandsUnlimited为不限局数
+	Session     *RoomSession `json:"session"`      // 多局对战，单局房间为nil
+	BaseStake   int64        `json:"base_stake"`   // 底注，每局按得分乘以底注结算金币，0为不结算金币
+	MinBalance  int64        `json:"min_balance"`  // 入座和开局所需的最低金币
 }
 
+// NoStake 创建房间时指定不结算金币，如比赛房间
+const NoStake = -1
+
 // NewRoom 创建新房间
 func NewRoom(id, name, owner string, roomType RoomType, password string) *Room {
 	return &Room{
@@ -111,7 +119,8 @@ func (r *Room) IsFull() bool {
 // CanJoin 检查玩家是否可以加入
 func (r *Room) CanJoin(password string) bool {
 	// 如果游戏已经结束，允许新玩家加入（将开始新游戏）
-	if r.CurrentGame != nil &&
+	// 多局对战进行中时保留上一局，等待自动开始下一局
+	if !r.Session.IsActive() && r.CurrentGame != nil &&
 		(r.CurrentGame.Status == GameStatusFinished ||
 			r.CurrentGame.Status == GameStatusAbandoned) {
 		// 清除旧游戏，准备开始新游戏
@@ -146,15 +155,27 @@ func (r *Room) FromJSON(data string) error {
 	return json.Unmarshal([]byte(data), r)
 }
 
+// Clone 深拷贝房间对象（包括当前游戏）
+func (r *Room) Clone() *Room {
+	clone := *r
+	clone.CurrentGame = r.CurrentGame.Clone()
+	clone.Session = r.Session.Clone()
+	return &clone
+}
+
 // GetSafeRoom 获取安全的房间信息（不包含敏感信息）
 func (r *Room) GetSafeRoom() *Room {
 	safeRoom := &Room{
 		ID:         r.ID,
+		Code:       r.Code,
 		Name:       r.Name,
 		Owner:      r.Owner,
 		Type:       r.Type,
 		Status:     r.Status,
 		MaxPlayers: r.MaxPlayers,
+		Hands:      r.Hands,
+		BaseStake:  r.BaseStake,
+		MinBalance: r.MinBalance,
 		CreatedAt:  r.CreatedAt,
 		UpdatedAt:  r.UpdatedAt,
 	}
@@ -0,0 +1,170 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// HandsUnlimited 不限局数，一直打到玩家停止
+const HandsUnlimited = -1
+
+// SessionStatus 房间多局对战状态
+type SessionStatus int
+
+const (
+	SessionStatusPlaying  SessionStatus = 0 // 进行中
+	SessionStatusFinished SessionStatus = 1 // 已结束
+)
+
+var SessionStatusNames = map[SessionStatus]string{
+	SessionStatusPlaying:  "进行中",
+	SessionStatusFinished: "已结束",
+}
+
+// HandResult 一局的结果
+type HandResult struct {
+	Hand      int            `json:"hand"`      // 第几局，从1开始
+	GameID    string         `json:"game_id"`   // 游戏ID
+	Winner    PlayerPosition `json:"winner"`    // 获胜者座位，流局时无意义
+	Abandoned bool           `json:"abandoned"` // 是否流局（无人叫地主）
+	Scores    [3]int         `json:"scores"`    // 各座位本局得分
+}
+
+// Standing 最终排名
+type Standing struct {
+	Rank     int            `json:"rank"`     // 名次，总分相同名次相同
+	Position PlayerPosition `json:"position"` // 座位
+	UserName string         `json:"username"` // 玩家
+	Total    int            `json:"total"`    // 总分
+}
+
+// RoomSession 房间多局对战，记录各座位累计得分并轮换首个叫地主的座位
+type RoomSession struct {
+	TotalHands    int            `json:"total_hands"`    // 计划局数，HandsUnlimited表示不限
+	HandsPlayed   int            `json:"hands_played"`   // 已完成的局数
+	Status        SessionStatus  `json:"status"`         // 状态
+	Seats         [3]string      `json:"seats"`          // 开始时各座位的玩家
+	Totals        [3]int         `json:"totals"`         // 各座位累计得分
+	FirstBidder   PlayerPosition `json:"first_bidder"`   // 下一局首个叫地主的座位
+	StopRequested bool           `json:"stop_requested"` // 当前局结束后停止
+	NextHandAt    *time.Time     `json:"next_hand_at"`   // 下一局自动开始的时间
+	Hands         []HandResult   `json:"hands"`          // 每局结果
+	EndReason     string         `json:"end_reason"`     // 结束原因
+	StartedAt     time.Time      `json:"started_at"`     // 开始时间
+	FinishedAt    *time.Time     `json:"finished_at"`    // 结束时间
+}
+
+// NewRoomSession 根据当前游戏的座位创建多局对战
+func NewRoomSession(totalHands int, game *Game) *RoomSession {
+	session := &RoomSession{
+		TotalHands:  totalHands,
+		Status:      SessionStatusPlaying,
+		FirstBidder: Position1,
+		Hands:       make([]HandResult, 0),
+		StartedAt:   time.Now(),
+	}
+	for i, player := range game.Players {
+		if player != nil {
+			session.Seats[i] = player.UserName
+		}
+	}
+	return session
+}
+
+// IsActive 检查多局对战是否进行中
+func (s *RoomSession) IsActive() bool {
+	return s != nil && s.Status == SessionStatusPlaying
+}
+
+// RecordHand 记录已结束的一局，累计得分并轮换首个叫地主的座位
+// 同一局只记录一次；返回多局对战是否继续
+func (s *RoomSession) RecordHand(game *Game, delay time.Duration) bool {
+	if !s.IsActive() {
+		return false
+	}
+	for _, hand := range s.Hands {
+		if hand.GameID == game.ID {
+			return s.NextHandAt != nil
+		}
+	}
+
+	s.HandsPlayed++
+	result := HandResult{
+		Hand:      s.HandsPlayed,
+		GameID:    game.ID,
+		Winner:    game.Winner,
+		Abandoned: game.Status == GameStatusAbandoned,
+	}
+	if !result.Abandoned {
+		for i, player := range game.Players {
+			if player != nil {
+				result.Scores[i] = player.Score
+				s.Totals[i] += player.Score
+			}
+		}
+	}
+	s.Hands = append(s.Hands, result)
+	s.FirstBidder = (s.FirstBidder + 1) % 3
+
+	switch {
+	case s.StopRequested:
+		s.Finish("玩家停止对战")
+	case s.TotalHands != HandsUnlimited && s.HandsPlayed >= s.TotalHands:
+		s.Finish("已完成全部对局")
+	default:
+		next := time.Now().Add(delay)
+		s.NextHandAt = &next
+		return true
+	}
+	return false
+}
+
+// Finish 结束多局对战
+func (s *RoomSession) Finish(reason string) {
+	if !s.IsActive() {
+		return
+	}
+	now := time.Now()
+	s.Status = SessionStatusFinished
+	s.EndReason = reason
+	s.NextHandAt = nil
+	s.FinishedAt = &now
+}
+
+// Standings 按累计得分从高到低排名
+func (s *RoomSession) Standings() []Standing {
+	standings := make([]Standing, 0, 3)
+	for i, name := range s.Seats {
+		if name != "" {
+			standings = append(standings, Standing{
+				Position: PlayerPosition(i),
+				UserName: name,
+				Total:    s.Totals[i],
+			})
+		}
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		return standings[i].Total > standings[j].Total
+	})
+	for i := range standings {
+		if i > 0 && standings[i].Total == standings[i-1].Total {
+			standings[i].Rank = standings[i-1].Rank
+		} else {
+			standings[i].Rank = i + 1
+		}
+	}
+	return standings
+}
+
+// Clone 深拷贝多局对战
+func (s *RoomSession) Clone() *RoomSession {
+	if s == nil {
+		return nil
+	}
+	clone := *s
+	clone.NextHandAt = cloneTime(s.NextHandAt)
+	clone.FinishedAt = cloneTime(s.FinishedAt)
+	clone.Hands = append([]HandResult(nil), s.Hands...)
+	return &clone
+}
@@ -0,0 +1,175 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// TournamentFormat 赛制
+type TournamentFormat int
+
+const (
+	TournamentFormatSwiss    TournamentFormat = 1 // 瑞士制：固定轮数，每轮按积分相近编桌
+	TournamentFormatKnockout TournamentFormat = 2 // 淘汰制：每桌第一名晋级，直到决出冠军
+)
+
+var TournamentFormatNames = map[TournamentFormat]string{
+	TournamentFormatSwiss:    "瑞士制",
+	TournamentFormatKnockout: "淘汰制",
+}
+
+// TournamentStatus 比赛状态
+type TournamentStatus int
+
+const (
+	TournamentStatusRegistration TournamentStatus = 0 // 报名中
+	TournamentStatusCheckIn      TournamentStatus = 1 // 签到中
+	TournamentStatusRunning      TournamentStatus = 2 // 进行中
+	TournamentStatusFinished     TournamentStatus = 3 // 已结束
+	TournamentStatusCancelled    TournamentStatus = 4 // 已取消
+)
+
+var TournamentStatusNames = map[TournamentStatus]string{
+	TournamentStatusRegistration: "报名中",
+	TournamentStatusCheckIn:      "签到中",
+	TournamentStatusRunning:      "进行中",
+	TournamentStatusFinished:     "已结束",
+	TournamentStatusCancelled:    "已取消",
+}
+
+// TournamentPlayer 参赛选手
+type TournamentPlayer struct {
+	Name         string    `json:"name"`          // 用户名
+	CheckedIn    bool      `json:"checked_in"`    // 是否已签到
+	Points       int       `json:"points"`        // 累计得分
+	TableWins    int       `json:"table_wins"`    // 获得桌第一的次数
+	Eliminated   bool      `json:"eliminated"`    // 是否已淘汰（淘汰制）
+	EliminatedIn int       `json:"eliminated_in"` // 在第几轮被淘汰
+	RegisteredAt time.Time `json:"registered_at"` // 报名时间
+}
+
+// TournamentTable 一轮中的一桌，空座位由AI补齐
+type TournamentTable struct {
+	Number   int       `json:"number"`   // 桌号，从1开始
+	RoomID   string    `json:"room_id"`  // 对应的房间
+	Players  [3]string `json:"players"`  // 各座位的选手，AI补位的座位为空
+	Scores   [3]int    `json:"scores"`   // 各座位本桌得分
+	Winner   string    `json:"winner"`   // 本桌第一名（只计选手）
+	Finished bool      `json:"finished"` // 是否已结束
+	Void     bool      `json:"void"`     // 是否作废：开局失败、房间关闭或一局未完成，不计分、不淘汰
+	Note     string    `json:"note"`     // 备注，如房间异常结束
+}
+
+// TournamentRound 一轮比赛
+type TournamentRound struct {
+	Number     int               `json:"number"`      // 轮次，从1开始
+	Tables     []TournamentTable `json:"tables"`      // 各桌
+	StartedAt  time.Time         `json:"started_at"`  // 开始时间
+	FinishedAt *time.Time        `json:"finished_at"` // 结束时间
+}
+
+// IsFinished 检查本轮所有桌是否都已结束
+func (r *TournamentRound) IsFinished() bool {
+	for _, table := range r.Tables {
+		if !table.Finished {
+			return false
+		}
+	}
+	return true
+}
+
+// Tournament 比赛
+type Tournament struct {
+	ID            string              `json:"id"`              // 比赛ID
+	Name          string              `json:"name"`            // 比赛名称
+	Organizer     string              `json:"organizer"`       // 组织者
+	Format        TournamentFormat    `json:"format"`          // 赛制
+	Rounds        int                 `json:"rounds"`          // 瑞士制的轮数
+	HandsPerTable int                 `json:"hands_per_table"` // 每桌局数
+	MaxPlayers    int                 `json:"max_players"`     // 报名人数上限
+	Status        TournamentStatus    `json:"status"`          // 状态
+	Players       []*TournamentPlayer `json:"players"`         // 选手，按报名顺序
+	CurrentRound  int                 `json:"current_round"`   // 当前轮次，未开始为0
+	Champion      string              `json:"champion"`        // 冠军
+	CreatedAt     time.Time           `json:"created_at"`      // 创建时间
+	StartedAt     *time.Time          `json:"started_at"`      // 开始时间
+	FinishedAt    *time.Time          `json:"finished_at"`     // 结束时间
+}
+
+// GetPlayer 获取选手
+func (t *Tournament) GetPlayer(name string) *TournamentPlayer {
+	for _, player := range t.Players {
+		if player.Name == name {
+			return player
+		}
+	}
+	return nil
+}
+
+// ActivePlayers 仍在比赛中的选手（已签到且未淘汰）
+func (t *Tournament) ActivePlayers() []*TournamentPlayer {
+	players := make([]*TournamentPlayer, 0, len(t.Players))
+	for _, player := range t.Players {
+		if player.CheckedIn && !player.Eliminated {
+			players = append(players, player)
+		}
+	}
+	return players
+}
+
+// Standings 排名：淘汰制先按坚持的轮次，再按累计得分、桌第一次数和用户名
+func (t *Tournament) Standings() []*TournamentPlayer {
+	players := make([]*TournamentPlayer, 0, len(t.Players))
+	for _, player := range t.Players {
+		if player.CheckedIn || t.Status == TournamentStatusRegistration || t.Status == TournamentStatusCheckIn {
+			players = append(players, player)
+		}
+	}
+
+	survived := func(p *TournamentPlayer) int {
+		if !p.Eliminated {
+			return t.CurrentRound + 1
+		}
+		return p.EliminatedIn
+	}
+	sort.SliceStable(players, func(i, j int) bool {
+		a, b := players[i], players[j]
+		if t.Format == TournamentFormatKnockout {
+			if a.Name == t.Champion || b.Name == t.Champion {
+				return a.Name == t.Champion && b.Name != t.Champion
+			}
+			if survived(a) != survived(b) {
+				return survived(a) > survived(b)
+			}
+		}
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		if a.TableWins != b.TableWins {
+			return a.TableWins > b.TableWins
+		}
+		return a.Name < b.Name
+	})
+	return players
+}
+
+// Clone 深拷贝比赛
+func (t *Tournament) Clone() *Tournament {
+	clone := *t
+	clone.Players = make([]*TournamentPlayer, len(t.Players))
+	for i, player := range t.Players {
+		copied := *player
+		clone.Players[i] = &copied
+	}
+	clone.StartedAt = cloneTime(t.StartedAt)
+	clone.FinishedAt = cloneTime(t.FinishedAt)
+	return &clone
+}
+
+// Clone 深拷贝一轮比赛
+func (r *TournamentRound) Clone() *TournamentRound {
+	clone := *r
+	clone.Tables = append([]TournamentTable(nil), r.Tables...)
+	clone.FinishedAt = cloneTime(r.FinishedAt)
+	return &clone
+}
@@ -1,16 +1,59 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
+// AINamePrefix AI玩家名称前缀，真人用户不能使用
+const AINamePrefix = "AI-"
+
+// IsAIName 判断名称是否为AI玩家名称
+func IsAIName(name string) bool {
+	return strings.HasPrefix(name, AINamePrefix)
+}
+
+// DeletedUserPrefix 已注销用户在历史记录中的匿名名称前缀，真人用户不能使用
+const DeletedUserPrefix = "已注销-"
+
+// IsReservedName 判断名称是否为系统保留名称
+func IsReservedName(name string) bool {
+	return IsAIName(name) || strings.HasPrefix(name, DeletedUserPrefix)
+}
+
+// AnonymousName 根据用户ID生成注销后的匿名名称
+func AnonymousName(id string) string {
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	return DeletedUserPrefix + id
+}
+
 // User 用户数据结构
 type User struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Password    string    `json:"password"`
-	Age         int       `json:"age"`
-	IsAI        bool      `json:"is_ai"`
-	CreatedAt   time.Time `json:"created_at"`
-	LastLoginAt time.Time `json:"last_login_at"`
+	ID              string    `json:"id"` // 用户唯一ID(UUID)，创建后不再变化
+	Name            string    `json:"name"`
+	Nickname        string    `json:"nickname,omitempty"` // 昵称
+	Avatar          string    `json:"avatar,omitempty"`   // 头像地址或预设头像标识
+	Password        string    `json:"password"`           // 密码哈希
+	PasswordAlgo    string    `json:"password_algo"`      // 密码哈希算法，为空表示旧版MD5
+	PasswordVersion int       `json:"password_version"`   // 密码哈希方案版本
+	Age             int       `json:"age"`
+	IsAI            bool      `json:"is_ai"`
+	CreatedAt       time.Time `json:"created_at"`
+	LastLoginAt     time.Time `json:"last_login_at"`
+
+	FailedLogins int        `json:"failed_logins,omitempty"`  // 连续登录失败次数
+	LastFailedAt *time.Time `json:"last_failed_at,omitempty"` // 最近一次登录失败时间
+	LockCount    int        `json:"lock_count,omitempty"`     // 连续锁定次数，用于计算锁定时长
+	LockedUntil  *time.Time `json:"locked_until,omitempty"`   // 锁定截止时间
+
+	Banned    bool       `json:"banned,omitempty"`     // 是否被运维封禁，封禁后不能登录
+	BanReason string     `json:"ban_reason,omitempty"` // 封禁原因
+	BannedAt  *time.Time `json:"banned_at,omitempty"`  // 封禁时间
+}
+
+// IsLocked 判断账户在指定时间是否处于锁定状态
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
 }
@@ -0,0 +1,367 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"aigames/internal/database"
+	"aigames/internal/models"
+
+	"go.etcd.io/bbolt"
+)
+
+// boltStore 基于bolt的存储，各类记录保存在 database 中定义的存储桶里
+type boltStore struct {
+	db *bbolt.DB
+}
+
+// NewBoltStore 创建基于bolt的存储
+func NewBoltStore(db *bbolt.DB) Store {
+	return &boltStore{db: db}
+}
+
+// Users 用户存储
+func (s *boltStore) Users() UserRepository { return boltUsers{s} }
+
+// Rooms 房间存储
+func (s *boltStore) Rooms() RoomRepository { return boltRooms{s} }
+
+// Games 游戏记录存储
+func (s *boltStore) Games() GameRepository { return boltGames{s} }
+
+// Update 在一个bolt写事务中执行 fn
+func (s *boltStore) Update(fn func(tx Tx) error) error {
+	return s.update(func(tx *boltTx) error {
+		return fn(tx)
+	})
+}
+
+// View 在一个bolt读事务中执行 fn
+func (s *boltStore) View(fn func(tx Tx) error) error {
+	return s.view(func(tx *boltTx) error {
+		return fn(tx)
+	})
+}
+
+// update 在bolt写事务中执行
+func (s *boltStore) update(fn func(tx *boltTx) error) error {
+	return database.Update(s.db, func(tx *bbolt.Tx) error {
+		return fn(&boltTx{tx: tx})
+	})
+}
+
+// view 在bolt读事务中执行
+func (s *boltStore) view(fn func(tx *boltTx) error) error {
+	return database.View(s.db, func(tx *bbolt.Tx) error {
+		return fn(&boltTx{tx: tx})
+	})
+}
+
+// boltTx bolt事务中的记录操作
+type boltTx struct {
+	tx *bbolt.Tx
+}
+
+// Bucket 服务自己的存储桶
+func (t *boltTx) Bucket(name string) (Bucket, error) {
+	if !t.tx.Writable() {
+		return boltBucket{name: name, b: t.tx.Bucket([]byte(name))}, nil
+	}
+	b, err := t.tx.CreateBucketIfNotExists([]byte(name))
+	if err != nil {
+		return nil, err
+	}
+	return boltBucket{name: name, b: b}, nil
+}
+
+// boltBucket bolt存储桶，只读事务中不存在的存储桶 b 为nil
+type boltBucket struct {
+	name string
+	b    *bbolt.Bucket
+}
+
+// Get 读取记录
+func (b boltBucket) Get(key []byte) []byte {
+	if b.b == nil {
+		return nil
+	}
+	return b.b.Get(key)
+}
+
+// Put 写入记录
+func (b boltBucket) Put(key, value []byte) error {
+	if b.b == nil {
+		return fmt.Errorf("存储桶 %s 不存在", b.name)
+	}
+	return b.b.Put(key, value)
+}
+
+// Delete 删除记录
+func (b boltBucket) Delete(key []byte) error {
+	if b.b == nil {
+		return nil
+	}
+	return b.b.Delete(key)
+}
+
+// NextSequence 存储桶的自增序号
+func (b boltBucket) NextSequence() (uint64, error) {
+	if b.b == nil {
+		return 0, fmt.Errorf("存储桶 %s 不存在", b.name)
+	}
+	return b.b.NextSequence()
+}
+
+// Scan 按键的顺序遍历以 prefix 开头的记录
+func (b boltBucket) Scan(prefix []byte, fn func(key, value []byte) error) error {
+	if b.b == nil {
+		return nil
+	}
+	c := b.b.Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetUser 获取用户
+func (t *boltTx) GetUser(name string) (*models.User, error) {
+	b := t.tx.Bucket([]byte(database.BucketUsers))
+	if b == nil {
+		return nil, ErrUserNotFound
+	}
+	data := b.Get([]byte(name))
+	if data == nil {
+		return nil, ErrUserNotFound
+	}
+
+	var user models.User
+	if err := json.Unmarshal(data, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// saveUser 保存用户
+func (t *boltTx) saveUser(user *models.User) error {
+	b, err := t.tx.CreateBucketIfNotExists([]byte(database.BucketUsers))
+	if err != nil {
+		return err
+	}
+	return putJSON(b, user.Name, user)
+}
+
+// DeleteUser 删除用户
+func (t *boltTx) DeleteUser(name string) error {
+	b := t.tx.Bucket([]byte(database.BucketUsers))
+	if b == nil || b.Get([]byte(name)) == nil {
+		return ErrUserNotFound
+	}
+	return b.Delete([]byte(name))
+}
+
+// listRooms 获取所有房间
+func (t *boltTx) listRooms() ([]*models.Room, error) {
+	var rooms []*models.Room
+	b := t.tx.Bucket([]byte(database.BucketRooms))
+	if b == nil {
+		return rooms, nil
+	}
+	err := b.ForEach(func(k, v []byte) error {
+		var room models.Room
+		if err := json.Unmarshal(v, &room); err == nil {
+			rooms = append(rooms, &room)
+		}
+		return nil
+	})
+	return rooms, err
+}
+
+// saveRoom 保存房间
+func (t *boltTx) saveRoom(room *models.Room) error {
+	b, err := t.tx.CreateBucketIfNotExists([]byte(database.BucketRooms))
+	if err != nil {
+		return err
+	}
+	if err := putJSON(b, room.ID, room); err != nil {
+		return fmt.Errorf("保存房间 %s 失败: %w", room.ID, err)
+	}
+	return nil
+}
+
+// DeleteRoom 删除房间
+func (t *boltTx) DeleteRoom(id string) error {
+	b := t.tx.Bucket([]byte(database.BucketRooms))
+	if b == nil {
+		return nil
+	}
+	return b.Delete([]byte(id))
+}
+
+// getGame 获取游戏记录
+func (t *boltTx) getGame(id string) (*models.Game, error) {
+	b := t.tx.Bucket([]byte(database.BucketGames))
+	if b == nil {
+		return nil, fmt.Errorf("游戏不存在")
+	}
+	data := b.Get([]byte(id))
+	if data == nil {
+		return nil, fmt.Errorf("游戏不存在")
+	}
+
+	var game models.Game
+	if err := json.Unmarshal(data, &game); err != nil {
+		return nil, err
+	}
+	return &game, nil
+}
+
+// SaveGame 保存游戏记录
+func (t *boltTx) SaveGame(game *models.Game) error {
+	b, err := t.tx.CreateBucketIfNotExists([]byte(database.BucketGames))
+	if err != nil {
+		return err
+	}
+	return putJSON(b, game.ID, game)
+}
+
+// UpdateGames 修改所有游戏记录
+func (t *boltTx) UpdateGames(fn func(game *models.Game) bool) (int, error) {
+	b := t.tx.Bucket([]byte(database.BucketGames))
+	if b == nil {
+		return 0, nil
+	}
+
+	updates := make(map[string]*models.Game)
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		var game models.Game
+		if err := json.Unmarshal(v, &game); err != nil {
+			continue
+		}
+		if fn(&game) {
+			updates[string(k)] = &game
+		}
+	}
+
+	// 遍历结束后再写入，避免在游标遍历中修改存储桶
+	for key, game := range updates {
+		if err := putJSON(b, key, game); err != nil {
+			return 0, err
+		}
+	}
+	return len(updates), nil
+}
+
+// putJSON 序列化并写入记录
+func putJSON(b *bbolt.Bucket, key string, v any) error {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("序列化失败: %w", err)
+	}
+	return b.Put([]byte(key), encoded)
+}
+
+// boltUsers bolt用户存储
+type boltUsers struct {
+	store *boltStore
+}
+
+// Get 获取用户
+func (r boltUsers) Get(name string) (user *models.User, err error) {
+	err = r.store.view(func(tx *boltTx) error {
+		user, err = tx.GetUser(name)
+		return err
+	})
+	return user, err
+}
+
+// Save 保存用户
+func (r boltUsers) Save(user *models.User) error {
+	return r.store.update(func(tx *boltTx) error {
+		return tx.saveUser(user)
+	})
+}
+
+// Update 在同一个事务中读取、修改并保存用户
+func (r boltUsers) Update(name string, fn func(user *models.User) error) error {
+	return r.store.update(func(tx *boltTx) error {
+		user, err := tx.GetUser(name)
+		if err != nil {
+			return err
+		}
+		if err := fn(user); err != nil {
+			return err
+		}
+		return tx.saveUser(user)
+	})
+}
+
+// Delete 删除用户
+func (r boltUsers) Delete(name string) error {
+	return r.store.update(func(tx *boltTx) error {
+		return tx.DeleteUser(name)
+	})
+}
+
+// boltRooms bolt房间存储
+type boltRooms struct {
+	store *boltStore
+}
+
+// List 获取所有房间
+func (r boltRooms) List() (rooms []*models.Room, err error) {
+	err = r.store.view(func(tx *boltTx) error {
+		rooms, err = tx.listRooms()
+		return err
+	})
+	return rooms, err
+}
+
+// Save 保存房间
+func (r boltRooms) Save(room *models.Room) error {
+	return r.SaveAll([]*models.Room{room})
+}
+
+// SaveAll 在一个事务中保存多个房间
+func (r boltRooms) SaveAll(rooms []*models.Room) error {
+	return r.store.update(func(tx *boltTx) error {
+		for _, room := range rooms {
+			if err := tx.saveRoom(room); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete 删除房间
+func (r boltRooms) Delete(id string) error {
+	return r.store.update(func(tx *boltTx) error {
+		return tx.DeleteRoom(id)
+	})
+}
+
+// boltGames bolt游戏记录存储
+type boltGames struct {
+	store *boltStore
+}
+
+// Get 获取游戏记录
+func (r boltGames) Get(id string) (game *models.Game, err error) {
+	err = r.store.view(func(tx *boltTx) error {
+		game, err = tx.getGame(id)
+		return err
+	})
+	return game, err
+}
+
+// Save 保存游戏记录
+func (r boltGames) Save(game *models.Game) error {
+	return r.store.update(func(tx *boltTx) error {
+		return tx.SaveGame(game)
+	})
+}
@@ -0,0 +1,362 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+
+	"aigames/internal/models"
+)
+
+// memoryStore 内存存储，记录保存为JSON编码，与bolt一样读写的都是副本
+// 所有操作共用一把锁，修改前复制记录的映射，失败时恢复执行前的记录；只用于测试和模拟，不适合大量记录
+type memoryStore struct {
+	mutex     sync.Mutex
+	users     map[string][]byte
+	rooms     map[string][]byte
+	games     map[string][]byte
+	buckets   map[string]map[string][]byte // 服务自己的存储桶
+	sequences map[string]uint64            // 存储桶的自增序号
+}
+
+// NewMemoryStore 创建空的内存存储
+func NewMemoryStore() Store {
+	return &memoryStore{
+		users:     make(map[string][]byte),
+		rooms:     make(map[string][]byte),
+		games:     make(map[string][]byte),
+		buckets:   make(map[string]map[string][]byte),
+		sequences: make(map[string]uint64),
+	}
+}
+
+// Users 用户存储
+func (s *memoryStore) Users() UserRepository { return memoryUsers{s} }
+
+// Rooms 房间存储
+func (s *memoryStore) Rooms() RoomRepository { return memoryRooms{s} }
+
+// Games 游戏记录存储
+func (s *memoryStore) Games() GameRepository { return memoryGames{s} }
+
+// Update 在一个事务中执行 fn，失败时恢复执行前的记录
+func (s *memoryStore) Update(fn func(tx Tx) error) error {
+	return s.update(func(tx *memoryTx) error {
+		return fn(tx)
+	})
+}
+
+// View 在只读事务中执行 fn
+func (s *memoryStore) View(fn func(tx Tx) error) error {
+	return s.view(func(tx *memoryTx) error {
+		return fn(tx)
+	})
+}
+
+// update 加锁执行 fn，失败时恢复执行前的记录
+// 记录的值写入后不再修改，复制映射即可保存执行前的状态
+func (s *memoryStore) update(fn func(tx *memoryTx) error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	users, rooms, games := maps.Clone(s.users), maps.Clone(s.rooms), maps.Clone(s.games)
+	buckets, sequences := make(map[string]map[string][]byte, len(s.buckets)), maps.Clone(s.sequences)
+	for name, records := range s.buckets {
+		buckets[name] = maps.Clone(records)
+	}
+	if err := fn(&memoryTx{store: s, writable: true}); err != nil {
+		s.users, s.rooms, s.games = users, rooms, games
+		s.buckets, s.sequences = buckets, sequences
+		return err
+	}
+	return nil
+}
+
+// view 加锁执行只读操作
+func (s *memoryStore) view(fn func(tx *memoryTx) error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return fn(&memoryTx{store: s})
+}
+
+// memoryTx 内存存储事务中的记录操作，调用方持有锁
+type memoryTx struct {
+	store    *memoryStore
+	writable bool
+}
+
+// Bucket 服务自己的存储桶
+func (t *memoryTx) Bucket(name string) (Bucket, error) {
+	records, ok := t.store.buckets[name]
+	if !ok && t.writable {
+		records = make(map[string][]byte)
+		t.store.buckets[name] = records
+	}
+	return &memoryBucket{tx: t, name: name, records: records}, nil
+}
+
+// memoryBucket 内存存储桶，只读事务中不存在的存储桶 records 为nil
+type memoryBucket struct {
+	tx      *memoryTx
+	name    string
+	records map[string][]byte
+}
+
+// Get 读取记录
+func (b *memoryBucket) Get(key []byte) []byte {
+	return b.records[string(key)]
+}
+
+// Put 写入记录，保存值的副本
+func (b *memoryBucket) Put(key, value []byte) error {
+	if !b.tx.writable {
+		return fmt.Errorf("只读事务不能修改存储桶 %s", b.name)
+	}
+	b.records[string(key)] = append([]byte{}, value...)
+	return nil
+}
+
+// Delete 删除记录
+func (b *memoryBucket) Delete(key []byte) error {
+	if !b.tx.writable {
+		return fmt.Errorf("只读事务不能修改存储桶 %s", b.name)
+	}
+	delete(b.records, string(key))
+	return nil
+}
+
+// NextSequence 存储桶的自增序号
+func (b *memoryBucket) NextSequence() (uint64, error) {
+	if !b.tx.writable {
+		return 0, fmt.Errorf("只读事务不能修改存储桶 %s", b.name)
+	}
+	b.tx.store.sequences[b.name]++
+	return b.tx.store.sequences[b.name], nil
+}
+
+// Scan 按键的顺序遍历以 prefix 开头的记录
+func (b *memoryBucket) Scan(prefix []byte, fn func(key, value []byte) error) error {
+	for _, key := range sortedKeys(b.records) {
+		if !strings.HasPrefix(key, string(prefix)) {
+			continue
+		}
+		if err := fn([]byte(key), b.records[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetUser 获取用户
+func (t *memoryTx) GetUser(name string) (*models.User, error) {
+	data, ok := t.store.users[name]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	var user models.User
+	if err := json.Unmarshal(data, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// saveUser 保存用户
+func (t *memoryTx) saveUser(user *models.User) error {
+	return putRecord(t.store.users, user.Name, user)
+}
+
+// DeleteUser 删除用户
+func (t *memoryTx) DeleteUser(name string) error {
+	if _, ok := t.store.users[name]; !ok {
+		return ErrUserNotFound
+	}
+	delete(t.store.users, name)
+	return nil
+}
+
+// listRooms 按房间ID顺序获取所有房间
+func (t *memoryTx) listRooms() []*models.Room {
+	var rooms []*models.Room
+	for _, id := range sortedKeys(t.store.rooms) {
+		var room models.Room
+		if err := json.Unmarshal(t.store.rooms[id], &room); err == nil {
+			rooms = append(rooms, &room)
+		}
+	}
+	return rooms
+}
+
+// saveRoom 保存房间
+func (t *memoryTx) saveRoom(room *models.Room) error {
+	if err := putRecord(t.store.rooms, room.ID, room); err != nil {
+		return fmt.Errorf("保存房间 %s 失败: %w", room.ID, err)
+	}
+	return nil
+}
+
+// DeleteRoom 删除房间
+func (t *memoryTx) DeleteRoom(id string) error {
+	delete(t.store.rooms, id)
+	return nil
+}
+
+// getGame 获取游戏记录
+func (t *memoryTx) getGame(id string) (*models.Game, error) {
+	data, ok := t.store.games[id]
+	if !ok {
+		return nil, fmt.Errorf("游戏不存在")
+	}
+	var game models.Game
+	if err := json.Unmarshal(data, &game); err != nil {
+		return nil, err
+	}
+	return &game, nil
+}
+
+// SaveGame 保存游戏记录
+func (t *memoryTx) SaveGame(game *models.Game) error {
+	return putRecord(t.store.games, game.ID, game)
+}
+
+// UpdateGames 修改所有游戏记录
+func (t *memoryTx) UpdateGames(fn func(game *models.Game) bool) (int, error) {
+	updated := 0
+	for _, id := range sortedKeys(t.store.games) {
+		var game models.Game
+		if err := json.Unmarshal(t.store.games[id], &game); err != nil {
+			continue
+		}
+		if !fn(&game) {
+			continue
+		}
+		if err := putRecord(t.store.games, id, &game); err != nil {
+			return 0, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// putRecord 序列化并保存记录
+func putRecord(records map[string][]byte, key string, v any) error {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("序列化失败: %w", err)
+	}
+	records[key] = encoded
+	return nil
+}
+
+// sortedKeys 按顺序返回所有键，与bolt的遍历顺序一致
+func sortedKeys(records map[string][]byte) []string {
+	keys := make([]string, 0, len(records))
+	for key := range records {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// memoryUsers 内存用户存储
+type memoryUsers struct {
+	store *memoryStore
+}
+
+// Get 获取用户
+func (r memoryUsers) Get(name string) (user *models.User, err error) {
+	err = r.store.view(func(tx *memoryTx) error {
+		user, err = tx.GetUser(name)
+		return err
+	})
+	return user, err
+}
+
+// Save 保存用户
+func (r memoryUsers) Save(user *models.User) error {
+	return r.store.update(func(tx *memoryTx) error {
+		return tx.saveUser(user)
+	})
+}
+
+// Update 读取、修改并保存用户
+func (r memoryUsers) Update(name string, fn func(user *models.User) error) error {
+	return r.store.update(func(tx *memoryTx) error {
+		user, err := tx.GetUser(name)
+		if err != nil {
+			return err
+		}
+		if err := fn(user); err != nil {
+			return err
+		}
+		return tx.saveUser(user)
+	})
+}
+
+// Delete 删除用户
+func (r memoryUsers) Delete(name string) error {
+	return r.store.update(func(tx *memoryTx) error {
+		return tx.DeleteUser(name)
+	})
+}
+
+// memoryRooms 内存房间存储
+type memoryRooms struct {
+	store *memoryStore
+}
+
+// List 获取所有房间
+func (r memoryRooms) List() (rooms []*models.Room, err error) {
+	err = r.store.view(func(tx *memoryTx) error {
+		rooms = tx.listRooms()
+		return nil
+	})
+	return rooms, err
+}
+
+// Save 保存房间
+func (r memoryRooms) Save(room *models.Room) error {
+	return r.SaveAll([]*models.Room{room})
+}
+
+// SaveAll 保存多个房间，有房间无法保存时不保存任何房间
+func (r memoryRooms) SaveAll(rooms []*models.Room) error {
+	return r.store.update(func(tx *memoryTx) error {
+		for _, room := range rooms {
+			if err := tx.saveRoom(room); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete 删除房间
+func (r memoryRooms) Delete(id string) error {
+	return r.store.update(func(tx *memoryTx) error {
+		return tx.DeleteRoom(id)
+	})
+}
+
+// memoryGames 内存游戏记录存储
+type memoryGames struct {
+	store *memoryStore
+}
+
+// Get 获取游戏记录
+func (r memoryGames) Get(id string) (game *models.Game, err error) {
+	err = r.store.view(func(tx *memoryTx) error {
+		game, err = tx.getGame(id)
+		return err
+	})
+	return game, err
+}
+
+// Save 保存游戏记录
+func (r memoryGames) Save(game *models.Game) error {
+	return r.store.update(func(tx *memoryTx) error {
+		return tx.SaveGame(game)
+	})
+}
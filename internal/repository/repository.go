@@ -0,0 +1,92 @@
+// Package repository 用户、房间和游戏记录，以及各服务自己的记录的存储
+//
+// 服务只依赖这里的接口，不直接访问bolt。Bolt 实现用于正式运行，
+// Memory 实现把记录保存在内存中，用于不需要数据库文件的服务测试和模拟。
+// 两种实现都按值保存记录：读取返回副本，修改返回的对象不会影响已保存的记录。
+// 用户、房间和游戏记录有各自的接口；比赛、金币、好友等只由一个服务读写的记录
+// 保存在该服务自己的存储桶中，通过 Tx.Bucket 按键值读写，与其他记录在同一个事务中提交或回滚。
+package repository
+
+import (
+	"errors"
+
+	"aigames/internal/models"
+)
+
+// ErrUserNotFound 用户不存在
+var ErrUserNotFound = errors.New("用户不存在")
+
+// Store 用户、房间和游戏记录的存储
+// 各类记录的单项操作通过 Users、Rooms、Games 执行，跨记录类型的修改通过 Update 在一个事务中执行
+type Store interface {
+	Users() UserRepository
+	Rooms() RoomRepository
+	Games() GameRepository
+	// Update 在一个事务中执行 fn，fn 返回错误时事务中的所有修改回滚
+	Update(fn func(tx Tx) error) error
+	// View 在一个只读事务中执行 fn
+	View(fn func(tx Tx) error) error
+}
+
+// Tx 事务中的记录操作，只能在 Store.Update 的回调中使用
+type Tx interface {
+	// GetUser 获取用户，不存在时返回 ErrUserNotFound
+	GetUser(name string) (*models.User, error)
+	// DeleteUser 删除用户，不存在时返回 ErrUserNotFound
+	DeleteUser(name string) error
+	// SaveGame 保存游戏记录，已存在时覆盖
+	SaveGame(game *models.Game) error
+	// UpdateGames 遍历所有游戏记录，fn 返回 true 的记录写回，返回写回的记录数；无法解析的记录保持原样
+	UpdateGames(fn func(game *models.Game) bool) (int, error)
+	// DeleteRoom 删除房间，不存在时忽略
+	DeleteRoom(id string) error
+	// Bucket 服务自己的记录所在的存储桶，写事务中不存在时创建，只读事务中不存在时为空
+	Bucket(name string) (Bucket, error)
+}
+
+// Bucket 按键值保存的一组记录，键按字节顺序排列
+// 读取的值只在事务内有效，需要保留时复制
+type Bucket interface {
+	// Get 读取记录，不存在时返回nil
+	Get(key []byte) []byte
+	// Put 写入记录，已存在时覆盖
+	Put(key, value []byte) error
+	// Delete 删除记录，不存在时忽略
+	Delete(key []byte) error
+	// NextSequence 存储桶的自增序号，从1开始
+	NextSequence() (uint64, error)
+	// Scan 按键的顺序遍历以 prefix 开头的记录，prefix 为空时遍历全部；fn 中不能修改存储桶
+	Scan(prefix []byte, fn func(key, value []byte) error) error
+}
+
+// UserRepository 用户存储，按用户名索引
+type UserRepository interface {
+	// Get 获取用户，不存在时返回 ErrUserNotFound
+	Get(name string) (*models.User, error)
+	// Save 保存用户，已存在时覆盖
+	Save(user *models.User) error
+	// Update 在同一个事务中读取、修改并保存用户，fn 返回错误时不保存
+	Update(name string, fn func(user *models.User) error) error
+	// Delete 删除用户，不存在时返回 ErrUserNotFound
+	Delete(name string) error
+}
+
+// RoomRepository 房间存储，按房间ID索引
+type RoomRepository interface {
+	// List 获取所有房间，按房间ID排序，无法解析的记录跳过
+	List() ([]*models.Room, error)
+	// Save 保存房间，已存在时覆盖
+	Save(room *models.Room) error
+	// SaveAll 在一个事务中保存多个房间
+	SaveAll(rooms []*models.Room) error
+	// Delete 删除房间，不存在时忽略
+	Delete(id string) error
+}
+
+// GameRepository 已归档的游戏记录存储，按游戏ID索引
+type GameRepository interface {
+	// Get 获取游戏记录，不存在时返回"游戏不存在"
+	Get(id string) (*models.Game, error)
+	// Save 保存游戏记录，已存在时覆盖
+	Save(game *models.Game) error
+}
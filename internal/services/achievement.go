@@ -0,0 +1,260 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"aigames/internal/config"
+	"aigames/internal/models"
+	"aigames/internal/repository"
+	"aigames/pkg/logger"
+	"aigames/pkg/protocol"
+
+	"github.com/spf13/viper"
+)
+
+// bucketAchievements 成就和任务进度存储桶 key: 用户名
+const bucketAchievements = "achievements"
+
+// AchievementService 成就和任务服务
+// 成就和每日、每周任务从数据文件加载，每局结束后按玩家累计事件次数，达成后在同一个事务中发放金币奖励
+type AchievementService struct {
+	store    repository.Store
+	coins    *CoinService
+	presence *PresenceService
+	goals    *models.GoalSet
+}
+
+// goalReward 一次达成的成就或任务，事务提交后推送
+type goalReward struct {
+	username string
+	kind     string
+	goal     models.Goal
+}
+
+// NewAchievementService 创建成就服务实例，定义文件不存在时不启用任何成就和任务
+func NewAchievementService(store repository.Store, coins *CoinService, presence *PresenceService, cfg config.GameConfig) (*AchievementService, error) {
+	goals, err := loadGoals(cfg.AchievementsFile)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("加载成就 %d 个，每日任务 %d 个，每周任务 %d 个",
+		len(goals.Achievements), len(goals.Daily), len(goals.Weekly))
+
+	return &AchievementService{
+		store:    store,
+		coins:    coins,
+		presence: presence,
+		goals:    goals,
+	}, nil
+}
+
+// loadGoals 从数据文件加载成就和任务定义
+func loadGoals(path string) (*models.GoalSet, error) {
+	goals := &models.GoalSet{}
+	if path == "" {
+		return goals, nil
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		logger.Warn("成就定义文件 %s 不存在，不启用成就和任务", path)
+		return goals, nil
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("读取成就定义文件失败: %w", err)
+	}
+	if err := v.Unmarshal(goals); err != nil {
+		return nil, fmt.Errorf("解析成就定义文件失败: %w", err)
+	}
+	if err := goals.Validate(); err != nil {
+		return nil, fmt.Errorf("成就定义无效: %w", err)
+	}
+	return goals, nil
+}
+
+// Goals 获取全部成就和任务定义
+func (as *AchievementService) Goals() *models.GoalSet {
+	return as.goals
+}
+
+// Progress 获取用户的成就和任务进度，已过期的任务周期按新周期返回
+func (as *AchievementService) Progress(username string) (*models.PlayerProgress, error) {
+	var progress *models.PlayerProgress
+	err := as.store.View(func(tx repository.Tx) error {
+		b, err := tx.Bucket(bucketAchievements)
+		if err != nil {
+			return err
+		}
+		progress, err = readProgress(b, username)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	progress.Daily.Roll(models.DailyPeriod(now))
+	progress.Weekly.Roll(models.WeeklyPeriod(now))
+	return progress, nil
+}
+
+// Record 统计一局已结束游戏中各玩家的事件，changes 为该局的金币变化，用于统计赢得的金币
+// 每局只统计一次，奖励和进度在同一个事务中保存
+func (as *AchievementService) Record(room *models.Room, game *models.Game, changes []models.CoinChange) {
+	if game == nil || game.Status != models.GameStatusFinished {
+		return
+	}
+
+	coinsWon := make(map[string]int64)
+	for _, change := range changes {
+		if change.Delta > 0 {
+			coinsWon[change.UserName] = change.Delta
+		}
+	}
+
+	now := time.Now()
+	var rewards []goalReward
+	balances := make(map[string]int64)
+	err := as.store.Update(func(tx repository.Tx) error {
+		b, err := tx.Bucket(bucketAchievements)
+		if err != nil {
+			return err
+		}
+
+		for i, player := range game.Players {
+			if player == nil || player.IsAI {
+				continue
+			}
+			progress, err := readProgress(b, player.UserName)
+			if err != nil {
+				return err
+			}
+			if progress.LastGameID == game.ID {
+				continue // 已统计
+			}
+			progress.LastGameID = game.ID
+			progress.UpdatedAt = now
+			progress.Daily.Roll(models.DailyPeriod(now))
+			progress.Weekly.Roll(models.WeeklyPeriod(now))
+
+			events := models.GameEvents(game, models.PlayerPosition(i))
+			if won := coinsWon[player.UserName]; won > 0 {
+				events[models.EventCoinsWon] = won
+			}
+			for event, count := range events {
+				progress.Counters[event] += count
+				progress.Daily.Progress[event] += count
+				progress.Weekly.Progress[event] += count
+			}
+
+			for _, goal := range reached(as.goals.Achievements, progress.Counters, progress.Unlocked, now) {
+				rewards = append(rewards, goalReward{player.UserName, protocol.GoalKindAchievement, goal})
+			}
+			for _, goal := range reached(as.goals.Daily, progress.Daily.Progress, progress.Daily.Completed, now) {
+				rewards = append(rewards, goalReward{player.UserName, protocol.GoalKindDaily, goal})
+			}
+			for _, goal := range reached(as.goals.Weekly, progress.Weekly.Progress, progress.Weekly.Completed, now) {
+				rewards = append(rewards, goalReward{player.UserName, protocol.GoalKindWeekly, goal})
+			}
+
+			encoded, err := json.Marshal(progress)
+			if err != nil {
+				return fmt.Errorf("序列化成就进度失败: %w", err)
+			}
+			if err := b.Put([]byte(player.UserName), encoded); err != nil {
+				return err
+			}
+		}
+
+		if len(rewards) == 0 {
+			return nil
+		}
+		lt := newLedgerTx(tx, as.coins, now)
+		for _, reward := range rewards {
+			if reward.goal.Reward > 0 {
+				err := lt.transfer(models.LedgerEntry{
+					Type:   models.LedgerTypeReward,
+					To:     reward.username,
+					Amount: reward.goal.Reward,
+					RoomID: room.ID,
+					GameID: game.ID,
+					Note:   reward.goal.Name,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			wallet, err := lt.wallet(reward.username)
+			if err != nil {
+				return err
+			}
+			balances[reward.username] = wallet.Balance
+		}
+		return lt.commit()
+	})
+	if err != nil {
+		logger.Error("统计游戏 %s 的成就和任务失败: %v", game.ID, err)
+		return
+	}
+
+	for _, reward := range rewards {
+		route := protocol.RouteMissionCompleted
+		if reward.kind == protocol.GoalKindAchievement {
+			route = protocol.RouteAchievementUnlocked
+		}
+		logger.Info("用户 %s 达成 %s，奖励 %d 金币", reward.username, reward.goal.Name, reward.goal.Reward)
+		as.presence.Push(reward.username, route, protocol.GoalRewardData{
+			Kind:    reward.kind,
+			Goal:    protocol.NewGoalData(reward.goal),
+			Balance: balances[reward.username],
+		})
+	}
+}
+
+// reached 找出新达成的成就或任务并记录完成时间
+func reached(goals []models.Goal, counters map[string]int64, completed map[string]time.Time, now time.Time) []models.Goal {
+	var result []models.Goal
+	for _, goal := range goals {
+		if _, ok := completed[goal.ID]; ok {
+			continue
+		}
+		if counters[goal.Event] >= goal.Target {
+			completed[goal.ID] = now
+			result = append(result, goal)
+		}
+	}
+	return result
+}
+
+// readProgress 读取用户进度，不存在时返回空进度
+func readProgress(b repository.Bucket, username string) (*models.PlayerProgress, error) {
+	progress := models.NewPlayerProgress()
+	data := b.Get([]byte(username))
+	if data == nil {
+		return progress, nil
+	}
+	if err := json.Unmarshal(data, progress); err != nil {
+		return nil, fmt.Errorf("解析成就进度失败: %w", err)
+	}
+	if progress.Counters == nil {
+		progress.Counters = make(map[string]int64)
+	}
+	if progress.Unlocked == nil {
+		progress.Unlocked = make(map[string]time.Time)
+	}
+	return progress, nil
+}
+
+// DeleteProgress 注销用户时删除其成就和任务进度，作为 UserService.OnDelete 的回调
+func (as *AchievementService) DeleteProgress(tx repository.Tx, name, alias string) error {
+	b, err := tx.Bucket(bucketAchievements)
+	if err != nil {
+		return err
+	}
+	return b.Delete([]byte(name))
+}
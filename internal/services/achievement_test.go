@@ -0,0 +1,162 @@
+package services
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"aigames/internal/config"
+	"aigames/internal/models"
+	"aigames/internal/repository"
+)
+
+// testGoals 首胜成就奖励100金币，每日完成2局奖励50金币
+const testGoals = `
+achievements:
+  - id: first_win
+    name: 初战告捷
+    event: wins
+    target: 1
+    reward: 100
+daily:
+  - id: daily_games_2
+    name: 每日对局
+    event: games
+    target: 2
+    reward: 50
+`
+
+// goalsFile 把成就定义写入临时文件，返回修改配置的函数
+func goalsFile(t *testing.T, content string) func(cfg *config.Config) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "achievements.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return func(cfg *config.Config) {
+		cfg.Game.AchievementsFile = path
+	}
+}
+
+// aliceGame 创建 alice 与两个AI的已结束游戏，score 为 alice 的得分
+func aliceGame(id string, score int) *models.Game {
+	return finishedGame(id, [3]string{"alice", "AI-A", "AI-B"}, [3]int{score, -score / 2, -score / 2})
+}
+
+func TestAchievementRewardsGrantedOnce(t *testing.T) {
+	env := newTestEnv(t, goalsFile(t, testGoals))
+	env.addUsers(t, "alice")
+	as := env.achievements
+	room := &models.Room{ID: "room1"}
+	start := env.coins.BalanceOf("alice")
+
+	as.Record(room, aliceGame("g1", 2), nil)
+	// 同一局重复统计不会再次计数和发放奖励
+	as.Record(room, aliceGame("g1", 2), nil)
+	if got := env.coins.BalanceOf("alice"); got != start+100 {
+		t.Fatalf("首胜后余额为 %d，应为 %d", got, start+100)
+	}
+
+	as.Record(room, aliceGame("g2", -2), nil)
+	progress, err := as.Progress("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.Counters[models.EventGames] != 2 || progress.Counters[models.EventWins] != 1 {
+		t.Fatalf("累计次数错误: %v", progress.Counters)
+	}
+	if _, ok := progress.Unlocked["first_win"]; !ok {
+		t.Fatal("首胜成就没有解锁")
+	}
+	if _, ok := progress.Daily.Completed["daily_games_2"]; !ok {
+		t.Fatal("每日任务没有完成")
+	}
+	if got := env.coins.BalanceOf("alice"); got != start+150 {
+		t.Fatalf("完成每日任务后余额为 %d，应为 %d", got, start+150)
+	}
+}
+
+func TestMissionProgressResetsEachPeriod(t *testing.T) {
+	env := newTestEnv(t, goalsFile(t, testGoals))
+	env.addUsers(t, "alice")
+	as := env.achievements
+
+	as.Record(&models.Room{ID: "room1"}, aliceGame("g1", 2), nil)
+
+	// 把每日和每周任务的周期改成已过去的周期
+	err := env.store.Update(func(tx repository.Tx) error {
+		b, err := tx.Bucket(bucketAchievements)
+		if err != nil {
+			return err
+		}
+		progress, err := readProgress(b, "alice")
+		if err != nil {
+			return err
+		}
+		progress.Daily.Period = "2000-01-01"
+		progress.Weekly.Period = "2000-W01"
+		data, err := json.Marshal(progress)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte("alice"), data)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	progress, err := as.Progress("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(progress.Daily.Progress) != 0 || len(progress.Weekly.Progress) != 0 {
+		t.Fatalf("新周期的任务进度没有清空: %v %v", progress.Daily.Progress, progress.Weekly.Progress)
+	}
+	if progress.Counters[models.EventGames] != 1 {
+		t.Fatal("成就的终身累计被清空")
+	}
+}
+
+func TestDeleteUserRemovesProgress(t *testing.T) {
+	env := newTestEnv(t, goalsFile(t, testGoals))
+	env.addUsers(t, "alice")
+
+	env.achievements.Record(&models.Room{ID: "room1"}, aliceGame("g1", 2), nil)
+	if _, err := env.users.DeleteUser("alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	progress, err := env.achievements.Progress("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(progress.Counters) != 0 || len(progress.Unlocked) != 0 {
+		t.Fatal("注销后成就进度仍然存在")
+	}
+}
+
+func TestLoadGoalsRejectsInvalidDefinitions(t *testing.T) {
+	tests := map[string]string{
+		"重复id": "achievements:\n  - {id: a, event: wins, target: 1}\ndaily:\n  - {id: a, event: games, target: 1}\n",
+		"未知事件": "achievements:\n  - {id: a, event: jumps, target: 1}\n",
+		"目标为0": "achievements:\n  - {id: a, event: wins, target: 0}\n",
+		"负数奖励": "daily:\n  - {id: a, event: games, target: 1, reward: -1}\n",
+		"格式错误": "achievements: [\n",
+	}
+	for name, content := range tests {
+		path := filepath.Join(t.TempDir(), "achievements.yaml")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadGoals(path); err == nil {
+			t.Errorf("%s: 无效的定义被接受", name)
+		}
+	}
+
+	// 定义文件不存在时不启用成就和任务
+	goals, err := loadGoals(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil || len(goals.Achievements)+len(goals.Daily)+len(goals.Weekly) != 0 {
+		t.Fatalf("定义文件不存在时返回 %v, %v", goals, err)
+	}
+}
@@ -2,7 +2,7 @@ package services
 
 import (
 	"fmt"
-	"time"
+	"sync/atomic"
 
 	"aigames/internal/ai"
 	"aigames/internal/models"
@@ -10,61 +10,63 @@ import (
 )
 
 // AIController AI控制器
+// 控制器本身不持有goroutine，轮到AI行动时把决策提交到共享的AI工作池
 type AIController struct {
 	player      *models.GamePlayer
 	gameService *GameService
-	actionChan  chan bool
-	stopChan    chan bool
+	pool        *AIWorkerPool
+	scheduled   int32 // 是否已有待执行的决策
+	stopped     int32 // 是否已停止
 	roomID      string
 }
 
 // NewAIController 创建AI控制器
-func NewAIController(player *models.GamePlayer, gameService *GameService, roomID string) *AIController {
+func NewAIController(player *models.GamePlayer, gameService *GameService, pool *AIWorkerPool, roomID string) *AIController {
 	return &AIController{
 		player:      player,
 		gameService: gameService,
-		actionChan:  make(chan bool, 1),
-		stopChan:    make(chan bool, 1),
+		pool:        pool,
 		roomID:      roomID,
 	}
 }
 
 // Start 启动AI控制器
 func (c *AIController) Start() {
+	atomic.StoreInt32(&c.stopped, 0)
 	logger.Info("AI玩家 %s 控制器启动", c.player.UserName)
-
-	for {
-		select {
-		case <-c.actionChan:
-			// 模拟思考时间
-			time.Sleep(1 * time.Second)
-
-			// 根据游戏状态执行不同的操作
-			if err := c.executeAction(); err != nil {
-				logger.Error("AI玩家 %s 执行操作失败: %v", c.player.UserName, err)
-			}
-
-		case <-c.stopChan:
-			logger.Info("AI玩家 %s 控制器停止", c.player.UserName)
-			return
-		}
-	}
 }
 
-// Stop 停止AI控制器
+// Stop 停止AI控制器，已提交但未执行的决策会被忽略
 func (c *AIController) Stop() {
-	select {
-	case c.stopChan <- true:
-	default:
+	if atomic.CompareAndSwapInt32(&c.stopped, 0, 1) {
+		logger.Info("AI玩家 %s 控制器停止", c.player.UserName)
 	}
 }
 
 // NotifyTurn 通知轮到AI行动
 func (c *AIController) NotifyTurn() {
-	select {
-	case c.actionChan <- true:
-	default:
+	if atomic.LoadInt32(&c.stopped) == 1 {
+		return
+	}
+	// 同一时间只保留一个待执行的决策
+	if !atomic.CompareAndSwapInt32(&c.scheduled, 0, 1) {
+		return
 	}
+	c.pool.Submit(c)
+}
+
+// run 由工作池调用，执行一次决策
+func (c *AIController) run() error {
+	atomic.StoreInt32(&c.scheduled, 0)
+	if atomic.LoadInt32(&c.stopped) == 1 {
+		return nil
+	}
+
+	if err := c.executeAction(); err != nil {
+		logger.Error("AI玩家 %s 执行操作失败: %v", c.player.UserName, err)
+		return err
+	}
+	return nil
 }
 
 // executeAction 执行AI操作
@@ -80,14 +82,19 @@ func (c *AIController) executeAction() error {
 		UserName: c.player.UserName,
 	}
 
+	// 决策与 cmd/simulate 使用同一套 ai.DecideCall、ai.DecidePlay
 	switch game.Status {
 	case models.GameStatusCalling:
-		// 叫地主阶段：AI永远不叫地主
-		return ai.CallLandlord(playerWrapper, c.gameService, c.roomID, false)
+		call := ai.DecideCall(game, c.player.Position)
+		return ai.CallLandlord(playerWrapper, c.gameService, c.roomID, call)
 
 	case models.GameStatusPlaying:
-		// 出牌阶段：AI永远过牌
-		return ai.PassTurn(playerWrapper, c.gameService, c.roomID)
+		cards := ai.DecidePlay(game, c.player.Position)
+		if len(cards) == 0 {
+			return ai.PassTurn(playerWrapper, c.gameService, c.roomID)
+		}
+		logger.Info("AI玩家 %s 出牌: %v", c.player.UserName, cards)
+		return c.gameService.PlayCards(c.roomID, c.player.UserName, cards)
 
 	default:
 		logger.Info("AI玩家 %s 在状态 %d 下无需操作", c.player.UserName, game.Status)
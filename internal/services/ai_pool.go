@@ -0,0 +1,235 @@
+package services
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"aigames/internal/config"
+	"aigames/pkg/logger"
+)
+
+// AIWorkerPool AI决策工作池，所有房间的AI共享固定数量的worker
+type AIWorkerPool struct {
+	workers       int
+	thinkTime     time.Duration // 基础思考时间
+	thinkJitter   time.Duration // 思考时间随机抖动上限
+	fastMode      bool          // 快速模式，跳过思考时间
+	statsInterval time.Duration // 负载统计日志间隔
+
+	queue   []*aiTask  // 等待worker执行的决策
+	cond    *sync.Cond // 队列通知
+	mutex   sync.Mutex // 保护队列和统计数据
+	stopped bool
+	wg      sync.WaitGroup
+	done    chan struct{}
+
+	thinking     int           // 思考中（尚未入队）的决策数
+	running      int           // 正在执行的决策数
+	decisions    int64         // 已完成的决策数
+	failures     int64         // 执行失败的决策数
+	totalLatency time.Duration // 累计执行耗时
+	maxLatency   time.Duration // 最大执行耗时
+	totalWait    time.Duration // 累计排队耗时
+}
+
+// aiTask AI决策任务
+type aiTask struct {
+	controller *AIController
+	enqueuedAt time.Time
+}
+
+// AIPoolStats AI工作池负载统计
+type AIPoolStats struct {
+	Workers      int           `json:"workers"`        // worker数量
+	QueueDepth   int           `json:"queue_depth"`    // 排队中的决策数
+	Thinking     int           `json:"thinking"`       // 思考中的决策数
+	Running      int           `json:"running"`        // 执行中的决策数
+	Decisions    int64         `json:"decisions"`      // 已完成的决策数
+	Failures     int64         `json:"failures"`       // 执行失败的决策数
+	AvgLatency   time.Duration `json:"avg_latency"`    // 平均执行耗时
+	MaxLatency   time.Duration `json:"max_latency"`    // 最大执行耗时
+	AvgQueueWait time.Duration `json:"avg_queue_wait"` // 平均排队耗时
+}
+
+// NewAIWorkerPool 根据AI配置创建工作池
+func NewAIWorkerPool(cfg config.AIConfig) *AIWorkerPool {
+	workers := cfg.MaxConcurrent
+	if workers <= 0 {
+		workers = 1
+	}
+
+	pool := &AIWorkerPool{
+		workers:       workers,
+		thinkTime:     time.Duration(cfg.DefaultThinkTime) * time.Second,
+		thinkJitter:   time.Duration(cfg.ThinkJitter) * time.Millisecond,
+		fastMode:      cfg.FastMode,
+		statsInterval: time.Duration(cfg.StatsInterval) * time.Second,
+		done:          make(chan struct{}),
+	}
+	pool.cond = sync.NewCond(&pool.mutex)
+	return pool
+}
+
+// Start 启动worker
+func (p *AIWorkerPool) Start() {
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+
+	if p.statsInterval > 0 {
+		go p.reportStats()
+	}
+
+	logger.Info("AI工作池启动: worker=%d, 思考时间=%v, 抖动=%v, 快速模式=%t",
+		p.workers, p.thinkTime, p.thinkJitter, p.fastMode)
+}
+
+// Stop 停止worker，排队中的决策将被丢弃
+func (p *AIWorkerPool) Stop() {
+	p.mutex.Lock()
+	if p.stopped {
+		p.mutex.Unlock()
+		return
+	}
+	p.stopped = true
+	p.queue = nil
+	p.mutex.Unlock()
+
+	close(p.done)
+	p.cond.Broadcast()
+	p.wg.Wait()
+	logger.Info("AI工作池已停止")
+}
+
+// Submit 提交一次AI决策，思考时间过后进入队列
+func (p *AIWorkerPool) Submit(controller *AIController) {
+	delay := p.thinkDelay()
+	if delay <= 0 {
+		p.enqueue(controller)
+		return
+	}
+
+	p.mutex.Lock()
+	p.thinking++
+	p.mutex.Unlock()
+
+	time.AfterFunc(delay, func() {
+		p.mutex.Lock()
+		p.thinking--
+		p.mutex.Unlock()
+		p.enqueue(controller)
+	})
+}
+
+// thinkDelay 计算本次决策的思考时间
+func (p *AIWorkerPool) thinkDelay() time.Duration {
+	if p.fastMode {
+		return 0
+	}
+	delay := p.thinkTime
+	if p.thinkJitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(p.thinkJitter) + 1))
+	}
+	return delay
+}
+
+// enqueue 将决策放入队列
+func (p *AIWorkerPool) enqueue(controller *AIController) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.stopped {
+		return
+	}
+	p.queue = append(p.queue, &aiTask{controller: controller, enqueuedAt: time.Now()})
+	p.cond.Signal()
+}
+
+// worker 从队列中取出决策并执行
+func (p *AIWorkerPool) worker() {
+	defer p.wg.Done()
+
+	for {
+		p.mutex.Lock()
+		for len(p.queue) == 0 && !p.stopped {
+			p.cond.Wait()
+		}
+		if p.stopped {
+			p.mutex.Unlock()
+			return
+		}
+		task := p.queue[0]
+		p.queue[0] = nil
+		p.queue = p.queue[1:]
+		p.running++
+		p.mutex.Unlock()
+
+		start := time.Now()
+		err := task.controller.run()
+		latency := time.Since(start)
+		if err != nil {
+			aiDecisionSeconds.Observe(latency.Seconds(), "error")
+		} else {
+			aiDecisionSeconds.Observe(latency.Seconds(), "ok")
+		}
+
+		p.mutex.Lock()
+		p.running--
+		p.decisions++
+		if err != nil {
+			p.failures++
+		}
+		p.totalLatency += latency
+		if latency > p.maxLatency {
+			p.maxLatency = latency
+		}
+		p.totalWait += start.Sub(task.enqueuedAt)
+		p.mutex.Unlock()
+	}
+}
+
+// GetStats 获取负载统计
+func (p *AIWorkerPool) GetStats() AIPoolStats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	stats := AIPoolStats{
+		Workers:    p.workers,
+		QueueDepth: len(p.queue),
+		Thinking:   p.thinking,
+		Running:    p.running,
+		Decisions:  p.decisions,
+		Failures:   p.failures,
+		MaxLatency: p.maxLatency,
+	}
+	if p.decisions > 0 {
+		stats.AvgLatency = p.totalLatency / time.Duration(p.decisions)
+		stats.AvgQueueWait = p.totalWait / time.Duration(p.decisions)
+	}
+	return stats
+}
+
+// reportStats 定期输出负载统计
+func (p *AIWorkerPool) reportStats() {
+	ticker := time.NewTicker(p.statsInterval)
+	defer ticker.Stop()
+
+	var lastDecisions int64
+	for {
+		select {
+		case <-ticker.C:
+			stats := p.GetStats()
+			if stats.Decisions == lastDecisions && stats.QueueDepth == 0 && stats.Thinking == 0 {
+				continue
+			}
+			lastDecisions = stats.Decisions
+			logger.Info("AI负载: 排队=%d, 思考中=%d, 执行中=%d/%d, 决策=%d, 失败=%d, 平均耗时=%v, 最大耗时=%v, 平均排队=%v",
+				stats.QueueDepth, stats.Thinking, stats.Running, stats.Workers, stats.Decisions, stats.Failures,
+				stats.AvgLatency, stats.MaxLatency, stats.AvgQueueWait)
+		case <-p.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,524 @@
+package services
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"aigames/internal/config"
+	"aigames/internal/models"
+	"aigames/internal/repository"
+	"aigames/pkg/logger"
+	"aigames/pkg/protocol"
+)
+
+const (
+	// bucketWallets 金币账户存储桶 key: 用户名
+	bucketWallets = "wallets"
+	// bucketCoinLedger 金币流水存储桶 key: 流水号(8字节大端)
+	bucketCoinLedger = "coin_ledger"
+	// bucketCoinLedgerIndex 按用户索引流水 key: 用户名+"\x00"+流水号, value: 空
+	bucketCoinLedgerIndex = "coin_ledger_index"
+	// bucketCoinSettlements 已结算的对局 key: 游戏ID, value: 结算产生的流水号
+	bucketCoinSettlements = "coin_settlements"
+)
+
+// CoinService 金币服务
+// 余额和流水在同一个事务中更新，每笔流水记录付款方、收款方和双方转账后的余额，便于对账
+type CoinService struct {
+	store          repository.Store
+	presence       *PresenceService
+	initialBalance int64 // 新用户的初始金币
+	dailyGrant     int64 // 每日登录赠送的金币
+}
+
+// NewCoinService 创建金币服务实例，用户上线时发放每日登录赠送
+func NewCoinService(store repository.Store, presence *PresenceService, cfg config.EconomyConfig) *CoinService {
+	service := &CoinService{
+		store:          store,
+		presence:       presence,
+		initialBalance: cfg.InitialBalance,
+		dailyGrant:     cfg.DailyGrant,
+	}
+	if service.initialBalance < 0 {
+		service.initialBalance = 0
+	}
+	presence.OnChange(service.grantOnLogin)
+	return service
+}
+
+// Balance 获取用户余额，还没有账户时为初始金币
+func (cs *CoinService) Balance(username string) (int64, error) {
+	balance := cs.initialBalance
+	err := cs.store.View(func(tx repository.Tx) error {
+		wallet, err := readWallet(tx, username)
+		if err != nil {
+			return err
+		}
+		if wallet != nil {
+			balance = wallet.Balance
+		}
+		return nil
+	})
+	return balance, err
+}
+
+// BalanceOf 获取用户余额，读取失败时为0，用于检查入场金币
+func (cs *CoinService) BalanceOf(username string) int64 {
+	balance, err := cs.Balance(username)
+	if err != nil {
+		logger.Error("读取用户 %s 金币失败: %v", username, err)
+		return 0
+	}
+	return balance
+}
+
+// ClaimDailyGrant 领取每日登录赠送，当天已领取时返回0
+func (cs *CoinService) ClaimDailyGrant(username string) (granted, balance int64, err error) {
+	now := time.Now()
+	today := now.Format("2006-01-02")
+
+	err = cs.store.Update(func(tx repository.Tx) error {
+		lt := newLedgerTx(tx, cs, now)
+		wallet, err := lt.wallet(username)
+		if err != nil {
+			return err
+		}
+
+		if cs.dailyGrant > 0 && wallet.LastGrantDay != today {
+			wallet.LastGrantDay = today
+			err := lt.transfer(models.LedgerEntry{
+				Type:   models.LedgerTypeDailyGrant,
+				To:     username,
+				Amount: cs.dailyGrant,
+			})
+			if err != nil {
+				return err
+			}
+			granted = cs.dailyGrant
+		}
+		balance = wallet.Balance
+		return lt.commit()
+	})
+	return granted, balance, err
+}
+
+// grantOnLogin 用户上线时发放每日登录赠送并推送
+func (cs *CoinService) grantOnLogin(username string, online bool) {
+	if !online || models.IsAIName(username) {
+		return
+	}
+
+	granted, balance, err := cs.ClaimDailyGrant(username)
+	if err != nil {
+		logger.Error("发放用户 %s 每日金币失败: %v", username, err)
+		return
+	}
+	if granted > 0 {
+		logger.Info("用户 %s 领取每日金币 %d，余额 %d", username, granted, balance)
+		cs.presence.Push(username, protocol.RouteCoinsGranted, protocol.CoinGrantData{
+			Type:     models.LedgerTypeDailyGrant,
+			TypeName: models.LedgerTypeNames[models.LedgerTypeDailyGrant],
+			Amount:   granted,
+			Balance:  balance,
+		})
+	}
+}
+
+// Settle 按得分乘以底注和游戏倍数结算一局，所有转账在一个事务中完成，同一局只结算一次
+// 输家最多付出全部余额，付出的金币按赢家得分比例分配；AI玩家的输赢记在庄家账户上，
+// AI输家合计最多付出庄家的全部余额，每次结算所有账户的金币总数不变
+func (cs *CoinService) Settle(room *models.Room, game *models.Game) ([]models.CoinChange, error) {
+	if room.BaseStake <= 0 || game == nil || game.Status != models.GameStatusFinished {
+		return nil, nil
+	}
+
+	var changes []models.CoinChange
+	err := cs.store.Update(func(tx repository.Tx) error {
+		settlements, err := tx.Bucket(bucketCoinSettlements)
+		if err != nil {
+			return err
+		}
+		if settlements.Get([]byte(game.ID)) != nil {
+			return nil // 已结算
+		}
+
+		lt := newLedgerTx(tx, cs, time.Now())
+		var deltas [3]int64
+
+		house, err := lt.wallet(models.HouseAccount)
+		if err != nil {
+			return err
+		}
+		houseAvailable := max(house.Balance, 0)
+		stake := room.BaseStake * int64(max(game.Multiplier, 1))
+
+		// 输家实际付出的金币
+		var pays [3]int64
+		var winnerScore int64
+		for i, player := range game.Players {
+			if player == nil {
+				continue
+			}
+			if player.Score > 0 {
+				winnerScore += int64(player.Score)
+				continue
+			}
+			pays[i] = -int64(player.Score) * stake
+			if player.IsAI {
+				pays[i] = min(pays[i], houseAvailable)
+				houseAvailable -= pays[i]
+				continue
+			}
+			wallet, err := lt.wallet(player.UserName)
+			if err != nil {
+				return err
+			}
+			if pays[i] > wallet.Balance {
+				pays[i] = max(wallet.Balance, 0)
+			}
+		}
+		if winnerScore == 0 {
+			return nil
+		}
+
+		for i, loser := range game.Players {
+			if loser == nil || pays[i] <= 0 {
+				continue
+			}
+
+			// 按赢家得分比例分配，余数归最后一名赢家
+			remaining := pays[i]
+			paid := int64(0)
+			for j, winner := range game.Players {
+				if winner == nil || winner.Score <= 0 {
+					continue
+				}
+				amount := pays[i] * int64(winner.Score) / winnerScore
+				paid += int64(winner.Score)
+				if paid == winnerScore {
+					amount = remaining
+				}
+				remaining -= amount
+				// AI之间的输赢都在庄家账户内，不需要转账
+				if amount == 0 || loser.IsAI && winner.IsAI {
+					continue
+				}
+
+				err := lt.transfer(models.LedgerEntry{
+					Type:   models.LedgerTypeSettlement,
+					From:   loser.UserName,
+					To:     winner.UserName,
+					Amount: amount,
+					RoomID: room.ID,
+					GameID: game.ID,
+					Note:   fmt.Sprintf("底注 %d × %d 倍", room.BaseStake, game.Multiplier),
+				})
+				if err != nil {
+					return err
+				}
+				deltas[i] -= amount
+				deltas[j] += amount
+			}
+		}
+
+		ids := make([]uint64, len(lt.entries))
+		for i, entry := range lt.entries {
+			ids[i] = entry.ID
+		}
+		encoded, err := json.Marshal(ids)
+		if err != nil {
+			return err
+		}
+		if err := settlements.Put([]byte(game.ID), encoded); err != nil {
+			return err
+		}
+
+		for i, player := range game.Players {
+			if player == nil {
+				continue
+			}
+			change := models.CoinChange{UserName: player.UserName, Delta: deltas[i]}
+			if wallet := lt.wallets[player.UserName]; wallet != nil {
+				change.Balance = wallet.Balance
+			}
+			changes = append(changes, change)
+		}
+		return lt.commit()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changes) > 0 {
+		logger.Info("游戏 %s 金币结算完成: 底注=%d, 倍数=%d", game.ID, room.BaseStake, game.Multiplier)
+		data := protocol.NewCoinSettlementData(room, game, changes)
+		for _, change := range changes {
+			if !models.IsAIName(change.UserName) {
+				cs.presence.Push(change.UserName, protocol.RouteCoinsSettled, data)
+			}
+		}
+	}
+	return changes, nil
+}
+
+// Ledger 获取用户的金币流水，按时间倒序分页，返回当前页和总数
+func (cs *CoinService) Ledger(username string, offset, limit int) ([]models.LedgerEntry, int, error) {
+	entries := make([]models.LedgerEntry, 0, limit)
+	total := 0
+	err := cs.store.View(func(tx repository.Tx) error {
+		index, err := tx.Bucket(bucketCoinLedgerIndex)
+		if err != nil {
+			return err
+		}
+		ledger, err := tx.Bucket(bucketCoinLedger)
+		if err != nil {
+			return err
+		}
+
+		prefix := []byte(username + "\x00")
+		var keys [][]byte
+		err = index.Scan(prefix, func(k, _ []byte) error {
+			keys = append(keys, k[len(prefix):])
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		total = len(keys)
+
+		for i := total - 1 - offset; i >= 0 && len(entries) < limit; i-- {
+			data := ledger.Get(keys[i])
+			if data == nil {
+				continue
+			}
+			var entry models.LedgerEntry
+			if err := json.Unmarshal(data, &entry); err != nil {
+				return fmt.Errorf("解析金币流水失败: %w", err)
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, total, err
+}
+
+// readWallet 读取金币账户，不存在时返回nil
+func readWallet(tx repository.Tx, username string) (*models.Wallet, error) {
+	b, err := tx.Bucket(bucketWallets)
+	if err != nil {
+		return nil, err
+	}
+	data := b.Get([]byte(username))
+	if data == nil {
+		return nil, nil
+	}
+
+	var wallet models.Wallet
+	if err := json.Unmarshal(data, &wallet); err != nil {
+		return nil, fmt.Errorf("解析金币账户失败: %w", err)
+	}
+	return &wallet, nil
+}
+
+// ledgerTx 一个事务中的账户和流水操作，账户在commit时统一保存
+type ledgerTx struct {
+	tx      repository.Tx
+	cs      *CoinService
+	now     time.Time
+	wallets map[string]*models.Wallet
+	entries []models.LedgerEntry
+}
+
+// newLedgerTx 创建事务中的账户操作
+func newLedgerTx(tx repository.Tx, cs *CoinService, now time.Time) *ledgerTx {
+	return &ledgerTx{
+		tx:      tx,
+		cs:      cs,
+		now:     now,
+		wallets: make(map[string]*models.Wallet),
+	}
+}
+
+// wallet 获取用户账户，不存在时开户并发放初始金币
+func (lt *ledgerTx) wallet(username string) (*models.Wallet, error) {
+	if wallet, ok := lt.wallets[username]; ok {
+		return wallet, nil
+	}
+
+	wallet, err := readWallet(lt.tx, username)
+	if err != nil {
+		return nil, err
+	}
+	if wallet != nil {
+		lt.wallets[username] = wallet
+		return wallet, nil
+	}
+
+	wallet = &models.Wallet{CreatedAt: lt.now, UpdatedAt: lt.now}
+	lt.wallets[username] = wallet
+	// 庄家账户只能从AI赢得的金币中支付
+	if lt.cs.initialBalance > 0 && username != models.HouseAccount {
+		err := lt.transfer(models.LedgerEntry{
+			Type:   models.LedgerTypeInitial,
+			To:     username,
+			Amount: lt.cs.initialBalance,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return wallet, nil
+}
+
+// accountOf 流水一方的金币账户，AI玩家使用庄家账户，系统没有账户时为空
+func accountOf(name string) string {
+	switch {
+	case name == "":
+		return ""
+	case models.IsAIName(name):
+		return models.HouseAccount
+	default:
+		return name
+	}
+}
+
+// transfer 记录一笔流水并更新双方余额
+func (lt *ledgerTx) transfer(entry models.LedgerEntry) error {
+	if account := accountOf(entry.From); account != "" {
+		wallet, err := lt.wallet(account)
+		if err != nil {
+			return err
+		}
+		wallet.Balance -= entry.Amount
+		wallet.UpdatedAt = lt.now
+		if account == entry.From {
+			entry.FromBalance = wallet.Balance
+		}
+	}
+	if account := accountOf(entry.To); account != "" {
+		wallet, err := lt.wallet(account)
+		if err != nil {
+			return err
+		}
+		wallet.Balance += entry.Amount
+		wallet.UpdatedAt = lt.now
+		if account == entry.To {
+			entry.ToBalance = wallet.Balance
+		}
+	}
+
+	ledger, err := lt.tx.Bucket(bucketCoinLedger)
+	if err != nil {
+		return err
+	}
+	index, err := lt.tx.Bucket(bucketCoinLedgerIndex)
+	if err != nil {
+		return err
+	}
+
+	entry.ID, err = ledger.NextSequence()
+	if err != nil {
+		return err
+	}
+	entry.CreatedAt = lt.now
+
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, entry.ID)
+	encoded, err := json.Marshal(&entry)
+	if err != nil {
+		return fmt.Errorf("序列化金币流水失败: %w", err)
+	}
+	if err := ledger.Put(key, encoded); err != nil {
+		return err
+	}
+	for _, name := range []string{entry.From, entry.To} {
+		if account := accountOf(name); account != "" {
+			if err := index.Put(append([]byte(account+"\x00"), key...), nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	lt.entries = append(lt.entries, entry)
+	return nil
+}
+
+// commit 保存事务中修改过的账户
+func (lt *ledgerTx) commit() error {
+	b, err := lt.tx.Bucket(bucketWallets)
+	if err != nil {
+		return err
+	}
+	for username, wallet := range lt.wallets {
+		encoded, err := json.Marshal(wallet)
+		if err != nil {
+			return fmt.Errorf("序列化金币账户失败: %w", err)
+		}
+		if err := b.Put([]byte(username), encoded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteAccount 注销用户时删除其金币账户，流水中的用户名替换为匿名名称，作为 UserService.OnDelete 的回调
+func (cs *CoinService) DeleteAccount(tx repository.Tx, name, alias string) error {
+	wallets, err := tx.Bucket(bucketWallets)
+	if err != nil {
+		return err
+	}
+	if err := wallets.Delete([]byte(name)); err != nil {
+		return err
+	}
+
+	index, err := tx.Bucket(bucketCoinLedgerIndex)
+	if err != nil {
+		return err
+	}
+	ledger, err := tx.Bucket(bucketCoinLedger)
+	if err != nil {
+		return err
+	}
+
+	prefix := []byte(name + "\x00")
+	var keys [][]byte
+	err = index.Scan(prefix, func(k, _ []byte) error {
+		keys = append(keys, append([]byte(nil), k...))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		if err := index.Delete(k); err != nil {
+			return err
+		}
+
+		key := k[len(prefix):]
+		data := ledger.Get(key)
+		if data == nil {
+			continue
+		}
+		var entry models.LedgerEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			continue // 无法识别的记录保持原样
+		}
+		if entry.From == name {
+			entry.From = alias
+		}
+		if entry.To == name {
+			entry.To = alias
+		}
+		encoded, err := json.Marshal(&entry)
+		if err != nil {
+			return err
+		}
+		if err := ledger.Put(key, encoded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
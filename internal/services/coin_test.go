@@ -0,0 +1,111 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"aigames/internal/config"
+	"aigames/internal/models"
+)
+
+// finishedGame 创建已结束的一局，scores 按座位给出得分
+func finishedGame(id string, players [3]string, scores [3]int) *models.Game {
+	now := time.Now()
+	game := &models.Game{ID: id, Status: models.GameStatusFinished, Multiplier: 1, FinishedAt: &now}
+	for i, name := range players {
+		game.Players[i] = &models.GamePlayer{
+			UserName: name,
+			Position: models.PlayerPosition(i + 1),
+			IsAI:     models.IsAIName(name),
+			Score:    scores[i],
+		}
+	}
+	return game
+}
+
+// totalCoins 用户和庄家账户的金币总数
+func totalCoins(t *testing.T, cs *CoinService, names ...string) int64 {
+	t.Helper()
+	var total int64
+	for _, name := range append(names, models.HouseAccount) {
+		balance, err := cs.Balance(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += balance
+	}
+	return total
+}
+
+func TestSettleAILosersPaidByHouse(t *testing.T) {
+	cs := newTestEnv(t).coins
+	room := &models.Room{ID: "room", BaseStake: 100000}
+	players := [3]string{"alice", "AI-A", "AI-B"}
+
+	// 庄家没有余额时，AI输家不付出金币
+	changes, err := cs.Settle(room, finishedGame("g1", players, [3]int{2, -1, -1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, change := range changes {
+		if change.Delta != 0 {
+			t.Fatalf("庄家没有余额时 %s 的金币变化为 %d", change.UserName, change.Delta)
+		}
+	}
+	if balance, _ := cs.Balance("alice"); balance != 1000 {
+		t.Fatalf("alice 余额为 %d，应为1000", balance)
+	}
+
+	// 真人输给AI的金币进入庄家账户
+	if _, err := cs.Settle(room, finishedGame("g2", players, [3]int{-2, 1, 1})); err != nil {
+		t.Fatal(err)
+	}
+	if balance, _ := cs.Balance(models.HouseAccount); balance != 1000 {
+		t.Fatalf("庄家余额为 %d，应为1000", balance)
+	}
+
+	// 之后AI输掉的金币最多为庄家的余额
+	if _, err := cs.Settle(room, finishedGame("g3", players, [3]int{2, -1, -1})); err != nil {
+		t.Fatal(err)
+	}
+	if balance, _ := cs.Balance("alice"); balance != 1000 {
+		t.Fatalf("alice 余额为 %d，应为1000", balance)
+	}
+	if total := totalCoins(t, cs, "alice"); total != 1000 {
+		t.Fatalf("金币总数为 %d，应为1000", total)
+	}
+}
+
+func TestSettleConservesCoins(t *testing.T) {
+	cs := newTestEnv(t, func(cfg *config.Config) {
+		cfg.Economy.InitialBalance = 500
+	}).coins
+	room := &models.Room{ID: "room", BaseStake: 300}
+	players := [3]string{"alice", "bob", "AI-A"}
+	games := [][3]int{{2, -1, -1}, {-2, 1, 1}, {-1, 2, -1}, {4, -2, -2}, {-4, 2, 2}}
+
+	for i, scores := range games {
+		if _, err := cs.Settle(room, finishedGame(string(rune('a'+i)), players, scores)); err != nil {
+			t.Fatal(err)
+		}
+		if total := totalCoins(t, cs, "alice", "bob"); total != 1000 {
+			t.Fatalf("第 %d 局后金币总数为 %d，应为1000", i+1, total)
+		}
+	}
+}
+
+func TestSettleMultipliesStake(t *testing.T) {
+	cs := newTestEnv(t).coins
+	room := &models.Room{ID: "room", BaseStake: 10}
+	game := finishedGame("g1", [3]string{"alice", "bob", "carol"}, [3]int{2, -1, -1})
+	game.Multiplier = 4
+
+	if _, err := cs.Settle(room, game); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]int64{"alice": 1080, "bob": 960, "carol": 960} {
+		if balance, _ := cs.Balance(name); balance != want {
+			t.Fatalf("%s 余额为 %d，应为 %d", name, balance, want)
+		}
+	}
+}
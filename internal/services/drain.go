@@ -0,0 +1,125 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"aigames/internal/config"
+	"aigames/internal/models"
+	"aigames/pkg/logger"
+	"aigames/pkg/protocol"
+)
+
+// drainCheckInterval 排空期间检查进行中游戏的间隔
+const drainCheckInterval = time.Second
+
+// DrainService 停机排空
+// 排空时拒绝创建房间和开始新的游戏，通知在线玩家即将维护，等待进行中的游戏在宽限时间内结束，
+// 之后停止AI控制器并把房间状态写入数据库。未结束的游戏保留在房间中，重启后恢复
+type DrainService struct {
+	roomService *RoomService
+	gameService *GameService
+	presence    *PresenceService
+
+	timeout time.Duration // 等待进行中的游戏结束的宽限时间
+
+	mutex    sync.Mutex
+	draining bool
+	hooks    []func() // 进入排空状态时的回调
+}
+
+// NewDrainService 根据服务器配置创建停机排空服务
+func NewDrainService(roomService *RoomService, gameService *GameService, presence *PresenceService, cfg config.ServerConfig) *DrainService {
+	timeout := time.Duration(cfg.DrainTimeout) * time.Second
+	if timeout < 0 {
+		timeout = 0
+	}
+	return &DrainService{
+		roomService: roomService,
+		gameService: gameService,
+		presence:    presence,
+		timeout:     timeout,
+	}
+}
+
+// OnDrain 注册进入排空状态时的回调，如就绪检查不再通过，只在启动时注册
+func (ds *DrainService) OnDrain(fn func()) {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	ds.hooks = append(ds.hooks, fn)
+}
+
+// Draining 是否处于排空状态
+func (ds *DrainService) Draining() bool {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	return ds.draining
+}
+
+// Drain 进入排空状态并等待进行中的游戏结束
+// 所有游戏结束、宽限时间已到或 force 关闭时返回，返回仍在进行中的游戏数
+func (ds *DrainService) Drain(force <-chan struct{}) int {
+	ds.mutex.Lock()
+	if ds.draining {
+		ds.mutex.Unlock()
+		return ds.runningGames()
+	}
+	ds.draining = true
+	hooks := ds.hooks
+	ds.mutex.Unlock()
+
+	ds.roomService.SetDraining(true)
+	for _, fn := range hooks {
+		fn()
+	}
+
+	deadline := time.Now().Add(ds.timeout)
+	notified := ds.presence.Broadcast(protocol.RouteMaintenance, protocol.MaintenanceData{
+		Message:    "服务器即将停机维护，暂停创建房间和开始新的游戏，进行中的游戏请尽快结束",
+		ShutdownAt: deadline,
+	})
+	logger.Info("开始停机排空: 宽限时间=%v, 已通知 %d 个在线用户", ds.timeout, notified)
+
+	timer := time.NewTimer(ds.timeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(drainCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		running := ds.runningGames()
+		if running == 0 {
+			logger.Info("进行中的游戏已全部结束")
+			return 0
+		}
+
+		select {
+		case <-ticker.C:
+		case <-timer.C:
+			running = ds.runningGames()
+			logger.Warn("停机宽限时间已到，仍有 %d 局游戏未结束，重启后恢复", running)
+			return running
+		case <-force:
+			running = ds.runningGames()
+			logger.Warn("立即停机，仍有 %d 局游戏未结束，重启后恢复", running)
+			return running
+		}
+	}
+}
+
+// Finish 停止所有AI控制器并把房间写入数据库，在nano停止后、关闭数据库前调用
+func (ds *DrainService) Finish() {
+	ds.gameService.StopAllAIControllers()
+
+	saved, err := ds.roomService.Flush()
+	if err != nil {
+		logger.Error("停机保存房间失败: %v", err)
+		return
+	}
+	logger.Info("停机前已保存 %d 个房间", saved)
+}
+
+// runningGames 已发牌且尚未结束的游戏数
+func (ds *DrainService) runningGames() int {
+	counts := ds.gameService.CountByStatus()
+	return counts[models.GameStatusDealing] + counts[models.GameStatusCalling] + counts[models.GameStatusPlaying]
+}
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"aigames/internal/models"
+	"aigames/internal/repository"
+	"aigames/pkg/logger"
+	"aigames/pkg/protocol"
+)
+
+// bucketFriends 好友关系存储桶 key: 所有者\x00对方, value: 好友关系
+const bucketFriends = "friends"
+
+// friendKeySeparator 好友关系key中分隔双方用户名，用户名中不会出现
+const friendKeySeparator = "\x00"
+
+// FriendService 好友服务
+type FriendService struct {
+	store    repository.Store
+	users    repository.UserRepository
+	presence *PresenceService
+}
+
+// FriendEntry 好友列表项
+type FriendEntry struct {
+	models.Friendship
+	Online bool // 是否在线，只对好友有效
+}
+
+// NewFriendService 创建好友服务实例，好友上线、下线时通知其在线好友
+func NewFriendService(store repository.Store, presence *PresenceService) *FriendService {
+	service := &FriendService{store: store, users: store.Users(), presence: presence}
+	presence.OnChange(service.notifyPresence)
+	return service
+}
+
+// friendKey 生成好友关系key
+func friendKey(owner, friend string) []byte {
+	return []byte(owner + friendKeySeparator + friend)
+}
+
+// getFriendship 读取好友关系，不存在时返回nil
+func getFriendship(b repository.Bucket, owner, friend string) (*models.Friendship, error) {
+	data := b.Get(friendKey(owner, friend))
+	if data == nil {
+		return nil, nil
+	}
+	var friendship models.Friendship
+	if err := json.Unmarshal(data, &friendship); err != nil {
+		return nil, err
+	}
+	return &friendship, nil
+}
+
+// putFriendship 保存好友关系，保留原创建时间
+func putFriendship(b repository.Bucket, owner, friend string, status models.FriendStatus, now time.Time) error {
+	friendship := models.Friendship{
+		Owner:     owner,
+		Friend:    friend,
+		Status:    status,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if existing, err := getFriendship(b, owner, friend); err == nil && existing != nil {
+		friendship.CreatedAt = existing.CreatedAt
+	}
+
+	encoded, err := json.Marshal(&friendship)
+	if err != nil {
+		return err
+	}
+	return b.Put(friendKey(owner, friend), encoded)
+}
+
+// update 在同一个事务中修改好友关系
+func (fs *FriendService) update(fn func(friends repository.Bucket) error) error {
+	return fs.store.Update(func(tx repository.Tx) error {
+		friends, err := tx.Bucket(bucketFriends)
+		if err != nil {
+			return err
+		}
+		return fn(friends)
+	})
+}
+
+// SendRequest 发送好友请求，对方已向自己发出请求时直接成为好友
+func (fs *FriendService) SendRequest(from, to string) (models.FriendStatus, error) {
+	if from == to {
+		return 0, fmt.Errorf("不能添加自己为好友")
+	}
+
+	if _, err := fs.users.Get(to); err != nil {
+		return 0, err
+	}
+
+	var status models.FriendStatus
+	now := time.Now()
+	err := fs.update(func(friends repository.Bucket) error {
+		mine, err := getFriendship(friends, from, to)
+		if err != nil {
+			return err
+		}
+		theirs, err := getFriendship(friends, to, from)
+		if err != nil {
+			return err
+		}
+
+		if mine != nil {
+			switch mine.Status {
+			case models.FriendStatusAccepted:
+				return fmt.Errorf("已经是好友")
+			case models.FriendStatusRequested:
+				return fmt.Errorf("已发送过好友请求")
+			case models.FriendStatusBlocked:
+				return fmt.Errorf("请先取消屏蔽该用户")
+			}
+		}
+		if theirs != nil && theirs.Status == models.FriendStatusBlocked {
+			return fmt.Errorf("无法添加该用户为好友")
+		}
+
+		// 对方已经发来请求，直接成为好友
+		if mine != nil && mine.Status == models.FriendStatusIncoming {
+			status = models.FriendStatusAccepted
+		} else {
+			status = models.FriendStatusRequested
+		}
+
+		theirStatus := models.FriendStatusIncoming
+		if status == models.FriendStatusAccepted {
+			theirStatus = models.FriendStatusAccepted
+		}
+		if err := putFriendship(friends, from, to, status, now); err != nil {
+			return err
+		}
+		return putFriendship(friends, to, from, theirStatus, now)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if status == models.FriendStatusAccepted {
+		fs.presence.Push(to, protocol.RouteFriendAccepted, protocol.FriendNameData{Name: from})
+	} else {
+		fs.presence.Push(to, protocol.RouteFriendRequest, protocol.FriendNameData{Name: from})
+	}
+	return status, nil
+}
+
+// Accept 接受好友请求
+func (fs *FriendService) Accept(username, from string) error {
+	now := time.Now()
+	err := fs.update(func(friends repository.Bucket) error {
+		mine, err := getFriendship(friends, username, from)
+		if err != nil {
+			return err
+		}
+		if mine == nil || mine.Status != models.FriendStatusIncoming {
+			return fmt.Errorf("没有该好友请求")
+		}
+
+		if err := putFriendship(friends, username, from, models.FriendStatusAccepted, now); err != nil {
+			return err
+		}
+		return putFriendship(friends, from, username, models.FriendStatusAccepted, now)
+	})
+	if err != nil {
+		return err
+	}
+
+	fs.presence.Push(from, protocol.RouteFriendAccepted, protocol.FriendNameData{Name: username})
+	return nil
+}
+
+// Remove 删除好友，也用于拒绝或撤回好友请求；对方的屏蔽记录保持不变
+func (fs *FriendService) Remove(username, other string) error {
+	return fs.update(func(friends repository.Bucket) error {
+		mine, err := getFriendship(friends, username, other)
+		if err != nil {
+			return err
+		}
+		if mine == nil || mine.Status == models.FriendStatusBlocked {
+			return fmt.Errorf("好友关系不存在")
+		}
+		if err := friends.Delete(friendKey(username, other)); err != nil {
+			return err
+		}
+
+		theirs, err := getFriendship(friends, other, username)
+		if err != nil {
+			return err
+		}
+		if theirs != nil && theirs.Status != models.FriendStatusBlocked {
+			return friends.Delete(friendKey(other, username))
+		}
+		return nil
+	})
+}
+
+// Block 屏蔽用户，同时解除双方的好友关系和好友请求
+func (fs *FriendService) Block(username, target string) error {
+	if username == target {
+		return fmt.Errorf("不能屏蔽自己")
+	}
+
+	if _, err := fs.users.Get(target); err != nil {
+		return err
+	}
+
+	return fs.update(func(friends repository.Bucket) error {
+		if err := putFriendship(friends, username, target, models.FriendStatusBlocked, time.Now()); err != nil {
+			return err
+		}
+
+		theirs, err := getFriendship(friends, target, username)
+		if err != nil {
+			return err
+		}
+		if theirs != nil && theirs.Status != models.FriendStatusBlocked {
+			return friends.Delete(friendKey(target, username))
+		}
+		return nil
+	})
+}
+
+// Unblock 取消屏蔽
+func (fs *FriendService) Unblock(username, target string) error {
+	return fs.update(func(friends repository.Bucket) error {
+		mine, err := getFriendship(friends, username, target)
+		if err != nil {
+			return err
+		}
+		if mine == nil || mine.Status != models.FriendStatusBlocked {
+			return fmt.Errorf("没有屏蔽该用户")
+		}
+		return friends.Delete(friendKey(username, target))
+	})
+}
+
+// List 获取用户的好友、好友请求和屏蔽列表
+func (fs *FriendService) List(username string) ([]FriendEntry, error) {
+	entries := make([]FriendEntry, 0)
+	prefix := friendKey(username, "")
+	err := fs.store.View(func(tx repository.Tx) error {
+		b, err := tx.Bucket(bucketFriends)
+		if err != nil {
+			return err
+		}
+
+		return b.Scan(prefix, func(k, v []byte) error {
+			var friendship models.Friendship
+			if err := json.Unmarshal(v, &friendship); err == nil {
+				entries = append(entries, FriendEntry{Friendship: friendship})
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		if entries[i].Status == models.FriendStatusAccepted {
+			entries[i].Online = fs.presence.IsOnline(entries[i].Friend)
+		}
+	}
+	return entries, nil
+}
+
+// AreFriends 判断两个用户是否为好友
+func (fs *FriendService) AreFriends(a, b string) bool {
+	friends := false
+	fs.store.View(func(tx repository.Tx) error {
+		bucket, err := tx.Bucket(bucketFriends)
+		if err != nil {
+			return err
+		}
+		friendship, err := getFriendship(bucket, a, b)
+		friends = err == nil && friendship != nil && friendship.Status == models.FriendStatusAccepted
+		return nil
+	})
+	return friends
+}
+
+// RemoveUser 在注销账户的事务中删除用户的所有好友关系
+func (fs *FriendService) RemoveUser(tx repository.Tx, username, _ string) error {
+	friends, err := tx.Bucket(bucketFriends)
+	if err != nil {
+		return err
+	}
+
+	var keys [][]byte
+	err = friends.Scan(nil, func(k, _ []byte) error {
+		parts := strings.SplitN(string(k), friendKeySeparator, 2)
+		if len(parts) == 2 && (parts[0] == username || parts[1] == username) {
+			keys = append(keys, append([]byte(nil), k...))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if err := friends.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notifyPresence 通知在线好友用户上线或下线
+func (fs *FriendService) notifyPresence(username string, online bool) {
+	entries, err := fs.List(username)
+	if err != nil {
+		logger.Error("获取用户 %s 的好友列表失败: %v", username, err)
+		return
+	}
+
+	data := protocol.FriendPresenceData{Name: username, Online: online}
+	for _, entry := range entries {
+		if entry.Status == models.FriendStatusAccepted && entry.Online {
+			fs.presence.Push(entry.Friend, protocol.RouteFriendPresence, data)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"aigames/internal/models"
+)
+
+func TestFriendRequestChecksUserRepository(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUsers(t, "alice", "bob")
+	fs := env.friends
+
+	status, err := fs.SendRequest("alice", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != models.FriendStatusRequested {
+		t.Fatalf("好友请求状态为 %v", status)
+	}
+	if err := fs.Accept("bob", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if !fs.AreFriends("alice", "bob") {
+		t.Fatal("接受请求后不是好友")
+	}
+
+	if _, err := fs.SendRequest("alice", "carol"); err == nil || err.Error() != "用户不存在" {
+		t.Fatalf("向不存在的用户发送请求返回 %v", err)
+	}
+	if err := fs.Block("alice", "carol"); err == nil || err.Error() != "用户不存在" {
+		t.Fatalf("屏蔽不存在的用户返回 %v", err)
+	}
+}
+
+func TestInvitationRejectedAfterBlock(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUsers(t, "alice", "bob")
+	fs := env.friends
+	env.makeFriends(t, "alice", "bob")
+	rs := env.rooms
+	room, err := rs.CreateRoom("test", "alice", models.RoomTypePrivate, "secret", 0, 0, models.NoStake, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invitation, err := rs.CreateInvitation(room.ID, "alice", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fs.Block("bob", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rs.JoinRoomByInvitation(room.ID, invitation.ID, "bob"); !errors.Is(err, ErrInvitationWithdrawn) {
+		t.Fatalf("屏蔽邀请人后凭邀请加入返回 %v", err)
+	}
+	if _, err := rs.JoinRoomByInvitation(room.ID, invitation.ID, "bob"); !errors.Is(err, ErrInvitationNotFound) {
+		t.Fatalf("失效的邀请再次使用返回 %v", err)
+	}
+}
+
+func TestInvitationJoinsPrivateRoomOnce(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUsers(t, "alice", "bob", "carol")
+	env.makeFriends(t, "alice", "bob")
+	rs := env.rooms
+	room, err := rs.CreateRoom("test", "alice", models.RoomTypePrivate, "secret", 0, 0, models.NoStake, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := rs.CreateInvitation(room.ID, "carol", "bob"); !errors.Is(err, ErrPlayerNotInRoom) {
+		t.Fatalf("房间外的玩家发出邀请返回 %v", err)
+	}
+	invitation, err := rs.CreateInvitation(room.ID, "alice", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 邀请只能由被邀请人在对应的房间使用
+	if _, err := rs.JoinRoomByInvitation(room.ID, invitation.ID, "carol"); !errors.Is(err, ErrInvitationNotFound) {
+		t.Fatalf("其他人使用邀请返回 %v", err)
+	}
+	if _, err := rs.JoinRoomByInvitation("other", invitation.ID, "bob"); !errors.Is(err, ErrInvitationNotFound) {
+		t.Fatalf("在其他房间使用邀请返回 %v", err)
+	}
+
+	// 凭邀请加入私人房间无需密码，邀请使用后失效
+	joined, err := rs.JoinRoomByInvitation(room.ID, invitation.ID, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !joined.HasPlayer("bob") {
+		t.Fatal("凭邀请加入后不在房间中")
+	}
+	if _, err := rs.JoinRoomByInvitation(room.ID, invitation.ID, "bob"); !errors.Is(err, ErrInvitationNotFound) {
+		t.Fatalf("再次使用邀请返回 %v", err)
+	}
+}
+
+func TestInvitationRejectedAfterUnfriendOrExpiry(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUsers(t, "alice", "bob")
+	env.makeFriends(t, "alice", "bob")
+	rs := env.rooms
+	room, err := rs.CreateRoom("test", "alice", models.RoomTypePrivate, "secret", 0, 0, models.NoStake, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 过期的邀请被删除
+	expired, err := rs.CreateInvitation(room.ID, "alice", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs.inviteMutex.Lock()
+	rs.invitations[expired.ID].ExpiresAt = time.Now().Add(-time.Second)
+	rs.inviteMutex.Unlock()
+	if _, err := rs.JoinRoomByInvitation(room.ID, expired.ID, "bob"); !errors.Is(err, ErrInvitationExpired) {
+		t.Fatalf("使用过期的邀请返回 %v", err)
+	}
+	if _, err := rs.JoinRoomByInvitation(room.ID, expired.ID, "bob"); !errors.Is(err, ErrInvitationNotFound) {
+		t.Fatalf("过期的邀请再次使用返回 %v", err)
+	}
+
+	// 邀请发出后删除好友，邀请随之失效
+	invitation, err := rs.CreateInvitation(room.ID, "alice", "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := env.friends.Remove("alice", "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rs.JoinRoomByInvitation(room.ID, invitation.ID, "bob"); !errors.Is(err, ErrInvitationWithdrawn) {
+		t.Fatalf("删除好友后凭邀请加入返回 %v", err)
+	}
+	got, err := rs.GetRoom(room.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.HasPlayer("bob") {
+		t.Fatal("失效的邀请加入了房间")
+	}
+}
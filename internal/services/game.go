@@ -6,44 +6,39 @@ import (
 	"time"
 
 	"aigames/internal/models"
+	"aigames/internal/repository"
 	"aigames/pkg/logger"
-
-	"go.etcd.io/bbolt"
 )
 
 // GameService 游戏服务
+// 游戏状态由房间actor持有，所有操作都通过RoomService.Dispatch发送到房间actor中执行
 type GameService struct {
-	db            *bbolt.DB
+	games         repository.GameRepository
 	roomService   *RoomService
-	games         map[string]*models.Game       // 内存中的游戏缓存
-	aiControllers map[string]*AIController      // AI控制器映射 key: playerName, value: controller
-	mutex         sync.RWMutex                  // 读写锁
+	aiControllers map[string]map[models.PlayerPosition]*AIController // AI控制器映射 key: roomID, value: 座位 -> controller
+	aiPool        *AIWorkerPool                                      // AI决策工作池
+	aiMutex       sync.Mutex                                         // 保护AI控制器映射
+
+	finishHooks []func(room *models.Room, game *models.Game) // 一局正常结束后的回调，如金币结算
 }
 
 // NewGameService 创建游戏服务实例
-func NewGameService(db *bbolt.DB, roomService *RoomService) *GameService {
+func NewGameService(store repository.Store, roomService *RoomService, aiPool *AIWorkerPool) *GameService {
 	return &GameService{
-		db:            db,
+		games:         store.Games(),
 		roomService:   roomService,
-		games:         make(map[string]*models.Game),
-		aiControllers: make(map[string]*AIController),
+		aiControllers: make(map[string]map[models.PlayerPosition]*AIController),
+		aiPool:        aiPool,
 	}
 }
 
-// GetGame 获取游戏
-func (gs *GameService) GetGame(gameID string) (*models.Game, error) {
-	gs.mutex.RLock()
-	defer gs.mutex.RUnlock()
-
-	game, exists := gs.games[gameID]
-	if !exists {
-		return nil, fmt.Errorf("游戏不存在")
-	}
-
-	return game, nil
+// OnGameFinished 注册一局正常结束（有人出完牌）后的回调，只在启动时注册
+// 回调在房间actor外按注册顺序同步执行，参数为结束时的房间和游戏快照
+func (gs *GameService) OnGameFinished(fn func(room *models.Room, game *models.Game)) {
+	gs.finishHooks = append(gs.finishHooks, fn)
 }
 
-// GetGameByRoom 通过房间ID获取游戏
+// GetGameByRoom 通过房间ID获取游戏快照
 func (gs *GameService) GetGameByRoom(roomID string) (*models.Game, error) {
 	room, err := gs.roomService.GetRoom(roomID)
 	if err != nil {
@@ -54,223 +49,263 @@ func (gs *GameService) GetGameByRoom(roomID string) (*models.Game, error) {
 		return nil, fmt.Errorf("房间没有活跃的游戏")
 	}
 
-	// 将游戏添加到缓存中
-	gs.mutex.Lock()
-	gs.games[room.CurrentGame.ID] = room.CurrentGame
-	gs.mutex.Unlock()
-
 	return room.CurrentGame, nil
 }
 
-// CallLandlord 叫地主
-func (gs *GameService) CallLandlord(roomID, username string, call bool) error {
-	game, err := gs.GetGameByRoom(roomID)
-	if err != nil {
-		return err
+// CountByStatus 按游戏状态统计各房间当前的游戏数
+func (gs *GameService) CountByStatus() map[models.GameStatus]int {
+	counts := make(map[models.GameStatus]int)
+	for _, room := range gs.roomService.Snapshots() {
+		if room.CurrentGame != nil {
+			counts[room.CurrentGame.Status]++
+		}
 	}
+	return counts
+}
 
-	position, found := game.GetPlayerPosition(username)
-	if !found {
-		return fmt.Errorf("玩家不在游戏中")
+// GetGame 获取游戏的完整状态，包括各玩家手牌，供运维查看
+// 先查找房间中进行中的游戏，找不到时读取已归档的游戏记录；返回游戏所在的房间ID，已归档时为空
+func (gs *GameService) GetGame(gameID string) (*models.Game, string, error) {
+	for _, room := range gs.roomService.Snapshots() {
+		if room.CurrentGame != nil && room.CurrentGame.ID == gameID {
+			return room.CurrentGame, room.ID, nil
+		}
 	}
 
-	gameLogic := models.NewGameLogic(game)
-	if err := gameLogic.CallLandlord(position, call); err != nil {
-		return err
+	game, err := gs.games.Get(gameID)
+	if err != nil {
+		return nil, "", err
 	}
+	return game, "", nil
+}
 
-	// 检查是否轮到下一个玩家，如果是AI玩家则通知
-	currentPlayer := game.GetPlayer(game.CurrentTurn)
-	if currentPlayer != nil && currentPlayer.IsAI {
-		gs.NotifyAITurn(roomID, currentPlayer.UserName)
+// TerminateGame 由运维结束房间当前的一局，该局标记为中止、不计分也不结算
+// gameID 不为空时只结束该局，避免误结束已开始的下一局；endSession 为 true 时同时结束多局对战，
+// 否则多局对战按流局处理并继续下一局
+func (gs *GameService) TerminateGame(roomID, gameID, reason string, endSession bool) (*models.Game, error) {
+	snapshot, err := gs.abandonGame(roomID, gameID, "terminate", reason, endSession)
+	if err != nil {
+		return nil, err
 	}
 
-	// 更新房间状态
-	room, _ := gs.roomService.GetRoom(roomID)
-	return gs.roomService.UpdateRoom(room)
+	logger.Info("房间 %s 的游戏 %s 被运维结束: %s", roomID, snapshot.ID, reason)
+	return snapshot, nil
 }
 
-// PlayCards 出牌
-func (gs *GameService) PlayCards(roomID, username string, cards []models.Card) error {
-	game, err := gs.GetGameByRoom(roomID)
+// abandonGame 把房间当前的一局标记为中止，返回中止后的游戏快照
+func (gs *GameService) abandonGame(roomID, gameID, action, reason string, endSession bool) (*models.Game, error) {
+	var snapshot *models.Game
+	err := gs.dispatchGame(roomID, action, func(room *models.Room, game *models.Game) error {
+		if !room.IsGameActive() || (gameID != "" && game.ID != gameID) {
+			return fmt.Errorf("游戏已结束")
+		}
+		if game.Status == models.GameStatusWaiting {
+			return fmt.Errorf("游戏未开始")
+		}
+
+		now := time.Now()
+		game.Status = models.GameStatusAbandoned
+		game.FinishedAt = &now
+		game.AddLog("abandon", game.CurrentTurn, nil, reason)
+		room.Status = models.RoomStatusIdle
+		room.UpdatedAt = now
+
+		if endSession && room.Session.IsActive() {
+			room.Session.RecordHand(game, 0)
+			room.Session.Finish(reason)
+		}
+		snapshot = game.Clone()
+		return nil
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return snapshot, nil
+}
 
-	if game.Status != models.GameStatusPlaying {
-		return fmt.Errorf("游戏状态不正确")
-	}
+// turnResult 游戏操作后的回合信息，用于在actor外通知AI
+type turnResult struct {
+	status      models.GameStatus
+	currentTurn models.PlayerPosition
+	currentIsAI bool
+	gameID      string
+	nextHand    bool         // 多局对战继续，需要安排下一局
+	ended       bool         // 本次操作使游戏结束（包括中止）
+	finished    *models.Room // 本次操作使游戏正常结束时的房间快照
+}
 
-	position, found := game.GetPlayerPosition(username)
-	if !found {
-		return fmt.Errorf("玩家不在游戏中")
-	}
+// dispatchGame 在房间actor中对当前游戏执行操作，并根据结果通知或停止AI
+func (gs *GameService) dispatchGame(roomID, action string, fn func(room *models.Room, game *models.Game) error) error {
+	var result turnResult
+	err := gs.roomService.Dispatch(roomID, action, func(room *models.Room) error {
+		game := room.CurrentGame
+		if game == nil {
+			return fmt.Errorf("房间没有活跃的游戏")
+		}
 
-	if position != game.CurrentTurn {
-		return fmt.Errorf("不是该玩家的回合")
-	}
+		active := room.IsGameActive()
+		if err := fn(room, game); err != nil {
+			return err
+		}
+		result.ended = active && !room.IsGameActive()
 
-	player := game.GetPlayer(position)
-	if player == nil {
-		return fmt.Errorf("玩家不存在")
-	}
+		result.status = game.Status
+		result.currentTurn = game.CurrentTurn
+		result.gameID = game.ID
+		if player := game.GetPlayer(game.CurrentTurn); player != nil {
+			result.currentIsAI = player.IsAI
+		}
 
-	// 检查玩家是否有这些牌
-	if !player.HasCards(cards) {
-		return fmt.Errorf("玩家没有这些牌")
+		// 多局对战中一局结束，记录得分
+		if !room.IsGameActive() && room.Session.IsActive() {
+			result.nextHand = room.Session.RecordHand(game, gs.roomService.NextHandDelay())
+		}
+		if game.Status == models.GameStatusFinished {
+			result.finished = room.Clone()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	// 分析牌型
-	handPattern := models.AnalyzeHand(cards)
-	if !handPattern.IsValid {
-		return fmt.Errorf("无效的牌型")
+	if result.ended {
+		gamesFinished.Mark()
 	}
-
-	// 检查是否能压过上一手牌
-	if len(game.LastPlayCards) > 0 && game.LastPlayer != position {
-		lastPattern := models.AnalyzeHand(game.LastPlayCards)
-		if !models.CanBeat(handPattern, lastPattern) {
-			return fmt.Errorf("无法压过上一手牌")
+	if result.finished != nil {
+		for _, hook := range gs.finishHooks {
+			hook(result.finished, result.finished.CurrentGame)
 		}
 	}
 
-	// 出牌
-	if !player.RemoveCards(cards) {
-		return fmt.Errorf("移除手牌失败")
+	if result.nextHand {
+		gs.scheduleNextHand(roomID, result.gameID)
 	}
 
-	// 更新游戏状态
-	game.LastPlayCards = cards
-	game.LastPlayer = position
-	game.NextTurn()
-
-	// 添加游戏日志
-	game.AddLog("play_cards", position, cards, fmt.Sprintf("%s 出牌 %s", player.UserName, handPattern.Type))
-
-	// 检查是否获胜
-	if player.GetCardCount() == 0 {
-		game.Status = models.GameStatusFinished
-		game.Winner = position
-		now := time.Now()
-		game.FinishedAt = &now
-
-		// 计算分数
-		gs.calculateScore(game)
-
-		game.AddLog("win", position, nil, fmt.Sprintf("%s 获胜", player.UserName))
-
-		// 结束房间游戏
-		room, _ := gs.roomService.GetRoom(roomID)
-		room.EndGame()
-
-		// 停止AI控制器
-		gs.StopAIControllers(roomID)
-	} else {
-		// 如果游戏继续，检查是否轮到AI玩家
-		currentPlayer := game.GetPlayer(game.CurrentTurn)
-		if currentPlayer != nil && currentPlayer.IsAI {
-			gs.NotifyAITurn(roomID, currentPlayer.UserName)
+	switch result.status {
+	case models.GameStatusCalling, models.GameStatusPlaying:
+		// 检查是否轮到AI玩家
+		if result.currentIsAI {
+			gs.NotifyAITurn(roomID, result.currentTurn)
 		}
+	default:
+		// 游戏已结束，停止AI控制器
+		gs.StopAIControllers(roomID)
 	}
-
-	// 更新房间状态
-	room, _ := gs.roomService.GetRoom(roomID)
-	return gs.roomService.UpdateRoom(room)
+	return nil
 }
 
-// PassTurn 过牌
-func (gs *GameService) PassTurn(roomID, username string) error {
-	game, err := gs.GetGameByRoom(roomID)
+// StartGame 开始房间的游戏：发牌、启动AI控制器，首个行动的是AI时通知其行动
+func (gs *GameService) StartGame(roomID string) (*models.Game, error) {
+	game, err := gs.roomService.StartGame(roomID)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	gamesStarted.Mark()
 
-	if game.Status != models.GameStatusPlaying {
-		return fmt.Errorf("游戏状态不正确")
+	// 启动AI控制器
+	if err := gs.StartAIControllers(roomID); err != nil {
+		logger.Error("启动AI控制器失败: %v", err)
 	}
 
-	position, found := game.GetPlayerPosition(username)
-	if !found {
-		return fmt.Errorf("玩家不在游戏中")
+	// 检查是否第一个玩家是AI，如果是则通知其行动
+	if player := game.GetPlayer(game.CurrentTurn); player != nil && player.IsAI {
+		gs.NotifyAITurn(roomID, player.Position)
 	}
+	return game, nil
+}
 
-	if position != game.CurrentTurn {
-		return fmt.Errorf("不是该玩家的回合")
-	}
+// scheduleNextHand 倒计时结束后自动开始多局对战的下一局
+func (gs *GameService) scheduleNextHand(roomID, previousGameID string) {
+	time.AfterFunc(gs.roomService.NextHandDelay(), func() {
+		if err := gs.StartNextHand(roomID, previousGameID); err != nil {
+			logger.Error("房间 %s 开始下一局失败: %v", roomID, err)
+		}
+	})
+}
 
-	// 如果上一次出牌的是自己，不能过牌
-	if game.LastPlayer == position {
-		return fmt.Errorf("上次出牌是你自己，不能过牌")
+// StartNextHand 开始多局对战的下一局并启动AI
+func (gs *GameService) StartNextHand(roomID, previousGameID string) error {
+	game, err := gs.roomService.StartNextHand(roomID, previousGameID)
+	if err != nil || game == nil {
+		return err
 	}
+	gamesStarted.Mark()
 
-	player := game.GetPlayer(position)
-	game.NextTurn()
-
-	// 添加游戏日志
-	game.AddLog("pass", position, nil, fmt.Sprintf("%s 过牌", player.UserName))
-
-	// 检查是否一圈都过了
-	if gs.checkAllPass(game) {
-		// 清除上一手牌，让最后出牌的玩家继续出牌
-		game.LastPlayCards = nil
-		game.CurrentTurn = game.LastPlayer
-		game.AddLog("new_round", game.LastPlayer, nil, "新一轮开始")
+	logger.Info("房间 %s 自动开始下一局: %s", roomID, game.ID)
+	if err := gs.StartAIControllers(roomID); err != nil {
+		return err
 	}
-
-	// 检查是否轮到AI玩家
-	currentPlayer := game.GetPlayer(game.CurrentTurn)
-	if currentPlayer != nil && currentPlayer.IsAI {
-		gs.NotifyAITurn(roomID, currentPlayer.UserName)
+	if player := game.GetPlayer(game.CurrentTurn); player != nil && player.IsAI {
+		gs.NotifyAITurn(roomID, player.Position)
 	}
-
-	// 更新房间状态
-	room, _ := gs.roomService.GetRoom(roomID)
-	return gs.roomService.UpdateRoom(room)
+	return nil
 }
 
-// checkAllPass 检查是否所有其他玩家都过牌了
-func (gs *GameService) checkAllPass(game *models.Game) bool {
-	// 这里简化处理，实际需要记录每轮的过牌状态
-	// 如果当前回合回到了上次出牌的玩家，说明其他人都过了
-	return game.CurrentTurn == game.LastPlayer
+// CallLandlord 叫地主
+func (gs *GameService) CallLandlord(roomID, username string, call bool) error {
+	return gs.dispatchGame(roomID, "call_landlord", func(room *models.Room, game *models.Game) error {
+		position, found := game.GetPlayerPosition(username)
+		if !found {
+			return fmt.Errorf("玩家不在游戏中")
+		}
+
+		gameLogic := models.NewGameLogic(game)
+		if err := gameLogic.CallLandlord(position, call); err != nil {
+			return err
+		}
+
+		if game.Status == models.GameStatusAbandoned {
+			room.Status = models.RoomStatusIdle
+		}
+		return nil
+	})
 }
 
-// calculateScore 计算分数
-func (gs *GameService) calculateScore(game *models.Game) {
-	if game.Status != models.GameStatusFinished {
-		return
-	}
+// PlayCards 出牌
+func (gs *GameService) PlayCards(roomID, username string, cards []models.Card) error {
+	return gs.dispatchGame(roomID, "play_cards", func(room *models.Room, game *models.Game) error {
+		if game.Status != models.GameStatusPlaying {
+			return fmt.Errorf("游戏状态不正确")
+		}
 
-	winner := game.GetPlayer(game.Winner)
-	if winner == nil {
-		return
-	}
+		position, found := game.GetPlayerPosition(username)
+		if !found {
+			return fmt.Errorf("玩家不在游戏中")
+		}
 
-	baseScore := 1
+		gameLogic := models.NewGameLogic(game)
+		if err := gameLogic.PlayCards(position, cards); err != nil {
+			return err
+		}
 
-	// 地主获胜
-	if winner.Role == models.RoleLandlord {
-		// 地主获胜，地主得2分，农民各扣1分
-		winner.Score = baseScore * 2
-		for _, player := range game.Players {
-			if player != nil && player.Role == models.RoleFarmer {
-				player.Score = -baseScore
+		if game.Status == models.GameStatusFinished {
+			if winner := game.GetPlayer(game.Winner); winner != nil {
+				logger.Info("游戏 %s 结束，获胜者: %s", game.ID, winner.UserName)
 			}
+
+			// 结束房间游戏
+			room.EndGame()
 		}
-	} else {
-		// 农民获胜，每个农民得1分，地主扣2分
-		for _, player := range game.Players {
-			if player != nil {
-				if player.Role == models.RoleFarmer {
-					player.Score = baseScore
-				} else if player.Role == models.RoleLandlord {
-					player.Score = -baseScore * 2
-				}
-			}
+		return nil
+	})
+}
+
+// PassTurn 过牌
+func (gs *GameService) PassTurn(roomID, username string) error {
+	return gs.dispatchGame(roomID, "pass", func(room *models.Room, game *models.Game) error {
+		if game.Status != models.GameStatusPlaying {
+			return fmt.Errorf("游戏状态不正确")
+		}
+
+		position, found := game.GetPlayerPosition(username)
+		if !found {
+			return fmt.Errorf("玩家不在游戏中")
 		}
-	}
 
-	logger.Info("游戏 %s 结束，获胜者: %s", game.ID, winner.UserName)
+		gameLogic := models.NewGameLogic(game)
+		return gameLogic.Pass(position)
+	})
 }
 
 // GetPlayerHand 获取玩家手牌（只能获取自己的手牌）
@@ -290,10 +325,14 @@ func (gs *GameService) GetPlayerHand(roomID, username string) ([]models.Card, er
 
 // GetGameState 获取游戏状态（公开信息）
 func (gs *GameService) GetGameState(roomID, username string) (map[string]interface{}, error) {
-	game, err := gs.GetGameByRoom(roomID)
+	room, err := gs.roomService.GetRoom(roomID)
 	if err != nil {
 		return nil, err
 	}
+	game := room.CurrentGame
+	if game == nil {
+		return nil, fmt.Errorf("房间没有活跃的游戏")
+	}
 
 	// 构建游戏状态信息
 	state := map[string]interface{}{
@@ -324,6 +363,7 @@ func (gs *GameService) GetGameState(roomID, username string) (map[string]interfa
 			"role_name":     models.RoleNames[player.Role],
 			"card_count":    player.GetCardCount(),
 			"is_ready":      player.IsReady,
+			"is_ai":         player.IsAI,
 			"is_online":     player.IsOnline,
 			"score":         player.Score,
 			"call_landlord": player.CallLandlord,
@@ -338,6 +378,29 @@ func (gs *GameService) GetGameState(roomID, username string) (map[string]interfa
 	}
 	state["players"] = players
 
+	// 金币结算
+	if room.BaseStake > 0 {
+		state["base_stake"] = room.BaseStake
+		state["multiplier"] = game.Multiplier
+		state["spring"] = game.Spring
+	}
+
+	// 多局对战
+	if session := room.Session; session != nil {
+		state["session"] = map[string]interface{}{
+			"total_hands":  session.TotalHands,
+			"hands_played": session.HandsPlayed,
+			"status":       session.Status,
+			"status_name":  models.SessionStatusNames[session.Status],
+			"totals":       session.Totals,
+			"first_bidder": session.FirstBidder,
+			"next_hand_at": session.NextHandAt,
+			"hands":        session.Hands,
+			"end_reason":   session.EndReason,
+			"standings":    session.Standings(),
+		}
+	}
+
 	// 地主牌（只有地主确定后才显示）
 	if game.Status >= models.GameStatusPlaying {
 		state["landlord_cards"] = game.LandlordCards
@@ -353,47 +416,74 @@ func (gs *GameService) StartAIControllers(roomID string) error {
 		return err
 	}
 
+	gs.aiMutex.Lock()
+	defer gs.aiMutex.Unlock()
+
+	// 重复启动时先停止旧的控制器，避免旧控制器继续行动
+	gs.stopAIControllersLocked(roomID)
+
+	controllers := make(map[models.PlayerPosition]*AIController)
 	for _, player := range game.Players {
 		if player != nil && player.IsAI {
 			// 创建并启动AI控制器
-			controller := NewAIController(player, gs, roomID)
-			gs.aiControllers[player.UserName] = controller
+			controller := NewAIController(player, gs, gs.aiPool, roomID)
+			controllers[player.Position] = controller
+			controller.Start()
 
-			// 在独立的goroutine中启动控制器
-			go controller.Start()
-
-			logger.Info("为AI玩家 %s 启动控制器", player.UserName)
+			logger.Info("为房间 %s 的AI玩家 %s 启动控制器", roomID, player.UserName)
 		}
 	}
 
+	if len(controllers) > 0 {
+		gs.aiControllers[roomID] = controllers
+	}
+
 	return nil
 }
 
 // StopAIControllers 停止房间中所有AI控制器
 func (gs *GameService) StopAIControllers(roomID string) {
-	game, err := gs.GetGameByRoom(roomID)
-	if err != nil {
-		return
+	gs.aiMutex.Lock()
+	defer gs.aiMutex.Unlock()
+
+	gs.stopAIControllersLocked(roomID)
+}
+
+// StopAllAIControllers 停止所有房间的AI控制器，用于停机
+func (gs *GameService) StopAllAIControllers() {
+	gs.aiMutex.Lock()
+	defer gs.aiMutex.Unlock()
+
+	for roomID := range gs.aiControllers {
+		gs.stopAIControllersLocked(roomID)
 	}
+}
 
-	for _, player := range game.Players {
-		if player != nil && player.IsAI {
-			if controller, exists := gs.aiControllers[player.UserName]; exists {
-				controller.Stop()
-				delete(gs.aiControllers, player.UserName)
-				logger.Info("停止AI玩家 %s 的控制器", player.UserName)
-			}
-		}
+// stopAIControllersLocked 停止房间中所有AI控制器，调用方需持有aiMutex
+func (gs *GameService) stopAIControllersLocked(roomID string) {
+	for _, controller := range gs.aiControllers[roomID] {
+		controller.Stop()
+		logger.Info("停止房间 %s 的AI玩家 %s 的控制器", roomID, controller.GetPlayer().UserName)
 	}
+	delete(gs.aiControllers, roomID)
 }
 
-// NotifyAITurn 通知AI玩家轮到其行动
-func (gs *GameService) NotifyAITurn(roomID string, playerName string) {
-	if controller, exists := gs.aiControllers[playerName]; exists {
+// NotifyAITurn 通知指定座位的AI玩家轮到其行动
+func (gs *GameService) NotifyAITurn(roomID string, position models.PlayerPosition) {
+	gs.aiMutex.Lock()
+	controller, exists := gs.aiControllers[roomID][position]
+	gs.aiMutex.Unlock()
+
+	if exists {
 		controller.NotifyTurn()
 	}
 }
 
+// GetAIStats 获取AI工作池负载统计
+func (gs *GameService) GetAIStats() AIPoolStats {
+	return gs.aiPool.GetStats()
+}
+
 // IsAIPlayer 检查是否为AI玩家
 func (gs *GameService) IsAIPlayer(roomID string, playerName string) bool {
 	game, err := gs.GetGameByRoom(roomID)
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"aigames/internal/config"
+	"aigames/internal/models"
+)
+
+// lockoutPolicy 登录失败锁定策略
+type lockoutPolicy struct {
+	maxFailures        int           // 账户连续失败阈值
+	maxAddressFailures int           // 来源地址失败阈值
+	window             time.Duration // 失败计数窗口
+	lockDuration       time.Duration // 首次锁定时长
+	maxLockDuration    time.Duration // 最长锁定时长
+}
+
+// newLockoutPolicy 根据安全配置创建锁定策略
+func newLockoutPolicy(security config.SecurityConfig) lockoutPolicy {
+	policy := lockoutPolicy{
+		maxFailures:        security.MaxLoginFailures,
+		maxAddressFailures: security.MaxAddressFailures,
+		window:             time.Duration(security.FailureWindow) * time.Second,
+		lockDuration:       time.Duration(security.LockDuration) * time.Second,
+		maxLockDuration:    time.Duration(security.MaxLockDuration) * time.Second,
+	}
+	if policy.lockDuration <= 0 {
+		policy.lockDuration = time.Minute
+	}
+	if policy.maxLockDuration < policy.lockDuration {
+		policy.maxLockDuration = policy.lockDuration
+	}
+	return policy
+}
+
+// backoff 计算第lockCount次锁定的时长，每次翻倍直到上限
+func (p lockoutPolicy) backoff(lockCount int) time.Duration {
+	duration := p.lockDuration
+	for i := 1; i < lockCount && duration < p.maxLockDuration; i++ {
+		duration *= 2
+	}
+	if duration > p.maxLockDuration {
+		duration = p.maxLockDuration
+	}
+	return duration
+}
+
+// expired 判断上次失败是否已超出计数窗口
+func (p lockoutPolicy) expired(lastFailed *time.Time, now time.Time) bool {
+	return lastFailed == nil || (p.window > 0 && now.Sub(*lastFailed) > p.window)
+}
+
+// addressAttempts 来源地址的登录失败记录
+type addressAttempts struct {
+	failures    int
+	lastFailed  time.Time
+	lockCount   int
+	lockedUntil time.Time
+}
+
+// addressGuard 按来源地址统计登录失败，仅保存在内存中
+type addressGuard struct {
+	attempts  map[string]*addressAttempts
+	lastSweep time.Time
+	mutex     sync.Mutex
+}
+
+// newAddressGuard 创建来源地址登录失败统计
+func newAddressGuard() *addressGuard {
+	return &addressGuard{attempts: make(map[string]*addressAttempts)}
+}
+
+// CheckLoginAllowed 检查账户或来源地址是否被锁定，锁定时返回解锁时间
+// user 为空表示用户不存在，此时只检查来源地址
+func (s *UserService) CheckLoginAllowed(user *models.User, addr string) (time.Time, bool) {
+	now := time.Now()
+	if user != nil && user.IsLocked(now) {
+		return *user.LockedUntil, false
+	}
+
+	s.addresses.mutex.Lock()
+	defer s.addresses.mutex.Unlock()
+
+	if attempts, exists := s.addresses.attempts[addr]; exists && now.Before(attempts.lockedUntil) {
+		return attempts.lockedUntil, false
+	}
+	return time.Time{}, true
+}
+
+// RecordLoginFailure 记录一次登录失败，达到阈值时锁定账户或来源地址并返回解锁时间
+// name 为空表示用户不存在，此时只记录来源地址
+func (s *UserService) RecordLoginFailure(name, addr string) (time.Time, bool, error) {
+	now := time.Now()
+	unlockAt, locked := s.recordAddressFailure(addr, now)
+
+	if name == "" {
+		return unlockAt, locked, nil
+	}
+
+	err := s.updateUser(name, func(user *models.User) error {
+		if s.lockout.expired(user.LastFailedAt, now) {
+			user.FailedLogins = 0
+		}
+		user.FailedLogins++
+		user.LastFailedAt = &now
+
+		if s.lockout.maxFailures > 0 && user.FailedLogins >= s.lockout.maxFailures {
+			user.LockCount++
+			until := now.Add(s.lockout.backoff(user.LockCount))
+			user.LockedUntil = &until
+			user.FailedLogins = 0
+
+			if !locked || until.After(unlockAt) {
+				unlockAt = until
+			}
+			locked = true
+		}
+		return nil
+	})
+	return unlockAt, locked, err
+}
+
+// recordAddressFailure 记录来源地址的一次登录失败
+func (s *UserService) recordAddressFailure(addr string, now time.Time) (time.Time, bool) {
+	guard := s.addresses
+	guard.mutex.Lock()
+	defer guard.mutex.Unlock()
+
+	guard.sweep(s.lockout, now)
+
+	attempts, exists := guard.attempts[addr]
+	if !exists {
+		attempts = &addressAttempts{}
+		guard.attempts[addr] = attempts
+	}
+	if s.lockout.expired(&attempts.lastFailed, now) {
+		attempts.failures = 0
+	}
+	attempts.failures++
+	attempts.lastFailed = now
+
+	if s.lockout.maxAddressFailures > 0 && attempts.failures >= s.lockout.maxAddressFailures {
+		attempts.lockCount++
+		attempts.lockedUntil = now.Add(s.lockout.backoff(attempts.lockCount))
+		attempts.failures = 0
+		return attempts.lockedUntil, true
+	}
+	return time.Time{}, false
+}
+
+// sweep 每分钟最多一次，删除已过窗口且未锁定的来源地址记录，调用方需持有锁
+func (g *addressGuard) sweep(policy lockoutPolicy, now time.Time) {
+	if now.Sub(g.lastSweep) < time.Minute {
+		return
+	}
+	g.lastSweep = now
+
+	idle := policy.window
+	if idle < policy.maxLockDuration {
+		idle = policy.maxLockDuration
+	}
+	for addr, attempts := range g.attempts {
+		if now.After(attempts.lockedUntil) && now.Sub(attempts.lastFailed) > idle {
+			delete(g.attempts, addr)
+		}
+	}
+}
+
+// RecordLoginSuccess 登录成功后清除失败计数和锁定状态，并更新最后登录时间
+func (s *UserService) RecordLoginSuccess(name string) error {
+	return s.updateUser(name, func(user *models.User) error {
+		user.FailedLogins = 0
+		user.LastFailedAt = nil
+		user.LockCount = 0
+		user.LockedUntil = nil
+		user.LastLoginAt = time.Now()
+		return nil
+	})
+}
+
+// Unlock 解除账户锁定并清除失败计数，供运维使用
+func (s *UserService) Unlock(name string) error {
+	return s.updateUser(name, func(user *models.User) error {
+		user.FailedLogins = 0
+		user.LastFailedAt = nil
+		user.LockCount = 0
+		user.LockedUntil = nil
+		return nil
+	})
+}
+
+// UnlockAddress 解除来源地址锁定，供运维使用
+func (s *UserService) UnlockAddress(addr string) bool {
+	s.addresses.mutex.Lock()
+	defer s.addresses.mutex.Unlock()
+
+	_, exists := s.addresses.attempts[addr]
+	delete(s.addresses.attempts, addr)
+	return exists
+}
+
+// updateUser 在同一个事务中读取、修改并保存用户
+func (s *UserService) updateUser(name string, fn func(user *models.User) error) error {
+	return s.users.Update(name, fn)
+}
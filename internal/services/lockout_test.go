@@ -0,0 +1,145 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"aigames/internal/config"
+	"aigames/internal/models"
+)
+
+// lockoutConfig 账户连续失败3次、地址失败5次后锁定，锁定时长从1分钟开始翻倍，最长2分半
+func lockoutConfig(cfg *config.Config) {
+	cfg.Security.MaxLoginFailures = 3
+	cfg.Security.MaxAddressFailures = 5
+	cfg.Security.FailureWindow = 600
+	cfg.Security.LockDuration = 60
+	cfg.Security.MaxLockDuration = 150
+}
+
+// failLogins 记录 n 次登录失败，返回最后一次的结果
+func failLogins(t *testing.T, us *UserService, name, addr string, n int) (time.Time, bool) {
+	t.Helper()
+	var unlockAt time.Time
+	var locked bool
+	for i := 0; i < n; i++ {
+		var err error
+		if unlockAt, locked, err = us.RecordLoginFailure(name, addr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return unlockAt, locked
+}
+
+// expireLock 把账户的锁定和最后失败时间移到过去，模拟锁定到期
+func expireLock(t *testing.T, env *testEnv, name string) {
+	t.Helper()
+	err := env.store.Users().Update(name, func(user *models.User) error {
+		past := time.Now().Add(-time.Hour)
+		user.LockedUntil = &past
+		user.LastFailedAt = &past
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLockoutBackoffDoublesUpToLimit(t *testing.T) {
+	env := newTestEnv(t, lockoutConfig)
+	env.addUsers(t, "alice")
+	us := env.users
+
+	if _, locked := failLogins(t, us, "alice", "", 2); locked {
+		t.Fatal("未达到阈值时账户被锁定")
+	}
+
+	// 每次锁定时长翻倍，不超过最长锁定时长
+	for i, want := range []time.Duration{time.Minute, 2 * time.Minute, 150 * time.Second} {
+		if i > 0 {
+			expireLock(t, env, "alice")
+			failLogins(t, us, "alice", "", 2)
+		}
+		unlockAt, locked := failLogins(t, us, "alice", "", 1)
+		if !locked {
+			t.Fatalf("第 %d 次锁定没有生效", i+1)
+		}
+		if got := time.Until(unlockAt); got > want || got < want-5*time.Second {
+			t.Fatalf("第 %d 次锁定时长为 %v，应为 %v", i+1, got, want)
+		}
+
+		user, err := us.GetUser("alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if at, allowed := us.CheckLoginAllowed(user, ""); allowed || !at.Equal(unlockAt) {
+			t.Fatalf("锁定期间允许登录: %v, 解锁时间 %v", allowed, at)
+		}
+	}
+
+	// 登录成功后重新从首次锁定时长开始
+	expireLock(t, env, "alice")
+	if err := us.RecordLoginSuccess("alice"); err != nil {
+		t.Fatal(err)
+	}
+	unlockAt, _ := failLogins(t, us, "alice", "", 3)
+	if got := time.Until(unlockAt); got > time.Minute {
+		t.Fatalf("登录成功后的锁定时长为 %v", got)
+	}
+}
+
+func TestLockoutFailuresOutsideWindowReset(t *testing.T) {
+	env := newTestEnv(t, lockoutConfig)
+	env.addUsers(t, "alice")
+	us := env.users
+
+	failLogins(t, us, "alice", "", 2)
+	err := env.store.Users().Update("alice", func(user *models.User) error {
+		past := time.Now().Add(-time.Hour)
+		user.LastFailedAt = &past
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, locked := failLogins(t, us, "alice", "", 1); locked {
+		t.Fatal("超出计数窗口的失败仍然计数")
+	}
+}
+
+func TestLockoutByAddress(t *testing.T) {
+	env := newTestEnv(t, lockoutConfig)
+	env.addUsers(t, "alice")
+	us := env.users
+
+	// 不存在的用户名只计入来源地址
+	if _, locked := failLogins(t, us, "", "10.0.0.1", 4); locked {
+		t.Fatal("未达到阈值时地址被锁定")
+	}
+	unlockAt, locked := failLogins(t, us, "", "10.0.0.1", 1)
+	if !locked {
+		t.Fatal("地址失败次数达到阈值后没有锁定")
+	}
+
+	// 地址锁定时正确的账户也不能从该地址登录，其他地址不受影响
+	user, err := us.GetUser("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if at, allowed := us.CheckLoginAllowed(user, "10.0.0.1"); allowed || !at.Equal(unlockAt) {
+		t.Fatalf("地址锁定期间允许登录: %v", allowed)
+	}
+	if _, allowed := us.CheckLoginAllowed(user, "10.0.0.2"); !allowed {
+		t.Fatal("其他地址被锁定")
+	}
+	if user.FailedLogins != 0 || user.IsLocked(time.Now()) {
+		t.Fatal("不存在的用户名的失败计入了其他账户")
+	}
+
+	if !us.UnlockAddress("10.0.0.1") {
+		t.Fatal("解锁地址时没有找到记录")
+	}
+	if _, allowed := us.CheckLoginAllowed(user, "10.0.0.1"); !allowed {
+		t.Fatal("解锁后地址仍被锁定")
+	}
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"aigames/internal/models"
+	"aigames/pkg/metrics"
+)
+
+// 服务内部记录的运行指标
+var (
+	gamesStarted  = metrics.NewMeter("aigames_games_started", "开始的游戏局数")
+	gamesFinished = metrics.NewMeter("aigames_games_finished", "结束的游戏局数（包括中止）")
+
+	aiDecisionSeconds = metrics.NewHistogram("aigames_ai_decision_seconds",
+		"AI一次决策的执行耗时(秒)，不含思考等待和排队时间", nil, "result")
+)
+
+// roomStatusLabels 房间状态在指标中的标签值
+var roomStatusLabels = map[models.RoomStatus]string{
+	models.RoomStatusIdle:    "idle",
+	models.RoomStatusWaiting: "waiting",
+	models.RoomStatusPlaying: "playing",
+}
+
+// gameStatusLabels 游戏状态在指标中的标签值
+var gameStatusLabels = map[models.GameStatus]string{
+	models.GameStatusWaiting:   "waiting",
+	models.GameStatusReady:     "ready",
+	models.GameStatusDealing:   "dealing",
+	models.GameStatusCalling:   "calling",
+	models.GameStatusPlaying:   "playing",
+	models.GameStatusFinished:  "finished",
+	models.GameStatusAbandoned: "abandoned",
+}
+
+// RegisterMetrics 注册从服务状态中读取的指标，在抓取时计算
+func RegisterMetrics(roomService *RoomService, gameService *GameService, presence *PresenceService) {
+	metrics.GaugeFunc("aigames_rooms", "内存中的房间数，按房间状态", []string{"status"}, func() []metrics.Sample {
+		counts := roomService.CountByStatus()
+		samples := make([]metrics.Sample, 0, len(roomStatusLabels))
+		for status, label := range roomStatusLabels {
+			samples = append(samples, metrics.Sample{Values: []string{label}, Value: float64(counts[status])})
+		}
+		return samples
+	})
+
+	metrics.GaugeFunc("aigames_games", "房间中当前的游戏数，按游戏状态", []string{"status"}, func() []metrics.Sample {
+		counts := gameService.CountByStatus()
+		samples := make([]metrics.Sample, 0, len(gameStatusLabels))
+		for status, label := range gameStatusLabels {
+			samples = append(samples, metrics.Sample{Values: []string{label}, Value: float64(counts[status])})
+		}
+		return samples
+	})
+
+	metrics.GaugeFunc("aigames_online_users", "已登录的在线用户数", nil, func() []metrics.Sample {
+		return []metrics.Sample{{Value: float64(presence.OnlineCount())}}
+	})
+}
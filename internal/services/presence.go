@@ -0,0 +1,135 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/lonng/nano/session"
+)
+
+// PresenceService 在线状态服务，记录每个在线用户当前使用的会话
+type PresenceService struct {
+	sessions  map[string]*session.Session
+	listeners []func(username string, online bool)
+	mutex     sync.RWMutex
+}
+
+// NewPresenceService 创建在线状态服务实例
+func NewPresenceService() *PresenceService {
+	return &PresenceService{sessions: make(map[string]*session.Session)}
+}
+
+// OnChange 注册上线、下线回调，回调在锁外执行
+func (ps *PresenceService) OnChange(fn func(username string, online bool)) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+
+	ps.listeners = append(ps.listeners, fn)
+}
+
+// SetOnline 记录用户上线，同一用户的新会话替换旧会话
+func (ps *PresenceService) SetOnline(username string, s *session.Session) {
+	if username == "" {
+		return
+	}
+
+	ps.mutex.Lock()
+	_, wasOnline := ps.sessions[username]
+	ps.sessions[username] = s
+	listeners := ps.listeners
+	ps.mutex.Unlock()
+
+	if !wasOnline {
+		for _, fn := range listeners {
+			fn(username, true)
+		}
+	}
+}
+
+// SetOffline 记录用户下线，只有当前会话下线时才生效
+func (ps *PresenceService) SetOffline(username string, s *session.Session) {
+	if username == "" {
+		return
+	}
+
+	ps.mutex.Lock()
+	current, exists := ps.sessions[username]
+	if !exists || current != s {
+		ps.mutex.Unlock()
+		return
+	}
+	delete(ps.sessions, username)
+	listeners := ps.listeners
+	ps.mutex.Unlock()
+
+	for _, fn := range listeners {
+		fn(username, false)
+	}
+}
+
+// IsOnline 判断用户是否在线
+func (ps *PresenceService) IsOnline(username string) bool {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	_, exists := ps.sessions[username]
+	return exists
+}
+
+// Session 获取在线用户当前使用的会话
+func (ps *PresenceService) Session(username string) (*session.Session, bool) {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	s, exists := ps.sessions[username]
+	return s, exists
+}
+
+// OnlineCount 获取在线用户数
+func (ps *PresenceService) OnlineCount() int {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	return len(ps.sessions)
+}
+
+// Push 向在线用户推送消息
+func (ps *PresenceService) Push(username, route string, v interface{}) error {
+	ps.mutex.RLock()
+	s, exists := ps.sessions[username]
+	ps.mutex.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("用户不在线")
+	}
+	return s.Push(route, v)
+}
+
+// Broadcast 向所有在线用户推送消息，返回推送的用户数
+func (ps *PresenceService) Broadcast(route string, v interface{}) int {
+	ps.mutex.RLock()
+	sessions := make([]*session.Session, 0, len(ps.sessions))
+	for _, s := range ps.sessions {
+		sessions = append(sessions, s)
+	}
+	ps.mutex.RUnlock()
+
+	for _, s := range sessions {
+		s.Push(route, v)
+	}
+	return len(sessions)
+}
+
+// Disconnect 向在线用户推送消息后断开其连接，返回用户是否在线
+func (ps *PresenceService) Disconnect(username, route string, v interface{}) bool {
+	ps.mutex.RLock()
+	s, exists := ps.sessions[username]
+	ps.mutex.RUnlock()
+
+	if !exists {
+		return false
+	}
+	s.Push(route, v)
+	s.Close()
+	return true
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"aigames/internal/config"
+	"aigames/pkg/logger"
+)
+
+// rateRule 令牌桶参数
+type rateRule struct {
+	rate  float64 // 每秒补充的令牌数，不大于0表示不限流
+	burst float64 // 令牌桶容量
+}
+
+// tokenBucket 令牌桶
+type tokenBucket struct {
+	route  string
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter 请求限流器
+// 每个路由和每个调用方（用户或会话）的组合拥有独立的令牌桶
+type RateLimiter struct {
+	enabled         bool
+	defaultRule     rateRule
+	routes          map[string]rateRule
+	cleanupInterval time.Duration
+
+	buckets  map[string]*tokenBucket // key: 路由|调用方
+	rejected map[string]int64        // 各路由被拒绝的请求数
+	total    int64                   // 被拒绝的请求总数
+	mutex    sync.Mutex
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// RateLimitStats 限流统计
+type RateLimitStats struct {
+	Buckets         int              `json:"buckets"`           // 当前令牌桶数量
+	Rejected        int64            `json:"rejected"`          // 被拒绝的请求总数
+	RejectedByRoute map[string]int64 `json:"rejected_by_route"` // 各路由被拒绝的请求数
+}
+
+// NewRateLimiter 根据限流配置创建限流器
+func NewRateLimiter(cfg config.RateLimitConfig) *RateLimiter {
+	limiter := &RateLimiter{
+		enabled:         cfg.Enabled,
+		defaultRule:     newRateRule(cfg.Rate, cfg.Burst),
+		routes:          make(map[string]rateRule),
+		cleanupInterval: time.Duration(cfg.CleanupInterval) * time.Second,
+		buckets:         make(map[string]*tokenBucket),
+		rejected:        make(map[string]int64),
+		done:            make(chan struct{}),
+	}
+	for _, rule := range cfg.Routes {
+		limiter.routes[rule.Route] = newRateRule(rule.Rate, rule.Burst)
+	}
+	return limiter
+}
+
+// newRateRule 创建令牌桶参数，容量至少为1
+func newRateRule(rate float64, burst int) rateRule {
+	if burst < 1 {
+		burst = 1
+	}
+	return rateRule{rate: rate, burst: float64(burst)}
+}
+
+// Start 启动空闲令牌桶清理
+func (rl *RateLimiter) Start() {
+	if !rl.enabled {
+		logger.Info("请求限流未启用")
+		return
+	}
+	if rl.cleanupInterval > 0 {
+		go rl.cleanup()
+	}
+	logger.Info("请求限流启动: 默认速率=%.2f/s, 容量=%.0f, 路由规则=%d",
+		rl.defaultRule.rate, rl.defaultRule.burst, len(rl.routes))
+}
+
+// Stop 停止空闲令牌桶清理
+func (rl *RateLimiter) Stop() {
+	rl.stopOnce.Do(func() { close(rl.done) })
+}
+
+// Allow 判断请求是否允许通过，允许时消耗一个令牌
+func (rl *RateLimiter) Allow(route, caller string) bool {
+	if !rl.enabled {
+		return true
+	}
+
+	rule := rl.ruleFor(route)
+	if rule.rate <= 0 {
+		return true
+	}
+
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	now := time.Now()
+	key := route + "|" + caller
+	bucket, exists := rl.buckets[key]
+	if !exists {
+		bucket = &tokenBucket{route: route, tokens: rule.burst, last: now}
+		rl.buckets[key] = bucket
+	} else {
+		bucket.tokens += now.Sub(bucket.last).Seconds() * rule.rate
+		if bucket.tokens > rule.burst {
+			bucket.tokens = rule.burst
+		}
+		bucket.last = now
+	}
+
+	if bucket.tokens < 1 {
+		rl.rejected[route]++
+		rl.total++
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// ruleFor 获取路由的限流规则
+func (rl *RateLimiter) ruleFor(route string) rateRule {
+	if rule, exists := rl.routes[route]; exists {
+		return rule
+	}
+	return rl.defaultRule
+}
+
+// GetStats 获取限流统计
+func (rl *RateLimiter) GetStats() RateLimitStats {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	stats := RateLimitStats{
+		Buckets:         len(rl.buckets),
+		Rejected:        rl.total,
+		RejectedByRoute: make(map[string]int64, len(rl.rejected)),
+	}
+	for route, count := range rl.rejected {
+		stats.RejectedByRoute[route] = count
+	}
+	return stats
+}
+
+// cleanup 定期删除已经回满的令牌桶，并输出拒绝统计
+func (rl *RateLimiter) cleanup() {
+	ticker := time.NewTicker(rl.cleanupInterval)
+	defer ticker.Stop()
+
+	var lastRejected int64
+	for {
+		select {
+		case now := <-ticker.C:
+			rl.mutex.Lock()
+			for key, bucket := range rl.buckets {
+				rule := rl.ruleFor(bucket.route)
+				// 回满的令牌桶与新建的令牌桶等价，可以安全删除
+				if rule.rate <= 0 || bucket.tokens+now.Sub(bucket.last).Seconds()*rule.rate >= rule.burst {
+					delete(rl.buckets, key)
+				}
+			}
+			total := rl.total
+			rl.mutex.Unlock()
+
+			if total != lastRejected {
+				logger.Warn("请求限流: 最近%v内拒绝%d个请求, 累计拒绝%d个",
+					rl.cleanupInterval, total-lastRejected, total)
+				lastRejected = total
+			}
+		case <-rl.done:
+			return
+		}
+	}
+}
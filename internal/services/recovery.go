@@ -0,0 +1,205 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"aigames/internal/config"
+	"aigames/internal/models"
+	"aigames/pkg/logger"
+	"aigames/pkg/protocol"
+)
+
+// GameRecovery 恢复服务器停机或崩溃时进行中的游戏
+// 启动时逐局检查一致性：不一致的游戏中止；一致的游戏重启AI，等待真人玩家重新上线。
+// 恢复时间内没有任何真人玩家重新上线的游戏中止，中止的游戏所在的多局对战一并结束。
+// 多局对战在两局之间停机的，重新安排下一局
+type GameRecovery struct {
+	roomService *RoomService
+	gameService *GameService
+	presence    *PresenceService
+
+	timeout time.Duration // 等待真人玩家重新上线的时长，0表示不恢复
+
+	mutex   sync.Mutex
+	pending map[string]*recoveredGame // 等待玩家重新上线的游戏 key: 房间ID
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// recoveredGame 已恢复、等待玩家重新上线的游戏
+type recoveredGame struct {
+	gameID      string
+	humans      []string // 真人玩家
+	reconnected bool     // 是否有真人玩家重新上线
+}
+
+// NewGameRecovery 根据游戏配置创建游戏恢复
+func NewGameRecovery(roomService *RoomService, gameService *GameService, presence *PresenceService, cfg config.GameConfig) *GameRecovery {
+	return &GameRecovery{
+		roomService: roomService,
+		gameService: gameService,
+		presence:    presence,
+		timeout:     time.Duration(cfg.RecoveryTimeout) * time.Second,
+		pending:     make(map[string]*recoveredGame),
+		done:        make(chan struct{}),
+	}
+}
+
+// Start 恢复数据库中进行中的游戏，在房间加载后、开始接受连接前调用
+func (gr *GameRecovery) Start() {
+	recovered, abandoned, nextHands := 0, 0, 0
+	for _, room := range gr.roomService.Snapshots() {
+		game := room.CurrentGame
+		if game == nil {
+			continue
+		}
+
+		if !room.IsGameActive() {
+			// 多局对战在两局之间停机
+			if room.Session.IsActive() && room.Session.NextHandAt != nil {
+				gr.touch(room.ID)
+				gr.gameService.scheduleNextHand(room.ID, game.ID)
+				nextHands++
+			}
+			continue
+		}
+		if game.Status == models.GameStatusWaiting {
+			continue
+		}
+
+		if gr.timeout <= 0 {
+			gr.abandon(room.ID, game.ID, "服务器重启，进行中的游戏已中止")
+			abandoned++
+			continue
+		}
+		if err := game.CheckConsistency(); err != nil {
+			logger.Warn("房间 %s 的游戏 %s 数据不一致: %v", room.ID, game.ID, err)
+			gr.abandon(room.ID, game.ID, "服务器重启后游戏数据不一致，已中止")
+			abandoned++
+			continue
+		}
+		if err := gr.gameService.StartAIControllers(room.ID); err != nil {
+			logger.Error("恢复房间 %s 的AI控制器失败: %v", room.ID, err)
+			gr.abandon(room.ID, game.ID, "服务器重启后无法恢复AI玩家，已中止")
+			abandoned++
+			continue
+		}
+		gr.touch(room.ID)
+		if player := game.GetPlayer(game.CurrentTurn); player.IsAI {
+			gr.gameService.NotifyAITurn(room.ID, player.Position)
+		}
+
+		var humans []string
+		for _, player := range game.Players {
+			if !player.IsAI {
+				humans = append(humans, player.UserName)
+			}
+		}
+		if len(humans) > 0 {
+			gr.mutex.Lock()
+			gr.pending[room.ID] = &recoveredGame{gameID: game.ID, humans: humans}
+			gr.mutex.Unlock()
+		}
+		recovered++
+	}
+
+	gr.presence.OnChange(gr.onPresenceChange)
+	gr.mutex.Lock()
+	waiting := len(gr.pending)
+	gr.mutex.Unlock()
+	if waiting > 0 {
+		go gr.expire()
+	}
+
+	if recovered+abandoned+nextHands > 0 {
+		logger.Info("恢复进行中的游戏: 恢复=%d, 中止=%d, 待开始的下一局=%d, 等待玩家重新上线=%v",
+			recovered, abandoned, nextHands, gr.timeout)
+	}
+}
+
+// Stop 停止等待玩家重新上线
+func (gr *GameRecovery) Stop() {
+	gr.stopOnce.Do(func() { close(gr.done) })
+}
+
+// onPresenceChange 玩家上线时，如果所在房间有进行中的游戏，通知其回到游戏
+func (gr *GameRecovery) onPresenceChange(username string, online bool) {
+	if !online {
+		return
+	}
+
+	roomID, found := gr.roomService.FindPlayerRoom(username)
+	if !found {
+		return
+	}
+	room, err := gr.roomService.GetRoom(roomID)
+	if err != nil || !room.IsGameActive() || room.CurrentGame.Status == models.GameStatusWaiting {
+		return
+	}
+
+	gr.mutex.Lock()
+	if recovered, ok := gr.pending[roomID]; ok && recovered.gameID == room.CurrentGame.ID {
+		recovered.reconnected = true
+	}
+	gr.mutex.Unlock()
+
+	gr.presence.Push(username, protocol.RouteGameResumed, protocol.GameResumedData{
+		RoomID:   room.ID,
+		RoomName: room.Name,
+		GameID:   room.CurrentGame.ID,
+	})
+}
+
+// expire 恢复时间到后中止没有真人玩家重新上线的游戏
+func (gr *GameRecovery) expire() {
+	timer := time.NewTimer(gr.timeout)
+	defer timer.Stop()
+
+	select {
+	case <-gr.done:
+		return
+	case <-timer.C:
+	}
+
+	gr.mutex.Lock()
+	pending := gr.pending
+	gr.pending = make(map[string]*recoveredGame)
+	gr.mutex.Unlock()
+
+	for roomID, recovered := range pending {
+		if recovered.reconnected {
+			continue
+		}
+		for _, name := range recovered.humans {
+			if gr.presence.IsOnline(name) {
+				recovered.reconnected = true
+				break
+			}
+		}
+		if !recovered.reconnected {
+			logger.Info("房间 %s 的玩家 %v 未在恢复时间内重新上线", roomID, recovered.humans)
+			gr.abandon(roomID, recovered.gameID, "玩家未在服务器重启后重新上线，游戏已中止")
+		}
+	}
+}
+
+// touch 更新恢复的房间的最后操作时间
+// 停机时间可能超过房间回收时长，不更新的话恢复的游戏会在启动时立即被回收
+func (gr *GameRecovery) touch(roomID string) {
+	if err := gr.roomService.Dispatch(roomID, "recover", func(*models.Room) error { return nil }); err != nil {
+		logger.Error("更新房间 %s 的操作时间失败: %v", roomID, err)
+	}
+}
+
+// abandon 中止恢复失败的游戏并结束多局对战，游戏已结束时忽略
+func (gr *GameRecovery) abandon(roomID, gameID, reason string) {
+	if _, err := gr.gameService.abandonGame(roomID, gameID, "recovery_abandon", reason, true); err != nil {
+		if err.Error() != "游戏已结束" {
+			logger.Error("中止房间 %s 的游戏 %s 失败: %v", roomID, gameID, err)
+		}
+		return
+	}
+	logger.Info("房间 %s 的游戏 %s 已中止: %s", roomID, gameID, reason)
+}
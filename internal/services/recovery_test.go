@@ -0,0 +1,39 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"aigames/internal/config"
+)
+
+func TestRecoveredGameSurvivesFirstSweep(t *testing.T) {
+	env := newTestEnv(t)
+	rs := env.rooms
+	room := startTestGame(t, rs, "alice")
+	// 停机时间超过房间回收时长
+	backdateRoom(t, rs, room.ID, 2*time.Hour)
+
+	cfg := config.GameConfig{RoomIdleTTL: 3600, FinishedRoomTTL: 3600, ReaperInterval: 60, RecoveryTimeout: 60}
+	recovery := NewGameRecovery(rs, env.games, env.presence, cfg)
+	recovery.Start()
+	t.Cleanup(recovery.Stop)
+
+	reaper := NewRoomReaper(rs, env.games, env.presence, cfg)
+	if closed := reaper.Sweep(); closed != 0 {
+		t.Fatalf("恢复后立即回收了 %d 个房间", closed)
+	}
+	got, err := rs.GetRoom(room.ID)
+	if err != nil {
+		t.Fatal("恢复的游戏被回收")
+	}
+	if !got.IsGameActive() {
+		t.Fatal("恢复的游戏已不在进行中")
+	}
+	recovery.mutex.Lock()
+	_, pending := recovery.pending[room.ID]
+	recovery.mutex.Unlock()
+	if !pending {
+		t.Fatal("恢复的游戏没有等待玩家重新上线")
+	}
+}
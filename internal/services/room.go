@@ -1,28 +1,106 @@
 package services
 
 import (
-	"encoding/json"
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"aigames/internal/config"
 	"aigames/internal/models"
+	"aigames/internal/repository"
+	"aigames/pkg/logger"
 
-	"go.etcd.io/bbolt"
+	"github.com/google/uuid"
+)
+
+// 房间操作错误
+var (
+	ErrRoomNotFound        = errors.New("房间不存在")
+	ErrRoomFull            = errors.New("房间已满")
+	ErrRoomPassword        = errors.New("房间密码错误")
+	ErrPlayerNotInRoom     = errors.New("玩家不在房间中")
+	ErrKickBanned          = errors.New("已被房主踢出，请稍后再加入")
+	ErrInsufficientBalance = errors.New("金币不足")
+	ErrInvitationNotFound  = errors.New("邀请不存在")
+	ErrInvitationExpired   = errors.New("邀请已过期")
+	ErrInvitationWithdrawn = errors.New("邀请已失效")
+	ErrServerDraining      = errors.New("服务器即将维护")
 )
 
 // RoomService 房间服务
+// 每个房间由一个actor持有，所有修改都以消息的形式在actor中串行执行
 type RoomService struct {
-	db    *bbolt.DB
-	rooms map[string]*models.Room // 内存中的房间缓存
-	mutex sync.RWMutex            // 读写锁
+	store   repository.Store
+	rooms   repository.RoomRepository
+	actors  map[string]*roomActor // 内存中的房间actor
+	aiNames map[string]bool       // 所有房间中正在使用的AI名称
+	codes   map[string]string     // 房间码 -> 房间ID
+	mutex   sync.RWMutex          // 保护actors、aiNames和codes
+
+	invitations map[string]*models.Invitation // 未使用的房间邀请，只保存在内存中
+	inviteTTL   time.Duration                 // 邀请有效期
+	inviteMutex sync.Mutex                    // 保护invitations
+
+	kickBans    map[string]time.Time // 被踢出玩家的禁止加入期限 key: 房间ID+"\x00"+用户名
+	kickBanTTL  time.Duration        // 被踢出后禁止重新加入的时长
+	kickBanLock sync.Mutex           // 保护kickBans
+
+	nextHandDelay time.Duration // 多局对战中下一局自动开始前的倒计时
+
+	defaultStake     int64                       // 默认底注
+	minBalanceFactor int64                       // 默认入场金币为底注的倍数
+	balanceOf        func(username string) int64 // 查询玩家金币，未设置时不检查入场金币
+
+	areFriends func(a, b string) bool // 查询好友关系，未设置时凭邀请加入不检查好友关系
+
+	draining atomic.Bool // 停机排空中，不再创建房间和开始新的游戏
 }
 
 // NewRoomService 创建房间服务实例
-func NewRoomService(db *bbolt.DB) *RoomService {
+func NewRoomService(store repository.Store, cfg config.GameConfig) *RoomService {
+	inviteTTL := time.Duration(cfg.InvitationTTL) * time.Second
+	if inviteTTL <= 0 {
+		inviteTTL = 5 * time.Minute
+	}
+
+	kickBanTTL := time.Duration(cfg.KickBanDuration) * time.Second
+	if kickBanTTL <= 0 {
+		kickBanTTL = 2 * time.Minute
+	}
+
+	nextHandDelay := time.Duration(cfg.NextHandDelay) * time.Second
+	if nextHandDelay < time.Second {
+		nextHandDelay = time.Second
+	}
+
+	defaultStake := int64(cfg.DefaultBaseStake)
+	if defaultStake < 0 {
+		defaultStake = 0
+	}
+	minBalanceFactor := int64(cfg.MinBalanceFactor)
+	if minBalanceFactor < 1 {
+		minBalanceFactor = 1
+	}
+
 	service := &RoomService{
-		db:    db,
-		rooms: make(map[string]*models.Room),
+		store:       store,
+		rooms:       store.Rooms(),
+		actors:      make(map[string]*roomActor),
+		aiNames:     make(map[string]bool),
+		codes:       make(map[string]string),
+		invitations: make(map[string]*models.Invitation),
+		inviteTTL:   inviteTTL,
+		kickBans:    make(map[string]time.Time),
+		kickBanTTL:  kickBanTTL,
+
+		nextHandDelay: nextHandDelay,
+
+		defaultStake:     defaultStake,
+		minBalanceFactor: minBalanceFactor,
 	}
 	// 加载已存在的房间
 	service.loadRoomsFromDB()
@@ -31,36 +109,120 @@ func NewRoomService(db *bbolt.DB) *RoomService {
 
 // loadRoomsFromDB 从数据库加载房间
 func (rs *RoomService) loadRoomsFromDB() {
-	rs.db.View(func(tx *bbolt.Tx) error {
-		b := tx.Bucket([]byte("rooms"))
-		if b == nil {
+	rooms, err := rs.rooms.List()
+	if err != nil {
+		logger.Error("加载房间失败: %v", err)
+	}
+
+	var missingCode []*models.Room
+	for _, room := range rooms {
+		rs.reserveAINames(room)
+		if room.Code == "" || rs.codes[room.Code] != "" {
+			missingCode = append(missingCode, room)
+		} else {
+			rs.codes[room.Code] = room.ID
+		}
+		rs.actors[room.ID] = newRoomActor(room)
+	}
+
+	// 旧房间没有房间码，加载后补充分配
+	for _, room := range missingCode {
+		code := rs.newRoomCode(room.ID)
+		if err := rs.Dispatch(room.ID, "assign_code", func(room *models.Room) error {
+			room.Code = code
 			return nil
+		}); err != nil {
+			logger.Error("为房间 %s 分配房间码失败: %v", room.ID, err)
 		}
+	}
+}
 
-		c := b.Cursor()
-		for k, v := c.First(); k != nil; k, v = c.Next() {
-			var room models.Room
-			if err := json.Unmarshal(v, &room); err == nil {
-				rs.rooms[room.ID] = &room
-			}
+// reserveAINames 记录房间中的AI名称，调用方需持有锁
+func (rs *RoomService) reserveAINames(room *models.Room) {
+	for _, name := range aiNamesOf(room) {
+		rs.aiNames[name] = true
+	}
+}
+
+// aiNamesOf 获取房间中的AI玩家名称
+func aiNamesOf(room *models.Room) []string {
+	var names []string
+	if room.CurrentGame == nil {
+		return names
+	}
+	for _, player := range room.CurrentGame.Players {
+		if player != nil && player.IsAI {
+			names = append(names, player.UserName)
 		}
+	}
+	return names
+}
+
+// getActor 获取房间actor
+func (rs *RoomService) getActor(id string) (*roomActor, error) {
+	rs.mutex.RLock()
+	defer rs.mutex.RUnlock()
+
+	actor, exists := rs.actors[id]
+	if !exists {
+		return nil, ErrRoomNotFound
+	}
+	return actor, nil
+}
+
+// listActors 获取所有房间actor
+func (rs *RoomService) listActors() []*roomActor {
+	rs.mutex.RLock()
+	defer rs.mutex.RUnlock()
+
+	actors := make([]*roomActor, 0, len(rs.actors))
+	for _, actor := range rs.actors {
+		actors = append(actors, actor)
+	}
+	return actors
+}
+
+// Dispatch 在房间actor中执行操作，操作成功并保存后才生效
+// 操作函数作用于房间的副本，返回错误或保存失败时丢弃其所有修改
+// 操作函数只能修改传入的房间，不能调用RoomService的其他方法
+func (rs *RoomService) Dispatch(roomID, action string, fn func(room *models.Room) error) error {
+	actor, err := rs.getActor(roomID)
+	if err != nil {
+		return err
+	}
+
+	return actor.send(action, func(room *models.Room) error {
+		draft := room.Clone()
+		if err := fn(draft); err != nil {
+			return err
+		}
+		draft.UpdatedAt = time.Now()
+		if err := rs.saveRoomToDB(draft); err != nil {
+			return err
+		}
+		*room = *draft
 		return nil
 	})
 }
 
-// CreateRoom 创建房间
-func (rs *RoomService) CreateRoom(id, name, owner string, roomType models.RoomType, password string, aiCount int) (*models.Room, error) {
+// CreateRoom 创建房间，房间ID和房间码由服务生成
+// hands 为每次对战的局数，0为单局，models.HandsUnlimited为不限局数
+// baseStake 为底注，0为默认底注，models.NoStake为不结算金币；minBalance 为0时按底注的倍数计算
+func (rs *RoomService) CreateRoom(name, owner string, roomType models.RoomType, password string,
+	aiCount, hands int, baseStake, minBalance int64) (*models.Room, error) {
+	if rs.draining.Load() {
+		return nil, fmt.Errorf("%w，暂停创建房间", ErrServerDraining)
+	}
+
 	rs.mutex.Lock()
 	defer rs.mutex.Unlock()
 
-	// 检查房间ID是否已存在
-	if _, exists := rs.rooms[id]; exists {
-		return nil, fmt.Errorf("房间ID已存在")
-	}
-
 	// 创建新房间
+	id := newRoomID()
 	room := models.NewRoom(id, name, owner, roomType, password)
-	rs.rooms[id] = room
+	room.Code = rs.newRoomCode(id)
+	room.Hands = hands
+	room.BaseStake, room.MinBalance = rs.resolveStake(baseStake, minBalance)
 
 	// 如果指定了AI玩家数量，自动创建AI玩家
 	if aiCount > 0 {
@@ -70,7 +232,7 @@ func (rs *RoomService) CreateRoom(id, name, owner string, roomType models.RoomTy
 
 		// 创建AI玩家
 		for i := 0; i < aiCount && i < 2; i++ { // 最多2个AI玩家
-			aiName := fmt.Sprintf("AI-%d", i+1)
+			aiName := rs.newAIName()
 
 			// 找到空位置加入AI玩家
 			for pos := models.Position1; pos <= models.Position3; pos++ {
@@ -96,50 +258,137 @@ func (rs *RoomService) CreateRoom(id, name, owner string, roomType models.RoomTy
 
 	// 保存到数据库
 	if err := rs.saveRoomToDB(room); err != nil {
-		delete(rs.rooms, id) // 如果保存失败，从内存中删除
+		delete(rs.codes, room.Code)
 		return nil, fmt.Errorf("保存房间失败: %w", err)
 	}
 
-	return room, nil
+	rs.reserveAINames(room)
+	snapshot := room.Clone()
+	rs.actors[id] = newRoomActor(room)
+
+	return snapshot, nil
 }
 
-// GetRoom 获取房间
-func (rs *RoomService) GetRoom(id string) (*models.Room, error) {
-	rs.mutex.RLock()
-	defer rs.mutex.RUnlock()
+// resolveStake 计算房间的底注和入场金币
+func (rs *RoomService) resolveStake(baseStake, minBalance int64) (int64, int64) {
+	switch {
+	case baseStake == models.NoStake:
+		return 0, 0
+	case baseStake <= 0:
+		baseStake = rs.defaultStake
+	}
+	if minBalance <= 0 {
+		minBalance = baseStake * rs.minBalanceFactor
+	}
+	return baseStake, minBalance
+}
 
-	room, exists := rs.rooms[id]
-	if !exists {
-		return nil, fmt.Errorf("房间不存在")
+// SetBalanceSource 设置查询玩家金币的方法，用于检查入场金币
+func (rs *RoomService) SetBalanceSource(balanceOf func(username string) int64) {
+	rs.balanceOf = balanceOf
+}
+
+// SetFriendCheck 设置查询好友关系的方法，凭邀请加入时确认双方仍是好友
+func (rs *RoomService) SetFriendCheck(areFriends func(a, b string) bool) {
+	rs.areFriends = areFriends
+}
+
+// checkBalance 检查玩家金币是否达到房间的入场金币
+func (rs *RoomService) checkBalance(room *models.Room, username string) error {
+	if rs.balanceOf == nil || room.MinBalance <= 0 || models.IsAIName(username) {
+		return nil
+	}
+	if rs.balanceOf(username) < room.MinBalance {
+		return fmt.Errorf("%w，该房间至少需要 %d 金币", ErrInsufficientBalance, room.MinBalance)
 	}
+	return nil
+}
 
-	return room, nil
+// roomCodeAlphabet 房间码字符集，去掉了容易混淆的 0/O、1/I/L
+const roomCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// roomCodeLength 房间码长度
+const roomCodeLength = 6
+
+// newRoomID 生成房间ID
+func newRoomID() string {
+	return "room_" + uuid.New().String()
 }
 
-// GetAllRooms 获取所有房间列表
-func (rs *RoomService) GetAllRooms() []*models.Room {
-	rs.mutex.RLock()
-	defer rs.mutex.RUnlock()
+// newRoomCode 生成在所有房间中唯一的房间码并登记，调用方需持有锁
+func (rs *RoomService) newRoomCode(roomID string) string {
+	for {
+		code := randomRoomCode()
+		if _, exists := rs.codes[code]; !exists {
+			rs.codes[code] = roomID
+			return code
+		}
+	}
+}
 
-	rooms := make([]*models.Room, 0, len(rs.rooms))
-	for _, room := range rs.rooms {
-		// 返回安全的房间信息
-		rooms = append(rooms, room.GetSafeRoom())
+// randomRoomCode 随机生成房间码，丢弃超出字符集整数倍的字节以保证均匀分布
+func randomRoomCode() string {
+	limit := byte(256 - 256%len(roomCodeAlphabet))
+	code := make([]byte, 0, roomCodeLength)
+	buf := make([]byte, roomCodeLength)
+	for len(code) < roomCodeLength {
+		rand.Read(buf)
+		for _, b := range buf {
+			if b < limit && len(code) < roomCodeLength {
+				code = append(code, roomCodeAlphabet[int(b)%len(roomCodeAlphabet)])
+			}
+		}
 	}
+	return string(code)
+}
 
-	return rooms
+// NormalizeRoomCode 规范化用户输入的房间码（去掉空白、转为大写）
+func NormalizeRoomCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
 }
 
-// GetPublicRooms 获取公开房间列表
-func (rs *RoomService) GetPublicRooms() []*models.Room {
+// ResolveCode 根据房间码查找房间ID
+func (rs *RoomService) ResolveCode(code string) (string, error) {
 	rs.mutex.RLock()
 	defer rs.mutex.RUnlock()
 
+	roomID, exists := rs.codes[NormalizeRoomCode(code)]
+	if !exists {
+		return "", ErrRoomNotFound
+	}
+	return roomID, nil
+}
+
+// newAIName 生成在所有房间中唯一的AI玩家名称，调用方需持有锁
+func (rs *RoomService) newAIName() string {
+	for {
+		name := models.AINamePrefix + strings.ToUpper(strings.ReplaceAll(uuid.New().String(), "-", "")[:6])
+		if !rs.aiNames[name] {
+			rs.aiNames[name] = true
+			return name
+		}
+	}
+}
+
+// GetRoom 获取房间快照，修改快照不会影响房间
+func (rs *RoomService) GetRoom(id string) (*models.Room, error) {
+	actor, err := rs.getActor(id)
+	if err != nil {
+		return nil, err
+	}
+	return actor.snapshot()
+}
+
+// GetAllRooms 获取所有房间列表
+func (rs *RoomService) GetAllRooms() []*models.Room {
 	rooms := make([]*models.Room, 0)
-	for _, room := range rs.rooms {
-		if room.Type == models.RoomTypePublic {
-			rooms = append(rooms, room.GetSafeRoom())
+	for _, actor := range rs.listActors() {
+		room, err := actor.snapshot()
+		if err != nil {
+			continue // 房间已被删除
 		}
+		// 返回安全的房间信息
+		rooms = append(rooms, room.GetSafeRoom())
 	}
 
 	return rooms
@@ -147,247 +396,597 @@ func (rs *RoomService) GetPublicRooms() []*models.Room {
 
 // JoinRoom 加入房间
 func (rs *RoomService) JoinRoom(roomID, username, password string) (*models.Room, error) {
-	rs.mutex.Lock()
-	defer rs.mutex.Unlock()
+	return rs.joinRoom(roomID, username, password, false)
+}
 
-	room, exists := rs.rooms[roomID]
-	if !exists {
-		return nil, fmt.Errorf("房间不存在")
+// joinRoom 加入房间，invited为true时凭邀请加入，不检查密码
+func (rs *RoomService) joinRoom(roomID, username, password string, invited bool) (*models.Room, error) {
+	if rs.isKickBanned(roomID, username) {
+		return nil, ErrKickBanned
 	}
 
-	// 检查是否可以加入
-	if !room.CanJoin(password) {
-		if room.IsFull() {
-			return nil, fmt.Errorf("房间已满")
+	var snapshot *models.Room
+	err := rs.Dispatch(roomID, "join", func(room *models.Room) error {
+		if !room.HasPlayer(username) {
+			if err := rs.checkBalance(room, username); err != nil {
+				return err
+			}
 		}
-		return nil, fmt.Errorf("房间密码错误")
-	}
 
-	// 检查玩家是否已在房间中
-	if room.HasPlayer(username) {
-		return room, nil // 已经在房间中
-	}
+		// 检查是否可以加入
+		if invited {
+			if room.IsFull() && !room.HasPlayer(username) {
+				return ErrRoomFull
+			}
+		} else if !room.CanJoin(password) {
+			if room.IsFull() {
+				return ErrRoomFull
+			}
+			return ErrRoomPassword
+		}
 
-	// 如果房间没有活跃游戏，创建新游戏
-	if !room.IsGameActive() {
-		room.StartGame()
-	}
+		// 检查玩家是否已在房间中
+		if room.HasPlayer(username) {
+			snapshot = room.Clone()
+			return nil // 已经在房间中
+		}
 
-	// 找到空位置加入游戏
-	game := room.CurrentGame
-	var joinedPosition models.PlayerPosition = -1
+		// 如果房间没有活跃游戏，创建新游戏
+		if !room.IsGameActive() {
+			room.StartGame()
+		}
+
+		// 找到空位置加入游戏
+		game := room.CurrentGame
+		var joinedPosition models.PlayerPosition = -1
 
-	for i := models.Position1; i <= models.Position3; i++ {
-		if game.GetPlayer(i) == nil {
-			if game.AddPlayer(username, i) {
-				joinedPosition = i
-				break
+		for i := models.Position1; i <= models.Position3; i++ {
+			if game.GetPlayer(i) == nil {
+				if game.AddPlayer(username, i) {
+					joinedPosition = i
+					break
+				}
 			}
 		}
-	}
 
-	if joinedPosition == -1 {
-		return nil, fmt.Errorf("无法加入游戏")
-	}
+		if joinedPosition == -1 {
+			return fmt.Errorf("无法加入游戏")
+		}
 
-	// 更新房间状态
-	if room.GetPlayerCount() > 0 {
-		room.Status = models.RoomStatusWaiting
+		// 更新房间状态
+		if room.GetPlayerCount() > 0 {
+			room.Status = models.RoomStatusWaiting
+		}
+
+		snapshot = room.Clone()
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	room.UpdatedAt = time.Now()
+	return snapshot, nil
+}
 
-	// 保存到数据库
-	if err := rs.saveRoomToDB(room); err != nil {
-		return nil, fmt.Errorf("保存房间状态失败: %w", err)
+// CreateInvitation 创建房间邀请，只有房间中的玩家或房主可以邀请
+func (rs *RoomService) CreateInvitation(roomID, from, to string) (*models.Invitation, error) {
+	room, err := rs.GetRoom(roomID)
+	if err != nil {
+		return nil, err
+	}
+	if room.Owner != from && !room.HasPlayer(from) {
+		return nil, ErrPlayerNotInRoom
+	}
+	if room.HasPlayer(to) {
+		return nil, fmt.Errorf("对方已在房间中")
+	}
+	if room.IsFull() {
+		return nil, ErrRoomFull
 	}
 
-	return room, nil
+	now := time.Now()
+	invitation := &models.Invitation{
+		ID:        uuid.New().String(),
+		RoomID:    room.ID,
+		RoomName:  room.Name,
+		From:      from,
+		To:        to,
+		CreatedAt: now,
+		ExpiresAt: now.Add(rs.inviteTTL),
+	}
+
+	rs.inviteMutex.Lock()
+	defer rs.inviteMutex.Unlock()
+
+	// 顺便清理已过期的邀请
+	for id, existing := range rs.invitations {
+		if existing.IsExpired(now) {
+			delete(rs.invitations, id)
+		}
+	}
+	rs.invitations[invitation.ID] = invitation
+
+	copied := *invitation
+	return &copied, nil
 }
 
-// LeaveRoom 离开房间
-func (rs *RoomService) LeaveRoom(roomID, username string) error {
-	rs.mutex.Lock()
-	defer rs.mutex.Unlock()
+// JoinRoomByInvitation 凭邀请加入房间，无需密码，邀请使用后失效
+func (rs *RoomService) JoinRoomByInvitation(roomID, inviteID, username string) (*models.Room, error) {
+	rs.inviteMutex.Lock()
+	invitation, exists := rs.invitations[inviteID]
+	if !exists || invitation.To != username || invitation.RoomID != roomID {
+		rs.inviteMutex.Unlock()
+		return nil, ErrInvitationNotFound
+	}
+	if invitation.IsExpired(time.Now()) {
+		delete(rs.invitations, inviteID)
+		rs.inviteMutex.Unlock()
+		return nil, ErrInvitationExpired
+	}
+	rs.inviteMutex.Unlock()
 
-	room, exists := rs.rooms[roomID]
-	if !exists {
-		return fmt.Errorf("房间不存在")
+	// 邀请发出后任何一方删除好友或屏蔽对方，邀请随之失效
+	if rs.areFriends != nil && (!rs.areFriends(invitation.From, username) || !rs.areFriends(username, invitation.From)) {
+		rs.inviteMutex.Lock()
+		delete(rs.invitations, inviteID)
+		rs.inviteMutex.Unlock()
+		return nil, ErrInvitationWithdrawn
 	}
 
-	if !room.HasPlayer(username) {
-		return nil // 玩家不在房间中
+	room, err := rs.joinRoom(roomID, username, "", true)
+	if err != nil {
+		return nil, err
 	}
 
-	// 从游戏中移除玩家
-	if room.CurrentGame != nil {
-		if position, found := room.CurrentGame.GetPlayerPosition(username); found {
+	rs.inviteMutex.Lock()
+	delete(rs.invitations, inviteID)
+	rs.inviteMutex.Unlock()
+	return room, nil
+}
+
+// LeaveRoom 离开房间，房主离开时房主身份自动转给下一位真人玩家
+// 返回新房主的用户名，房主未变化时返回空字符串；单局私人房间的房主离开后没有其他真人玩家时关闭房间，closed 为 true
+func (rs *RoomService) LeaveRoom(roomID, username string) (newOwner string, closed bool, err error) {
+	closeRoom := false
+	err = rs.Dispatch(roomID, "leave", func(room *models.Room) error {
+		seated := room.HasPlayer(username)
+		if !seated && room.Owner != username {
+			return nil // 玩家不在房间中
+		}
+
+		// 房主没有入座时从1号位开始查找下一任房主
+		position := models.Position3
+		if seated {
+			// 多局对战中有玩家离开，对战结束
+			if room.Session.IsActive() {
+				room.Session.Finish("玩家离开")
+			}
+
+			// 从游戏中移除玩家
+			position, _ = room.CurrentGame.GetPlayerPosition(username)
 			room.CurrentGame.RemovePlayer(position)
 		}
-	}
 
-	// 更新房间状态
-	playerCount := room.GetPlayerCount()
-	if playerCount == 0 {
-		room.Status = models.RoomStatusIdle
-		// 如果游戏已经结束，可以清除当前游戏
-		if room.CurrentGame != nil &&
-			(room.CurrentGame.Status == models.GameStatusFinished ||
-				room.CurrentGame.Status == models.GameStatusAbandoned) {
-			room.CurrentGame = nil
+		// 房主离开，按座位顺序转给下一位真人玩家
+		if room.Owner == username && room.CurrentGame != nil {
+			if next := nextHumanPlayer(room.CurrentGame, position); next != "" {
+				room.Owner = next
+				newOwner = next
+			}
 		}
-	} else {
-		room.Status = models.RoomStatusWaiting
-	}
 
-	room.UpdatedAt = time.Now()
+		// 更新房间状态
+		if room.GetPlayerCount() == 0 {
+			room.Status = models.RoomStatusIdle
+			// 如果游戏已经结束，可以清除当前游戏
+			if room.CurrentGame != nil &&
+				(room.CurrentGame.Status == models.GameStatusFinished ||
+					room.CurrentGame.Status == models.GameStatusAbandoned) {
+				room.CurrentGame = nil
+			}
+		} else {
+			room.Status = models.RoomStatusWaiting
+		}
 
-	// 如果房间是私人房间且房主离开且没有其他玩家，删除房间
-	if room.Type == models.RoomTypePrivate && room.Owner == username && playerCount == 0 {
-		return rs.DeleteRoom(roomID)
+		// 私人房间的房主离开且没有转给其他人，说明只剩下AI玩家或空位，关闭房间
+		// 多局对战的房间保留到回收，比赛从房间中收集对战结果
+		closeRoom = room.Type == models.RoomTypePrivate && room.Owner == username && room.Session == nil
+		return nil
+	})
+	if err != nil {
+		return "", false, err
 	}
 
-	// 保存到数据库
-	return rs.saveRoomToDB(room)
+	if closeRoom {
+		// 离开之后可能已有玩家加入，关闭前重新确认
+		_, err := rs.CloseRoomIf(roomID, func(room *models.Room) error {
+			if room.Owner != username || hasHumanPlayer(room) {
+				return errRoomActive
+			}
+			return nil
+		})
+		if err == nil {
+			logger.Info("私人房间 %s 的房主 %s 离开，房间已关闭", roomID, username)
+			return "", true, nil
+		}
+		if !errors.Is(err, errRoomActive) {
+			return "", false, err
+		}
+	}
+	if newOwner != "" {
+		logger.Info("房主 %s 离开房间 %s，房主转给 %s", username, roomID, newOwner)
+	}
+	return newOwner, false, nil
 }
 
 // DeleteRoom 删除房间
 func (rs *RoomService) DeleteRoom(id string) error {
-	rs.mutex.Lock()
-	defer rs.mutex.Unlock()
+	_, err := rs.detachRoom(id, "delete", func(room *models.Room) error {
+		// 结束当前游戏
+		if room.IsGameActive() {
+			room.EndGame()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-	room, exists := rs.rooms[id]
-	if !exists {
-		return fmt.Errorf("房间不存在")
+	// 从数据库中删除
+	return rs.rooms.Delete(id)
+}
+
+// CloseRoom 关闭房间：未结束的游戏标记为中止，已开始过的最后一局归档到游戏记录，
+// 然后在同一个事务中删除房间。返回关闭前的房间快照
+func (rs *RoomService) CloseRoom(id string) (*models.Room, error) {
+	return rs.CloseRoomIf(id, nil)
+}
+
+// CloseRoomIf 在房间actor中执行 check，通过后再关闭房间；check 返回错误时房间保持不变并返回该错误
+// check 与关闭之间不会有其他操作修改房间，用于关闭前重新确认关闭条件
+func (rs *RoomService) CloseRoomIf(id string, check func(room *models.Room) error) (*models.Room, error) {
+	room, err := rs.detachRoom(id, "close", func(room *models.Room) error {
+		if check != nil {
+			if err := check(room); err != nil {
+				return err
+			}
+		}
+		if room.IsGameActive() {
+			now := time.Now()
+			room.CurrentGame.Status = models.GameStatusAbandoned
+			room.CurrentGame.FinishedAt = &now
+		}
+		room.Status = models.RoomStatusIdle
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// 结束当前游戏
-	if room.IsGameActive() {
-		room.EndGame()
+	err = rs.store.Update(func(tx repository.Tx) error {
+		if game := room.CurrentGame; game != nil && game.StartedAt != nil {
+			if err := tx.SaveGame(game); err != nil {
+				return fmt.Errorf("归档游戏失败: %w", err)
+			}
+		}
+		return tx.DeleteRoom(id)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return room, nil
+}
 
-	// 从内存中删除
-	delete(rs.rooms, id)
+// detachRoom 将房间从内存中移除：先执行最后的操作，再停止actor并释放AI名称和房间码
+// fn 返回错误时房间保持不变；成功后actor拒绝之后的所有消息。返回房间的最终快照，调用方负责从数据库中删除
+func (rs *RoomService) detachRoom(id, action string, fn func(room *models.Room) error) (*models.Room, error) {
+	actor, err := rs.getActor(id)
+	if err != nil {
+		return nil, err
+	}
 
-	// 从数据库中删除
-	return rs.db.Update(func(tx *bbolt.Tx) error {
-		b := tx.Bucket([]byte("rooms"))
-		if b == nil {
-			return nil
+	var final *models.Room
+	err = actor.send(action, func(room *models.Room) error {
+		if err := fn(room); err != nil {
+			return err
 		}
-		return b.Delete([]byte(id))
+		final = room.Clone()
+		actor.detached = true
+		return nil
 	})
-}
+	if err != nil {
+		return nil, err
+	}
 
-// UpdateRoom 更新房间
-func (rs *RoomService) UpdateRoom(room *models.Room) error {
 	rs.mutex.Lock()
-	defer rs.mutex.Unlock()
+	if rs.actors[id] == actor {
+		delete(rs.actors, id)
+	}
+	rs.mutex.Unlock()
+	actor.stop()
+
+	rs.mutex.Lock()
+	for _, name := range aiNamesOf(final) {
+		delete(rs.aiNames, name)
+	}
+	if final.Code != "" && rs.codes[final.Code] == id {
+		delete(rs.codes, final.Code)
+	}
+	rs.mutex.Unlock()
 
-	if _, exists := rs.rooms[room.ID]; !exists {
-		return fmt.Errorf("房间不存在")
+	return final, nil
+}
+
+// Snapshots 获取所有房间的完整快照，包含密码和游戏详情，不能直接返回给玩家
+func (rs *RoomService) Snapshots() []*models.Room {
+	rooms := make([]*models.Room, 0)
+	for _, actor := range rs.listActors() {
+		room, err := actor.snapshot()
+		if err != nil {
+			continue // 房间已被删除
+		}
+		rooms = append(rooms, room)
 	}
+	return rooms
+}
+
+// SetDraining 设置停机排空状态，排空时拒绝创建房间和开始新的游戏
+func (rs *RoomService) SetDraining(draining bool) {
+	rs.draining.Store(draining)
+}
 
-	room.UpdatedAt = time.Now()
-	rs.rooms[room.ID] = room
+// Draining 是否处于停机排空状态
+func (rs *RoomService) Draining() bool {
+	return rs.draining.Load()
+}
 
-	return rs.saveRoomToDB(room)
+// Flush 在一个事务中把内存中的所有房间写入数据库，用于停机前保存最终状态
+func (rs *RoomService) Flush() (int, error) {
+	rooms := rs.Snapshots()
+	err := rs.rooms.SaveAll(rooms)
+	if err != nil {
+		return 0, err
+	}
+	return len(rooms), nil
 }
 
 // saveRoomToDB 保存房间到数据库
 func (rs *RoomService) saveRoomToDB(room *models.Room) error {
-	return rs.db.Update(func(tx *bbolt.Tx) error {
-		b, err := tx.CreateBucketIfNotExists([]byte("rooms"))
-		if err != nil {
-			return err
+	return rs.rooms.Save(room)
+}
+
+// StartGame 开始游戏
+// 上一局已结束时按原座位开始新的一局；多局对战房间在没有进行中的对战时开始新的对战
+func (rs *RoomService) StartGame(roomID string) (*models.Game, error) {
+	if rs.draining.Load() {
+		return nil, fmt.Errorf("%w，暂停开始新的游戏", ErrServerDraining)
+	}
+
+	var game *models.Game
+	err := rs.Dispatch(roomID, "start_game", func(room *models.Room) error {
+		if room.CurrentGame == nil {
+			return fmt.Errorf("没有活跃的游戏")
 		}
 
-		encoded, err := json.Marshal(room)
-		if err != nil {
-			return fmt.Errorf("序列化房间失败: %w", err)
+		current := room.CurrentGame
+		if room.IsGameActive() && current.Status != models.GameStatusWaiting {
+			return fmt.Errorf("游戏已开始")
+		}
+		if !room.IsGameActive() && room.Session.IsActive() {
+			return fmt.Errorf("下一局即将自动开始")
+		}
+
+		// 检查是否所有玩家都准备
+		if !current.IsAllReady() {
+			return fmt.Errorf("不是所有玩家都准备")
+		}
+		for _, player := range current.Players {
+			if err := rs.checkBalance(room, player.UserName); err != nil {
+				return fmt.Errorf("玩家 %s %w", player.UserName, err)
+			}
+		}
+
+		if !room.IsGameActive() {
+			current = redeal(room, 0)
+		}
+
+		if room.Hands != 0 && !room.Session.IsActive() {
+			room.Session = models.NewRoomSession(room.Hands, current)
 		}
 
-		return b.Put([]byte(room.ID), encoded)
+		if err := dealHand(room, current); err != nil {
+			return err
+		}
+		game = current.Clone()
+		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return game, nil
 }
 
-// StartGame 开始游戏
-func (rs *RoomService) StartGame(roomID string) (*models.Game, error) {
-	rs.mutex.Lock()
-	defer rs.mutex.Unlock()
+// StartNextHand 多局对战中自动开始下一局，previousGameID 为触发倒计时的一局
+// 座位上的玩家与对战开始时不一致（有人离开或更换）时结束对战；返回nil表示没有开始新的一局
+func (rs *RoomService) StartNextHand(roomID, previousGameID string) (*models.Game, error) {
+	var game *models.Game
+	err := rs.Dispatch(roomID, "next_hand", func(room *models.Room) error {
+		session := room.Session
+		if !session.IsActive() || session.NextHandAt == nil ||
+			room.CurrentGame == nil || room.CurrentGame.ID != previousGameID {
+			return nil // 对战已结束或下一局已经开始
+		}
+		if rs.draining.Load() {
+			return nil // 停机排空中，下一局保持待开始状态，重启后继续
+		}
 
-	room, exists := rs.rooms[roomID]
-	if !exists {
-		return nil, fmt.Errorf("房间不存在")
+		for i, name := range session.Seats {
+			player := room.CurrentGame.Players[i]
+			if player == nil || player.UserName != name {
+				session.Finish("玩家离开")
+				return nil
+			}
+			if rs.checkBalance(room, name) != nil {
+				session.Finish("玩家金币不足")
+				return nil
+			}
+		}
+
+		current := redeal(room, session.HandsPlayed+1)
+		for _, player := range current.Players {
+			player.IsReady = true
+		}
+		session.NextHandAt = nil
+
+		if err := dealHand(room, current); err != nil {
+			return err
+		}
+		game = current.Clone()
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if room.CurrentGame == nil {
-		return nil, fmt.Errorf("没有活跃的游戏")
+	return game, nil
+}
+
+// EndSession 房主结束多局对战，正在进行的一局打完后结束
+func (rs *RoomService) EndSession(roomID, owner string) (*models.Room, error) {
+	var snapshot *models.Room
+	err := rs.Dispatch(roomID, "end_session", func(room *models.Room) error {
+		if room.Owner != owner {
+			return fmt.Errorf("只有房主可以操作")
+		}
+		if !room.Session.IsActive() {
+			return fmt.Errorf("没有进行中的对战")
+		}
+
+		if room.IsGameActive() && room.CurrentGame.Status != models.GameStatusWaiting {
+			room.Session.StopRequested = true
+		} else {
+			room.Session.Finish("房主结束对战")
+		}
+		snapshot = room.Clone()
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	game := room.CurrentGame
+	return snapshot, nil
+}
 
-	// 检查是否所有玩家都准备
-	if !game.IsAllReady() {
-		return nil, fmt.Errorf("不是所有玩家都准备")
+// redeal 按上一局的座位创建新的一局，hand 大于0时作为局数附加到游戏ID
+func redeal(room *models.Room, hand int) *models.Game {
+	previous := room.CurrentGame
+	game := room.StartGame()
+	if hand > 0 {
+		game.ID = fmt.Sprintf("%s_%d", game.ID, hand)
 	}
 
-	// 开始游戏
+	for i, player := range previous.Players {
+		if player == nil {
+			continue
+		}
+		position := models.PlayerPosition(i)
+		game.AddPlayer(player.UserName, position)
+		seat := game.GetPlayer(position)
+		seat.IsAI = player.IsAI
+		seat.IsReady = player.IsReady
+	}
+	return game
+}
+
+// dealHand 发牌并开始游戏，多局对战时由轮到的座位首先叫地主
+func dealHand(room *models.Room, game *models.Game) error {
 	game.Status = models.GameStatusReady
 	now := time.Now()
 	game.StartedAt = &now
+	if room.Session.IsActive() {
+		game.CurrentTurn = room.Session.FirstBidder
+	}
 
 	// 发牌
 	gameLogic := models.NewGameLogic(game)
 	if err := gameLogic.DealCards(); err != nil {
-		return nil, fmt.Errorf("发牌失败: %w", err)
+		return fmt.Errorf("发牌失败: %w", err)
 	}
 
 	room.Status = models.RoomStatusPlaying
-	room.UpdatedAt = time.Now()
-
-	// 保存到数据库
-	if err := rs.saveRoomToDB(room); err != nil {
-		return nil, fmt.Errorf("保存游戏状态失败: %w", err)
-	}
+	return nil
+}
 
-	return game, nil
+// NextHandDelay 多局对战中下一局自动开始前的倒计时
+func (rs *RoomService) NextHandDelay() time.Duration {
+	return rs.nextHandDelay
 }
 
 // SetPlayerReady 设置玩家准备状态
 func (rs *RoomService) SetPlayerReady(roomID, username string, ready bool) error {
-	rs.mutex.Lock()
-	defer rs.mutex.Unlock()
+	return rs.Dispatch(roomID, "ready", func(room *models.Room) error {
+		if room.CurrentGame == nil {
+			return fmt.Errorf("没有活跃的游戏")
+		}
 
-	room, exists := rs.rooms[roomID]
-	if !exists {
-		return fmt.Errorf("房间不存在")
-	}
+		player := room.CurrentGame.GetPlayerByName(username)
+		if player == nil {
+			return fmt.Errorf("玩家不在游戏中")
+		}
 
-	if room.CurrentGame == nil {
-		return fmt.Errorf("没有活跃的游戏")
-	}
+		player.IsReady = ready
+		return nil
+	})
+}
 
-	player := room.CurrentGame.GetPlayerByName(username)
-	if player == nil {
-		return fmt.Errorf("玩家不在游戏中")
+// FindPlayerRoom 查找玩家所在的房间
+func (rs *RoomService) FindPlayerRoom(username string) (string, bool) {
+	for _, actor := range rs.listActors() {
+		found := false
+		roomID := ""
+		actor.send("find_player", func(room *models.Room) error {
+			found = room.HasPlayer(username)
+			roomID = room.ID
+			return nil
+		})
+		if found {
+			return roomID, true
+		}
 	}
+	return "", false
+}
 
-	player.IsReady = ready
-	room.UpdatedAt = time.Now()
-
-	// 保存到数据库
-	return rs.saveRoomToDB(room)
+// RenameOwner 将指定用户拥有的房间转给新名称，用于注销账户后匿名化
+func (rs *RoomService) RenameOwner(oldName, newName string) {
+	for _, actor := range rs.listActors() {
+		var roomID string
+		actor.send("find_owner", func(room *models.Room) error {
+			if room.Owner == oldName {
+				roomID = room.ID
+			}
+			return nil
+		})
+		if roomID == "" {
+			continue
+		}
+		rs.Dispatch(roomID, "rename_owner", func(room *models.Room) error {
+			if room.Owner == oldName {
+				room.Owner = newName
+			}
+			return nil
+		})
+	}
 }
 
 // GetPlayerCount 获取在线玩家总数
 func (rs *RoomService) GetPlayerCount() int {
-	rs.mutex.RLock()
-	defer rs.mutex.RUnlock()
-
 	count := 0
-	for _, room := range rs.rooms {
-		count += room.GetPlayerCount()
+	for _, actor := range rs.listActors() {
+		actor.send("count_players", func(room *models.Room) error {
+			count += room.GetPlayerCount()
+			return nil
+		})
 	}
 
 	return count
@@ -398,5 +997,5 @@ func (rs *RoomService) GetRoomCount() int {
 	rs.mutex.RLock()
 	defer rs.mutex.RUnlock()
 
-	return len(rs.rooms)
+	return len(rs.actors)
 }
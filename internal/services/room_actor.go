@@ -0,0 +1,103 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+
+	"aigames/internal/models"
+	"aigames/pkg/logger"
+)
+
+// roomCommand 发送给房间actor的消息
+type roomCommand struct {
+	action string                        // 操作名称，用于日志
+	fn     func(room *models.Room) error // 在actor goroutine中执行的操作
+	done   chan error                    // 执行结果
+}
+
+// roomActor 房间actor
+// 房间及其当前游戏只在actor goroutine中读写，其他goroutine通过消息操作房间、通过快照读取房间
+type roomActor struct {
+	room     *models.Room
+	detached bool // 房间已从服务中移除，只在actor goroutine中读写
+	commands chan roomCommand
+	quit     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// newRoomActor 创建房间actor并启动其goroutine
+func newRoomActor(room *models.Room) *roomActor {
+	actor := &roomActor{
+		room:     room,
+		commands: make(chan roomCommand),
+		quit:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go actor.run()
+	return actor
+}
+
+// run actor主循环，逐条处理消息
+func (a *roomActor) run() {
+	defer close(a.stopped)
+
+	for {
+		select {
+		case cmd := <-a.commands:
+			cmd.done <- a.handle(cmd)
+		case <-a.quit:
+			return
+		}
+	}
+}
+
+// handle 执行一条消息，操作中的panic不会导致actor退出
+// 房间移除后仍在排队的消息不再执行
+func (a *roomActor) handle(cmd roomCommand) (err error) {
+	if a.detached {
+		return ErrRoomNotFound
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("房间 %s 执行 %s 时发生panic: %v", a.room.ID, cmd.action, r)
+			err = fmt.Errorf("房间操作失败")
+		}
+	}()
+	return cmd.fn(a.room)
+}
+
+// send 发送消息并等待执行完成
+// 操作函数中不能再向同一个actor发送消息，也不能获取RoomService的锁
+func (a *roomActor) send(action string, fn func(room *models.Room) error) error {
+	cmd := roomCommand{
+		action: action,
+		fn:     fn,
+		done:   make(chan error, 1),
+	}
+
+	select {
+	case a.commands <- cmd:
+	case <-a.stopped:
+		return ErrRoomNotFound
+	}
+
+	// 消息被接收后一定会执行完成
+	return <-cmd.done
+}
+
+// snapshot 获取房间的深拷贝
+func (a *roomActor) snapshot() (*models.Room, error) {
+	var room *models.Room
+	err := a.send("snapshot", func(r *models.Room) error {
+		room = r.Clone()
+		return nil
+	})
+	return room, err
+}
+
+// stop 停止actor，已接收的消息会执行完成
+func (a *roomActor) stop() {
+	a.stopOnce.Do(func() { close(a.quit) })
+	<-a.stopped
+}
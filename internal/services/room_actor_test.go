@@ -0,0 +1,180 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"aigames/internal/models"
+)
+
+func TestDispatchDiscardsChangesOnError(t *testing.T) {
+	env := newTestEnv(t)
+	rs, store := env.rooms, env.store
+	room, err := rs.CreateRoom("test", "alice", models.RoomTypePublic, "", 0, 0, models.NoStake, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = rs.Dispatch(room.ID, "test", func(room *models.Room) error {
+		room.StartGame()
+		room.CurrentGame.AddPlayer("bob", models.Position1)
+		room.Name = "changed"
+		return fmt.Errorf("失败")
+	})
+	if err == nil || err.Error() != "失败" {
+		t.Fatalf("Dispatch返回 %v", err)
+	}
+
+	got, err := rs.GetRoom(room.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "test" || got.CurrentGame != nil || got.Status != models.RoomStatusIdle {
+		t.Fatalf("操作失败后房间被修改: %+v", got)
+	}
+	rooms, err := store.Rooms().List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rooms) != 1 || rooms[0].Name != "test" || rooms[0].CurrentGame != nil {
+		t.Fatal("操作失败后保存了修改")
+	}
+}
+
+func TestConcurrentJoinFillsEachSeatOnce(t *testing.T) {
+	rs := newTestEnv(t).rooms
+	room, err := rs.CreateRoom("test", "owner", models.RoomTypePublic, "", 0, 0, models.NoStake, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const players = 12
+	var wg sync.WaitGroup
+	var joined atomic.Int32
+	for i := 0; i < players; i++ {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			if _, err := rs.JoinRoom(room.ID, name, ""); err == nil {
+				joined.Add(1)
+			} else if err.Error() != "房间已满" {
+				t.Errorf("%s 加入房间返回 %v", name, err)
+			}
+		}(fmt.Sprintf("player%d", i))
+	}
+	// 同时读取快照
+	for i := 0; i < players; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, snapshot := range rs.Snapshots() {
+				snapshot.GetPlayerCount()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if joined.Load() != 3 {
+		t.Fatalf("%d 个玩家加入了房间", joined.Load())
+	}
+	got, err := rs.GetRoom(room.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	for _, player := range got.CurrentGame.Players {
+		if player == nil || seen[player.UserName] {
+			t.Fatalf("座位分配错误: %+v", got.CurrentGame.Players)
+		}
+		seen[player.UserName] = true
+	}
+}
+
+func TestConcurrentDispatchAppliesEveryChange(t *testing.T) {
+	env := newTestEnv(t)
+	rs, store := env.rooms, env.store
+	room, err := rs.CreateRoom("test", "owner", models.RoomTypePublic, "", 0, 0, models.NoStake, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const workers, rounds = 8, 25
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(fail bool) {
+			defer wg.Done()
+			for j := 0; j < rounds; j++ {
+				rs.Dispatch(room.ID, "test", func(room *models.Room) error {
+					room.MaxPlayers++
+					if fail {
+						return fmt.Errorf("失败")
+					}
+					return nil
+				})
+			}
+		}(i%2 == 1)
+	}
+	wg.Wait()
+
+	want := 3 + workers/2*rounds
+	got, err := rs.GetRoom(room.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.MaxPlayers != want {
+		t.Fatalf("MaxPlayers为 %d，应为 %d", got.MaxPlayers, want)
+	}
+	rooms, err := store.Rooms().List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rooms[0].MaxPlayers != want {
+		t.Fatalf("保存的MaxPlayers为 %d，应为 %d", rooms[0].MaxPlayers, want)
+	}
+}
+
+func TestDispatchRacingCloseNeverResurrectsRoom(t *testing.T) {
+	env := newTestEnv(t)
+	rs, store := env.rooms, env.store
+	room, err := rs.CreateRoom("test", "owner", models.RoomTypePublic, "", 0, 0, models.NoStake, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				err := rs.Dispatch(room.ID, "test", func(room *models.Room) error {
+					room.MaxPlayers++
+					return nil
+				})
+				if err != nil && !errors.Is(err, ErrRoomNotFound) {
+					t.Errorf("关闭过程中的操作返回 %v", err)
+				}
+			}
+		}()
+	}
+	if _, err := rs.CloseRoom(room.ID); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	// 关闭之后排队的操作不能把房间重新写回存储
+	if err := rs.Dispatch(room.ID, "test", func(*models.Room) error { return nil }); !errors.Is(err, ErrRoomNotFound) {
+		t.Fatalf("关闭后的操作返回 %v", err)
+	}
+	rooms, err := store.Rooms().List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rooms) != 0 {
+		t.Fatalf("关闭后存储中还有 %d 个房间", len(rooms))
+	}
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"aigames/internal/models"
+	"aigames/pkg/logger"
+)
+
+// 房主操作：踢人、转让房主、增减AI座位
+// 所有检查都在房间actor中进行，避免检查和修改之间房间状态发生变化
+
+// checkOwnerControl 检查操作者是房主且游戏尚未开始
+func checkOwnerControl(room *models.Room, operator string) error {
+	if room.Owner != operator {
+		return fmt.Errorf("只有房主可以操作")
+	}
+	if room.IsGameActive() && room.CurrentGame.Status != models.GameStatusWaiting {
+		return fmt.Errorf("游戏已开始")
+	}
+	if room.Session.IsActive() {
+		return fmt.Errorf("对战进行中")
+	}
+	return nil
+}
+
+// nextHumanPlayer 从指定座位之后按座位顺序查找下一位真人玩家
+func nextHumanPlayer(game *models.Game, from models.PlayerPosition) string {
+	for i := 1; i <= 3; i++ {
+		player := game.GetPlayer(models.PlayerPosition((int(from) + i) % 3))
+		if player != nil && !player.IsAI {
+			return player.UserName
+		}
+	}
+	return ""
+}
+
+// hasHumanPlayer 判断房间中是否有真人玩家
+func hasHumanPlayer(room *models.Room) bool {
+	return room.CurrentGame != nil && nextHumanPlayer(room.CurrentGame, models.Position3) != ""
+}
+
+// KickPlayer 房主踢出玩家，被踢出的玩家短时间内不能重新加入该房间
+func (rs *RoomService) KickPlayer(roomID, owner, target string) (time.Time, error) {
+	err := rs.Dispatch(roomID, "kick", func(room *models.Room) error {
+		if err := checkOwnerControl(room, owner); err != nil {
+			return err
+		}
+		if target == owner {
+			return fmt.Errorf("不能踢出自己")
+		}
+		if room.CurrentGame == nil {
+			return ErrPlayerNotInRoom
+		}
+
+		player := room.CurrentGame.GetPlayerByName(target)
+		if player == nil {
+			return ErrPlayerNotInRoom
+		}
+		if player.IsAI {
+			return fmt.Errorf("请使用移除AI座位")
+		}
+
+		room.CurrentGame.RemovePlayer(player.Position)
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	until := time.Now().Add(rs.kickBanTTL)
+	rs.kickBanLock.Lock()
+	rs.kickBans[kickBanKey(roomID, target)] = until
+	rs.kickBanLock.Unlock()
+
+	logger.Info("房主 %s 将 %s 踢出房间 %s", owner, target, roomID)
+	return until, nil
+}
+
+// kickBanKey 禁止加入记录的键
+func kickBanKey(roomID, username string) string {
+	return roomID + "\x00" + username
+}
+
+// isKickBanned 检查玩家是否仍在被踢出后的禁止加入期内，顺便清理过期记录
+func (rs *RoomService) isKickBanned(roomID, username string) bool {
+	rs.kickBanLock.Lock()
+	defer rs.kickBanLock.Unlock()
+
+	now := time.Now()
+	for key, until := range rs.kickBans {
+		if !now.Before(until) {
+			delete(rs.kickBans, key)
+		}
+	}
+	_, banned := rs.kickBans[kickBanKey(roomID, username)]
+	return banned
+}
+
+// TransferOwnership 房主将房主身份转给房间中的另一位真人玩家
+func (rs *RoomService) TransferOwnership(roomID, owner, target string) (*models.Room, error) {
+	var snapshot *models.Room
+	err := rs.Dispatch(roomID, "transfer_owner", func(room *models.Room) error {
+		if room.Owner != owner {
+			return fmt.Errorf("只有房主可以操作")
+		}
+		if target == owner {
+			return fmt.Errorf("你已经是房主")
+		}
+		if room.CurrentGame == nil {
+			return ErrPlayerNotInRoom
+		}
+
+		player := room.CurrentGame.GetPlayerByName(target)
+		if player == nil {
+			return ErrPlayerNotInRoom
+		}
+		if player.IsAI {
+			return fmt.Errorf("不能将房主转给AI玩家")
+		}
+
+		room.Owner = target
+		snapshot = room.Clone()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("房间 %s 房主由 %s 转给 %s", roomID, owner, target)
+	return snapshot, nil
+}
+
+// AddAISeat 房主在游戏开始前添加一个AI座位
+func (rs *RoomService) AddAISeat(roomID, owner string) (*models.Room, error) {
+	rs.mutex.Lock()
+	aiName := rs.newAIName()
+	rs.mutex.Unlock()
+
+	var snapshot *models.Room
+	err := rs.Dispatch(roomID, "add_ai", func(room *models.Room) error {
+		if err := checkOwnerControl(room, owner); err != nil {
+			return err
+		}
+
+		// 上一局已经结束时开始新的一局
+		if !room.IsGameActive() {
+			room.StartGame()
+		}
+		if room.IsFull() {
+			return ErrRoomFull
+		}
+
+		game := room.CurrentGame
+		for pos := models.Position1; pos <= models.Position3; pos++ {
+			if game.GetPlayer(pos) == nil && game.AddPlayer(aiName, pos) {
+				player := game.GetPlayer(pos)
+				player.IsAI = true
+				player.IsReady = true // AI玩家默认准备
+				break
+			}
+		}
+
+		room.Status = models.RoomStatusWaiting
+		snapshot = room.Clone()
+		return nil
+	})
+	if err != nil {
+		rs.releaseAIName(aiName)
+		return nil, err
+	}
+
+	logger.Info("房主 %s 在房间 %s 添加AI玩家 %s", owner, roomID, aiName)
+	return snapshot, nil
+}
+
+// RemoveAISeat 房主在游戏开始前移除一个AI座位
+func (rs *RoomService) RemoveAISeat(roomID, owner, aiName string) (*models.Room, error) {
+	var snapshot *models.Room
+	err := rs.Dispatch(roomID, "remove_ai", func(room *models.Room) error {
+		if err := checkOwnerControl(room, owner); err != nil {
+			return err
+		}
+		if room.CurrentGame == nil {
+			return ErrPlayerNotInRoom
+		}
+
+		player := room.CurrentGame.GetPlayerByName(aiName)
+		if player == nil {
+			return ErrPlayerNotInRoom
+		}
+		if !player.IsAI {
+			return fmt.Errorf("该玩家不是AI玩家")
+		}
+
+		room.CurrentGame.RemovePlayer(player.Position)
+		if room.GetPlayerCount() == 0 {
+			room.Status = models.RoomStatusIdle
+		}
+		snapshot = room.Clone()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rs.releaseAIName(aiName)
+	logger.Info("房主 %s 在房间 %s 移除AI玩家 %s", owner, roomID, aiName)
+	return snapshot, nil
+}
+
+// releaseAIName 释放不再使用的AI名称
+func (rs *RoomService) releaseAIName(name string) {
+	rs.mutex.Lock()
+	delete(rs.aiNames, name)
+	rs.mutex.Unlock()
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"aigames/internal/models"
+)
+
+// 房间列表排序字段
+const (
+	RoomSortCreatedAt   = "created_at"   // 按创建时间
+	RoomSortPlayerCount = "player_count" // 按玩家数
+)
+
+// 房间列表排序方向
+const (
+	RoomOrderAsc  = "asc"
+	RoomOrderDesc = "desc"
+)
+
+// RoomQuery 房间列表查询条件，指针字段为nil表示不过滤，Type 除外
+type RoomQuery struct {
+	Type        *models.RoomType   // 房间类型，nil表示只列出公开房间，私人房间需要显式指定
+	Status      *models.RoomStatus // 房间状态
+	OpenSeats   int                // 至少空余的座位数，0表示不过滤
+	HasPassword *bool              // 是否有密码
+	Keyword     string             // 房间名称关键字，不区分大小写
+	SortBy      string             // 排序字段，默认按创建时间
+	Order       string             // 排序方向，默认倒序
+	Cursor      string             // 上一页返回的游标，为空时从头开始
+	Offset      int                // 没有游标时跳过的房间数，用于兼容页码分页
+	Limit       int                // 每页数量
+}
+
+// RoomPage 房间列表查询结果
+type RoomPage struct {
+	Rooms      []*models.Room // 本页房间快照
+	Total      int            // 符合条件的房间总数
+	NextCursor string         // 下一页游标，没有更多房间时为空
+}
+
+// roomSortKey 房间排序键，依次比较主键、创建时间和房间ID，保证顺序稳定
+type roomSortKey struct {
+	primary int64
+	created int64
+	id      string
+}
+
+// less 比较两个排序键
+func (k roomSortKey) less(other roomSortKey) bool {
+	if k.primary != other.primary {
+		return k.primary < other.primary
+	}
+	if k.created != other.created {
+		return k.created < other.created
+	}
+	return k.id < other.id
+}
+
+// ListRooms 按条件查询房间，结果按排序键稳定排序并分页
+func (rs *RoomService) ListRooms(query RoomQuery) (*RoomPage, error) {
+	if query.SortBy == "" {
+		query.SortBy = RoomSortCreatedAt
+	}
+	if query.SortBy != RoomSortCreatedAt && query.SortBy != RoomSortPlayerCount {
+		return nil, fmt.Errorf("排序字段无效")
+	}
+	if query.Order == "" {
+		query.Order = RoomOrderDesc
+	}
+	if query.Order != RoomOrderAsc && query.Order != RoomOrderDesc {
+		return nil, fmt.Errorf("排序方向无效")
+	}
+	desc := query.Order == RoomOrderDesc
+	roomType := models.RoomTypePublic
+	if query.Type != nil {
+		roomType = *query.Type
+	}
+
+	var after *roomSortKey
+	if query.Cursor != "" {
+		key, err := decodeRoomCursor(query.Cursor, query.SortBy, query.Order)
+		if err != nil {
+			return nil, err
+		}
+		after = &key
+	}
+
+	// 过滤
+	keyword := strings.ToLower(strings.TrimSpace(query.Keyword))
+	rooms := make([]*models.Room, 0)
+	for _, room := range rs.Snapshots() {
+		if room.Type != roomType {
+			continue
+		}
+		if query.Status != nil && room.Status != *query.Status {
+			continue
+		}
+		if query.OpenSeats > 0 && room.MaxPlayers-room.GetPlayerCount() < query.OpenSeats {
+			continue
+		}
+		if query.HasPassword != nil && (room.Password != "") != *query.HasPassword {
+			continue
+		}
+		if keyword != "" && !strings.Contains(strings.ToLower(room.Name), keyword) {
+			continue
+		}
+		rooms = append(rooms, room)
+	}
+
+	// 排序
+	keys := make(map[string]roomSortKey, len(rooms))
+	for _, room := range rooms {
+		keys[room.ID] = roomSortKeyOf(room, query.SortBy)
+	}
+	sort.Slice(rooms, func(i, j int) bool {
+		if desc {
+			return keys[rooms[j].ID].less(keys[rooms[i].ID])
+		}
+		return keys[rooms[i].ID].less(keys[rooms[j].ID])
+	})
+
+	page := &RoomPage{Total: len(rooms)}
+
+	// 定位起始位置：有游标时从游标之后开始，否则按偏移量
+	start := 0
+	if after != nil {
+		start = sort.Search(len(rooms), func(i int) bool {
+			key := keys[rooms[i].ID]
+			if desc {
+				return key.less(*after)
+			}
+			return after.less(key)
+		})
+	} else if query.Offset > 0 {
+		start = query.Offset
+	}
+	if start > len(rooms) {
+		start = len(rooms)
+	}
+
+	end := len(rooms)
+	if query.Limit > 0 && start+query.Limit < end {
+		end = start + query.Limit
+	}
+	page.Rooms = rooms[start:end]
+
+	if end < len(rooms) && end > start {
+		page.NextCursor = encodeRoomCursor(keys[rooms[end-1].ID], query.SortBy, query.Order)
+	}
+	return page, nil
+}
+
+// roomSortKeyOf 计算房间的排序键
+func roomSortKeyOf(room *models.Room, sortBy string) roomSortKey {
+	key := roomSortKey{
+		created: room.CreatedAt.UnixNano(),
+		id:      room.ID,
+	}
+	if sortBy == RoomSortPlayerCount {
+		key.primary = int64(room.GetPlayerCount())
+	} else {
+		key.primary = key.created
+	}
+	return key
+}
+
+// encodeRoomCursor 将排序方式和最后一个房间的排序键编码为游标
+func encodeRoomCursor(key roomSortKey, sortBy, order string) string {
+	raw := strings.Join([]string{
+		sortBy,
+		order,
+		strconv.FormatInt(key.primary, 10),
+		strconv.FormatInt(key.created, 10),
+		key.id,
+	}, "|")
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeRoomCursor 解析游标，游标必须与本次查询的排序方式一致
+func decodeRoomCursor(cursor, sortBy, order string) (roomSortKey, error) {
+	invalid := fmt.Errorf("分页游标无效")
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return roomSortKey{}, invalid
+	}
+	parts := strings.SplitN(string(raw), "|", 5)
+	if len(parts) != 5 || parts[0] != sortBy || parts[1] != order {
+		return roomSortKey{}, invalid
+	}
+
+	primary, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return roomSortKey{}, invalid
+	}
+	created, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return roomSortKey{}, invalid
+	}
+	return roomSortKey{primary: primary, created: created, id: parts[4]}, nil
+}
+
+// CountByStatus 按房间状态统计内存中的房间数
+func (rs *RoomService) CountByStatus() map[models.RoomStatus]int {
+	counts := make(map[models.RoomStatus]int)
+	for _, room := range rs.Snapshots() {
+		counts[room.Status]++
+	}
+	return counts
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"aigames/internal/config"
+	"aigames/internal/models"
+	"aigames/pkg/logger"
+	"aigames/pkg/protocol"
+)
+
+// errRoomActive 重新检查时房间已不再需要回收
+var errRoomActive = errors.New("房间仍在使用")
+
+// RoomReaper 房间回收器
+// 定期关闭长时间无操作的房间和游戏已结束的房间：停止AI、归档最后一局，并从内存和数据库中删除
+type RoomReaper struct {
+	roomService *RoomService
+	gameService *GameService
+	presence    *PresenceService
+
+	idleTTL     time.Duration // 没有进行中游戏的房间无操作的回收时长
+	activeTTL   time.Duration // 游戏进行中的房间无操作的回收时长
+	finishedTTL time.Duration // 游戏结束或中止后的回收时长
+	interval    time.Duration // 检查间隔
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewRoomReaper 根据游戏配置创建房间回收器
+func NewRoomReaper(roomService *RoomService, gameService *GameService, presence *PresenceService, cfg config.GameConfig) *RoomReaper {
+	return &RoomReaper{
+		roomService: roomService,
+		gameService: gameService,
+		presence:    presence,
+		idleTTL:     time.Duration(cfg.RoomIdleTTL) * time.Second,
+		activeTTL:   time.Duration(cfg.ActiveRoomTTL) * time.Second,
+		finishedTTL: time.Duration(cfg.FinishedRoomTTL) * time.Second,
+		interval:    time.Duration(cfg.ReaperInterval) * time.Second,
+		done:        make(chan struct{}),
+	}
+}
+
+// Start 启动回收器，启动时立即检查一次，清理上次运行遗留的房间
+func (rr *RoomReaper) Start() {
+	if rr.interval <= 0 {
+		logger.Info("房间回收未启用")
+		return
+	}
+
+	rr.Sweep()
+	go rr.run()
+	logger.Info("房间回收启动: 无操作=%v, 游戏进行中=%v, 游戏结束后=%v, 间隔=%v", rr.idleTTL, rr.activeTTL, rr.finishedTTL, rr.interval)
+}
+
+// Stop 停止回收器
+func (rr *RoomReaper) Stop() {
+	rr.stopOnce.Do(func() { close(rr.done) })
+}
+
+// run 定期检查
+func (rr *RoomReaper) run() {
+	ticker := time.NewTicker(rr.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			rr.Sweep()
+		case <-rr.done:
+			return
+		}
+	}
+}
+
+// Sweep 检查所有房间并关闭需要回收的房间，返回关闭的房间数
+func (rr *RoomReaper) Sweep() int {
+	now := time.Now()
+	closed := 0
+	for _, room := range rr.roomService.Snapshots() {
+		if _, expired := rr.expired(room, now); !expired {
+			continue
+		}
+
+		if err := rr.closeExpired(room.ID); err != nil {
+			if !errors.Is(err, errRoomActive) && !errors.Is(err, ErrRoomNotFound) {
+				logger.Error("回收房间 %s 失败: %v", room.ID, err)
+			}
+			continue
+		}
+		closed++
+	}
+
+	if closed > 0 {
+		logger.Info("本次回收房间 %d 个，剩余 %d 个", closed, rr.roomService.GetRoomCount())
+	}
+	return closed
+}
+
+// closeExpired 关闭需要回收的房间
+// 快照之后房间可能又有操作，关闭前在房间actor中按最新状态重新检查，不再需要回收时返回 errRoomActive
+func (rr *RoomReaper) closeExpired(roomID string) error {
+	return rr.close(roomID, func(room *models.Room) (string, error) {
+		if reason, expired := rr.expired(room, time.Now()); expired {
+			return reason, nil
+		}
+		return "", errRoomActive
+	})
+}
+
+// expired 判断房间是否需要回收，返回回收原因
+func (rr *RoomReaper) expired(room *models.Room, now time.Time) (string, bool) {
+	idle := now.Sub(room.UpdatedAt)
+
+	if room.CurrentGame != nil && !room.IsGameActive() && rr.finishedTTL > 0 && idle >= rr.finishedTTL {
+		return "游戏已结束", true
+	}
+	// 游戏进行中的房间可能只是在等待玩家重连，使用单独的更长时长
+	if room.IsGameActive() && room.CurrentGame.Status != models.GameStatusWaiting {
+		if rr.activeTTL > 0 && idle >= rr.activeTTL {
+			return "游戏长时间无操作", true
+		}
+		return "", false
+	}
+	if rr.idleTTL > 0 && idle >= rr.idleTTL {
+		return "房间长时间无操作", true
+	}
+	return "", false
+}
+
+// Close 关闭房间并通知房间中的玩家，供运维删除房间使用
+func (rr *RoomReaper) Close(roomID, reason string) error {
+	return rr.close(roomID, func(*models.Room) (string, error) {
+		return reason, nil
+	})
+}
+
+// close 在房间actor中执行 check 得到关闭原因，通过后停止AI并关闭房间，最后通知房间中的玩家
+func (rr *RoomReaper) close(roomID string, check func(room *models.Room) (string, error)) error {
+	var reason string
+	room, err := rr.roomService.CloseRoomIf(roomID, func(room *models.Room) error {
+		var err error
+		if reason, err = check(room); err != nil {
+			return err
+		}
+		// 确认关闭后先停止AI，避免AI在房间关闭过程中继续操作；只获取AI控制器的锁，可以在actor中调用
+		rr.gameService.StopAIControllers(roomID)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("关闭房间 %s（%s）: %s", room.ID, room.Name, reason)
+
+	if room.CurrentGame == nil {
+		return nil
+	}
+	data := protocol.RoomClosedData{RoomID: room.ID, Reason: reason}
+	for _, player := range room.CurrentGame.Players {
+		if player != nil && !player.IsAI {
+			rr.presence.Push(player.UserName, protocol.RouteRoomClosed, data)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"aigames/internal/config"
+	"aigames/internal/models"
+)
+
+// reaperConfig 房间无操作一小时后回收，游戏进行中的房间四小时后回收
+var reaperConfig = config.GameConfig{RoomIdleTTL: 3600, ActiveRoomTTL: 4 * 3600, ReaperInterval: 60}
+
+func TestSweepClosesIdleRooms(t *testing.T) {
+	env := newTestEnv(t)
+	rs := env.rooms
+	rr := NewRoomReaper(rs, env.games, env.presence, reaperConfig)
+	idle, err := rs.CreateRoom("idle", "alice", models.RoomTypePublic, "", 0, 0, models.NoStake, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	active, err := rs.CreateRoom("active", "bob", models.RoomTypePublic, "", 0, 0, models.NoStake, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backdateRoom(t, rs, idle.ID, 2*time.Hour)
+
+	if closed := rr.Sweep(); closed != 1 {
+		t.Fatalf("回收了 %d 个房间", closed)
+	}
+	if _, err := rs.GetRoom(idle.ID); err == nil {
+		t.Fatal("无操作的房间没有被回收")
+	}
+	if _, err := rs.GetRoom(active.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCloseExpiredRechecksLatestState(t *testing.T) {
+	env := newTestEnv(t)
+	rs := env.rooms
+	rr := NewRoomReaper(rs, env.games, env.presence, reaperConfig)
+	room, err := rs.CreateRoom("test", "alice", models.RoomTypePublic, "", 0, 0, models.NoStake, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backdateRoom(t, rs, room.ID, 2*time.Hour)
+
+	// 快照显示需要回收，但关闭前玩家加入了房间
+	if _, err := rs.JoinRoom(room.ID, "alice", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := rr.closeExpired(room.ID); err != errRoomActive {
+		t.Fatalf("重新检查后返回 %v", err)
+	}
+	got, err := rs.GetRoom(room.ID)
+	if err != nil {
+		t.Fatal("仍在使用的房间被关闭")
+	}
+	if !got.HasPlayer("alice") {
+		t.Fatal("重新检查修改了房间")
+	}
+
+	// 已移除的房间不再执行排队的操作
+	if _, err := rs.CloseRoom(room.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := rr.closeExpired(room.ID); err == nil || err.Error() != "房间不存在" {
+		t.Fatalf("关闭已关闭的房间返回 %v", err)
+	}
+}
+
+func TestSweepKeepsActiveGameUntilActiveTTL(t *testing.T) {
+	env := newTestEnv(t)
+	rs := env.rooms
+	rr := NewRoomReaper(rs, env.games, env.presence, reaperConfig)
+	room := startTestGame(t, rs, "alice")
+
+	// 超过无操作时长但游戏仍在进行，可能只是玩家重连较慢
+	backdateRoom(t, rs, room.ID, 2*time.Hour)
+	if closed := rr.Sweep(); closed != 0 {
+		t.Fatalf("回收了游戏进行中的房间 %d 个", closed)
+	}
+
+	backdateRoom(t, rs, room.ID, 5*time.Hour)
+	if closed := rr.Sweep(); closed != 1 {
+		t.Fatalf("超过游戏进行中的回收时长后回收了 %d 个房间", closed)
+	}
+	if _, err := rs.GetRoom(room.ID); err == nil {
+		t.Fatal("长时间无操作的游戏没有被回收")
+	}
+}
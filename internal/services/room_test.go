@@ -0,0 +1,252 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"aigames/internal/config"
+	"aigames/internal/models"
+)
+
+func TestRoomsReloadedFromStore(t *testing.T) {
+	env := newTestEnv(t)
+	rs, store := env.rooms, env.store
+	room := startTestGame(t, rs, "alice")
+
+	reloaded := NewRoomService(store, config.GameConfig{})
+	loaded, err := reloaded.GetRoom(room.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.CurrentGame == nil || loaded.CurrentGame.ID != room.CurrentGame.ID {
+		t.Fatal("重新加载的房间没有进行中的游戏")
+	}
+	if id, err := reloaded.ResolveCode(room.Code); err != nil || id != room.ID {
+		t.Fatalf("房间码 %s 解析为 %s, %v", room.Code, id, err)
+	}
+}
+
+func TestCloseRoomArchivesGame(t *testing.T) {
+	env := newTestEnv(t)
+	rs, store := env.rooms, env.store
+	room := startTestGame(t, rs, "alice")
+
+	closed, err := rs.CloseRoom(room.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if closed.CurrentGame.Status != models.GameStatusAbandoned {
+		t.Fatalf("关闭房间后游戏状态为 %v", closed.CurrentGame.Status)
+	}
+
+	rooms, err := store.Rooms().List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rooms) != 0 {
+		t.Fatalf("关闭后存储中还有 %d 个房间", len(rooms))
+	}
+	archived, err := store.Games().Get(room.CurrentGame.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archived.Status != models.GameStatusAbandoned {
+		t.Fatalf("归档的游戏状态为 %v", archived.Status)
+	}
+
+	gs := NewGameService(store, rs, nil)
+	game, roomID, err := gs.GetGame(room.CurrentGame.ID)
+	if err != nil || roomID != "" || game.ID != room.CurrentGame.ID {
+		t.Fatalf("获取归档的游戏: %v, %q, %v", game, roomID, err)
+	}
+	if _, _, err := gs.GetGame("missing"); err == nil || err.Error() != "游戏不存在" {
+		t.Fatalf("获取不存在的游戏返回 %v", err)
+	}
+}
+
+func TestJoinRoomWrongPasswordKeepsFinishedGame(t *testing.T) {
+	rs := newTestEnv(t).rooms
+	room, err := rs.CreateRoom("test", "alice", models.RoomTypePrivate, "secret", 0, 0, models.NoStake, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rs.JoinRoom(room.ID, "alice", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Dispatch(room.ID, "finish", func(room *models.Room) error {
+		room.EndGame()
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := rs.JoinRoom(room.ID, "bob", "wrong"); err == nil || err.Error() != "房间密码错误" {
+		t.Fatalf("密码错误时返回 %v", err)
+	}
+	got, err := rs.GetRoom(room.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.CurrentGame == nil || got.CurrentGame.Status != models.GameStatusFinished {
+		t.Fatal("加入失败后上一局被清除")
+	}
+}
+
+func TestListRoomsDefaultsToPublic(t *testing.T) {
+	rs := newTestEnv(t).rooms
+	public, err := rs.CreateRoom("public", "alice", models.RoomTypePublic, "", 0, 0, models.NoStake, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	private, err := rs.CreateRoom("private", "bob", models.RoomTypePrivate, "secret", 0, 0, models.NoStake, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := rs.ListRooms(RoomQuery{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 1 || page.Rooms[0].ID != public.ID {
+		t.Fatalf("未指定类型时应只列出公开房间，实际 %d 个房间", page.Total)
+	}
+
+	roomType := models.RoomTypePrivate
+	page, err = rs.ListRooms(RoomQuery{Type: &roomType, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 1 || page.Rooms[0].ID != private.ID {
+		t.Fatalf("指定私人房间时应只列出私人房间，实际 %d 个房间", page.Total)
+	}
+}
+
+func TestLeaveRoomTransfersOwnershipFromUnseatedOwner(t *testing.T) {
+	rs := newTestEnv(t).rooms
+	room, err := rs.CreateRoom("test", "alice", models.RoomTypePublic, "", 0, 0, models.NoStake, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rs.JoinRoom(room.ID, "bob", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	newOwner, _, err := rs.LeaveRoom(room.ID, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newOwner != "bob" {
+		t.Fatalf("新房主为 %q，应为 bob", newOwner)
+	}
+	room, err = rs.GetRoom(room.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if room.Owner != "bob" {
+		t.Fatalf("房主为 %s，应为 bob", room.Owner)
+	}
+}
+
+func TestPrivateRoomClosedWhenOnlyAIRemain(t *testing.T) {
+	env := newTestEnv(t)
+	rs, store := env.rooms, env.store
+	room, err := rs.CreateRoom("test", "alice", models.RoomTypePrivate, "secret", 2, 0, models.NoStake, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rs.JoinRoom(room.ID, "alice", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.SetPlayerReady(room.ID, "alice", true); err != nil {
+		t.Fatal(err)
+	}
+	game, err := rs.StartGame(room.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newOwner, closed, err := rs.LeaveRoom(room.ID, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !closed || newOwner != "" {
+		t.Fatalf("房主离开后 closed=%v, newOwner=%q，只剩AI的私人房间应关闭", closed, newOwner)
+	}
+	if _, err := rs.GetRoom(room.ID); err == nil {
+		t.Fatal("关闭的房间仍然存在")
+	}
+	archived, err := store.Games().Get(game.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archived.Status != models.GameStatusAbandoned {
+		t.Fatalf("归档的游戏状态为 %v", archived.Status)
+	}
+}
+
+func TestPrivateRoomKeptWhenHumanRemains(t *testing.T) {
+	rs := newTestEnv(t).rooms
+	room, err := rs.CreateRoom("test", "alice", models.RoomTypePrivate, "secret", 1, 0, models.NoStake, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"alice", "bob"} {
+		if _, err := rs.JoinRoom(room.ID, name, "secret"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	newOwner, closed, err := rs.LeaveRoom(room.ID, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if closed || newOwner != "bob" {
+		t.Fatalf("房主离开后 closed=%v, newOwner=%q，房间应转给 bob", closed, newOwner)
+	}
+	if _, err := rs.GetRoom(room.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRoomErrorsMatchSentinels(t *testing.T) {
+	rs := newTestEnv(t).rooms
+	balances := map[string]int64{"alice": 1000, "bob": 1000}
+	rs.SetBalanceSource(func(name string) int64 { return balances[name] })
+	room, err := rs.CreateRoom("test", "alice", models.RoomTypePublic, "", 1, 0, 10, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"alice", "bob"} {
+		if _, err := rs.JoinRoom(room.ID, name, ""); err != nil {
+			t.Fatal(err)
+		}
+		if err := rs.SetPlayerReady(room.ID, name, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := rs.JoinRoom(room.ID, "carol", ""); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("金币不足时加入房间返回 %v", err)
+	}
+	balances["dave"] = 1000
+	if _, err := rs.JoinRoom(room.ID, "dave", ""); !errors.Is(err, ErrRoomFull) {
+		t.Fatalf("加入满员房间返回 %v", err)
+	}
+	if _, err := rs.JoinRoom("missing", "alice", ""); !errors.Is(err, ErrRoomNotFound) {
+		t.Fatalf("加入不存在的房间返回 %v", err)
+	}
+
+	// 开局时逐个检查玩家金币，错误信息带上玩家名
+	balances["bob"] = 100
+	if _, err := rs.StartGame(room.ID); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("玩家金币不足时开始游戏返回 %v", err)
+	}
+
+	rs.SetDraining(true)
+	if _, err := rs.StartGame(room.ID); !errors.Is(err, ErrServerDraining) {
+		t.Fatalf("排空时开始游戏返回 %v", err)
+	}
+	if _, err := rs.CreateRoom("test", "bob", models.RoomTypePublic, "", 0, 0, models.NoStake, 0); !errors.Is(err, ErrServerDraining) {
+		t.Fatalf("排空时创建房间返回 %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"aigames/internal/config"
+	"aigames/internal/models"
+	"aigames/internal/repository"
+)
+
+// testEnv 共用一个内存存储的一组服务，按 main.go 的方式互相连接
+// AI工作池不启动，AI玩家不会自动行动
+type testEnv struct {
+	cfg          config.Config
+	store        repository.Store
+	presence     *PresenceService
+	users        *UserService
+	tokens       *TokenService
+	friends      *FriendService
+	coins        *CoinService
+	achievements *AchievementService
+	rooms        *RoomService
+	games        *GameService
+	tournaments  *TournamentService
+}
+
+// newTestEnv 创建测试使用的服务，configure 在创建服务前修改默认配置
+// 默认配置使用最低的密码哈希强度，多局对战的下一局不会在测试中自动开始
+func newTestEnv(t *testing.T, configure ...func(cfg *config.Config)) *testEnv {
+	t.Helper()
+	cfg := config.Config{
+		AI:       config.AIConfig{FastMode: true},
+		Game:     config.GameConfig{NextHandDelay: 3600},
+		Security: config.SecurityConfig{BcryptCost: 4},
+		JWT:      config.JWTConfig{Secret: "secret", Issuer: "aigames"},
+		Economy:  config.EconomyConfig{InitialBalance: 1000},
+	}
+	for _, fn := range configure {
+		fn(&cfg)
+	}
+
+	env := &testEnv{cfg: cfg, store: repository.NewMemoryStore(), presence: NewPresenceService()}
+	env.users = NewUserService(env.store, cfg.Security)
+	env.tokens = NewTokenService(env.store, cfg.JWT)
+	env.friends = NewFriendService(env.store, env.presence)
+	env.coins = NewCoinService(env.store, env.presence, cfg.Economy)
+	achievements, err := NewAchievementService(env.store, env.coins, env.presence, cfg.Game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.achievements = achievements
+	env.rooms = NewRoomService(env.store, cfg.Game)
+	env.games = NewGameService(env.store, env.rooms, NewAIWorkerPool(cfg.AI))
+	env.tournaments = NewTournamentService(env.store, env.rooms, env.games, env.presence, cfg.Game)
+
+	env.rooms.SetFriendCheck(env.friends.AreFriends)
+	env.rooms.SetBalanceSource(env.coins.BalanceOf)
+	env.users.OnDelete(env.tokens.RevokeDeletedUser)
+	env.users.OnDelete(env.friends.RemoveUser)
+	env.users.OnDelete(env.coins.DeleteAccount)
+	env.users.OnDelete(env.achievements.DeleteProgress)
+	return env
+}
+
+// addUsers 注册用户，密码与用户名相同
+func (env *testEnv) addUsers(t *testing.T, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		user := &models.User{Name: name}
+		if err := env.users.SetPassword(user, name); err != nil {
+			t.Fatal(err)
+		}
+		if err := env.users.SaveUser(user); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// makeFriends 让两个用户成为好友
+func (env *testEnv) makeFriends(t *testing.T, a, b string) {
+	t.Helper()
+	if _, err := env.friends.SendRequest(a, b); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.friends.Accept(b, a); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// startTestGame 创建有两个AI的房间，房主入座准备后开始游戏
+func startTestGame(t *testing.T, rs *RoomService, owner string) *models.Room {
+	t.Helper()
+	room, err := rs.CreateRoom("test", owner, models.RoomTypePublic, "", 2, 0, models.NoStake, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rs.JoinRoom(room.ID, owner, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.SetPlayerReady(room.ID, owner, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rs.StartGame(room.ID); err != nil {
+		t.Fatal(err)
+	}
+	room, err = rs.GetRoom(room.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return room
+}
+
+// backdateRoom 把房间的最后操作时间改到 d 之前
+func backdateRoom(t *testing.T, rs *RoomService, roomID string, d time.Duration) {
+	t.Helper()
+	actor, err := rs.getActor(roomID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := actor.send("backdate", func(room *models.Room) error {
+		room.UpdatedAt = time.Now().Add(-d)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"aigames/internal/config"
+	"aigames/internal/repository"
+	"aigames/pkg/logger"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// bucketRevokedTokens 已吊销凭证存储桶 key: 凭证ID, value: 凭证过期时间(unix秒)
+	bucketRevokedTokens = "revoked_tokens"
+	// bucketTokenEpochs 用户凭证失效时间存储桶 key: 用户名, value: 此前签发的凭证全部失效(unix纳秒)
+	bucketTokenEpochs = "token_epochs"
+)
+
+// 凭证校验错误
+var (
+	ErrTokenExpired = errors.New("登录已过期")
+	ErrTokenInvalid = errors.New("登录凭证无效")
+)
+
+// TokenClaims 登录凭证内容，Subject 为用户名，ID 为凭证ID
+type TokenClaims struct {
+	jwt.RegisteredClaims
+	IssuedAtNano int64 `json:"iat_ns"` // 签发时间(unix纳秒)，iat只精确到秒，不能区分同一秒内吊销前后签发的凭证
+}
+
+// TokenService 登录凭证服务
+type TokenService struct {
+	store  repository.Store
+	secret []byte
+	expire time.Duration
+	issuer string
+}
+
+// NewTokenService 创建登录凭证服务实例
+func NewTokenService(store repository.Store, cfg config.JWTConfig) *TokenService {
+	expire := time.Duration(cfg.ExpireTime) * time.Hour
+	if expire <= 0 {
+		expire = 24 * time.Hour
+	}
+
+	service := &TokenService{
+		store:  store,
+		secret: []byte(cfg.Secret),
+		expire: expire,
+		issuer: cfg.Issuer,
+	}
+	// 清理已经过期的吊销记录
+	if err := service.purgeRevoked(); err != nil {
+		logger.Warn("清理吊销凭证失败: %v", err)
+	}
+	return service
+}
+
+// Issue 为用户签发凭证
+func (ts *TokenService) Issue(username string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ts.expire)
+
+	claims := TokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Subject:   username,
+			Issuer:    ts.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		IssuedAtNano: now.UnixNano(),
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ts.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("签发凭证失败: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// Verify 校验凭证的签名、签发者、有效期和吊销状态
+func (ts *TokenService) Verify(tokenString string) (*TokenClaims, error) {
+	claims := &TokenClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return ts.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(ts.issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, ErrTokenInvalid
+	}
+
+	if claims.Subject == "" || claims.ID == "" {
+		return nil, ErrTokenInvalid
+	}
+
+	if ts.isRevoked(claims.ID) || ts.issuedBeforeEpoch(claims) {
+		return nil, ErrTokenInvalid
+	}
+
+	return claims, nil
+}
+
+// Refresh 使用有效凭证换取新凭证，旧凭证随即吊销
+func (ts *TokenService) Refresh(tokenString string) (string, time.Time, *TokenClaims, error) {
+	claims, err := ts.Verify(tokenString)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+
+	token, expiresAt, err := ts.Issue(claims.Subject)
+	if err != nil {
+		return "", time.Time{}, nil, err
+	}
+
+	if err := ts.revokeClaims(claims); err != nil {
+		return "", time.Time{}, nil, err
+	}
+	return token, expiresAt, claims, nil
+}
+
+// Revoke 吊销凭证，已过期或无效的凭证无需吊销
+func (ts *TokenService) Revoke(tokenString string) error {
+	claims, err := ts.Verify(tokenString)
+	if err != nil {
+		return err
+	}
+	return ts.revokeClaims(claims)
+}
+
+// RevokeUser 吊销用户此前签发的所有凭证，用于修改密码
+func (ts *TokenService) RevokeUser(username string) error {
+	return ts.store.Update(func(tx repository.Tx) error {
+		return putEpoch(tx, username)
+	})
+}
+
+// RevokeDeletedUser 在注销账户的事务中吊销用户的所有凭证
+func (ts *TokenService) RevokeDeletedUser(tx repository.Tx, username, _ string) error {
+	return putEpoch(tx, username)
+}
+
+// putEpoch 将用户凭证失效时间设为当前时间
+func putEpoch(tx repository.Tx, username string) error {
+	b, err := tx.Bucket(bucketTokenEpochs)
+	if err != nil {
+		return err
+	}
+
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, uint64(time.Now().UnixNano()))
+	return b.Put([]byte(username), value)
+}
+
+// issuedBeforeEpoch 检查凭证是否签发于用户凭证失效时间之前
+func (ts *TokenService) issuedBeforeEpoch(claims *TokenClaims) bool {
+	if claims.IssuedAtNano == 0 {
+		return true
+	}
+
+	before := false
+	ts.store.View(func(tx repository.Tx) error {
+		b, err := tx.Bucket(bucketTokenEpochs)
+		if err != nil {
+			return err
+		}
+		if v := b.Get([]byte(claims.Subject)); len(v) == 8 {
+			before = claims.IssuedAtNano < int64(binary.BigEndian.Uint64(v))
+		}
+		return nil
+	})
+	return before
+}
+
+// revokeClaims 记录吊销的凭证ID，保留到凭证过期为止
+func (ts *TokenService) revokeClaims(claims *TokenClaims) error {
+	return ts.store.Update(func(tx repository.Tx) error {
+		b, err := tx.Bucket(bucketRevokedTokens)
+		if err != nil {
+			return err
+		}
+
+		value := make([]byte, 8)
+		binary.BigEndian.PutUint64(value, uint64(claims.ExpiresAt.Unix()))
+		return b.Put([]byte(claims.ID), value)
+	})
+}
+
+// isRevoked 检查凭证是否已吊销
+func (ts *TokenService) isRevoked(id string) bool {
+	revoked := false
+	ts.store.View(func(tx repository.Tx) error {
+		b, err := tx.Bucket(bucketRevokedTokens)
+		if err != nil {
+			return err
+		}
+		revoked = b.Get([]byte(id)) != nil
+		return nil
+	})
+	return revoked
+}
+
+// purgeRevoked 删除已经过期的吊销记录
+func (ts *TokenService) purgeRevoked() error {
+	now := uint64(time.Now().Unix())
+	return ts.store.Update(func(tx repository.Tx) error {
+		b, err := tx.Bucket(bucketRevokedTokens)
+		if err != nil {
+			return err
+		}
+
+		var expired [][]byte
+		err = b.Scan(nil, func(k, v []byte) error {
+			if len(v) != 8 || binary.BigEndian.Uint64(v) < now {
+				expired = append(expired, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestRevokeUserWithinSameSecond(t *testing.T) {
+	ts := newTestEnv(t).tokens
+	old, _, err := ts.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 修改密码：吊销旧凭证后立即签发新凭证，通常与旧凭证在同一秒内
+	if err := ts.RevokeUser("alice"); err != nil {
+		t.Fatal(err)
+	}
+	fresh, _, err := ts.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ts.Verify(old); !errors.Is(err, ErrTokenInvalid) {
+		t.Fatalf("吊销前签发的凭证校验返回 %v", err)
+	}
+	if _, err := ts.Verify(fresh); err != nil {
+		t.Fatalf("吊销后签发的凭证校验返回 %v", err)
+	}
+}
+
+func TestRevokedTokensRejected(t *testing.T) {
+	env := newTestEnv(t)
+	ts := env.tokens
+	token, _, err := ts.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 刷新后旧凭证立即失效，不能再次刷新
+	refreshed, _, claims, err := ts.Refresh(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "alice" {
+		t.Fatalf("刷新的凭证属于 %s", claims.Subject)
+	}
+	if _, err := ts.Verify(token); !errors.Is(err, ErrTokenInvalid) {
+		t.Fatalf("刷新后旧凭证校验返回 %v", err)
+	}
+	if _, _, _, err := ts.Refresh(token); !errors.Is(err, ErrTokenInvalid) {
+		t.Fatalf("再次刷新旧凭证返回 %v", err)
+	}
+
+	// 吊销记录保存在存储中，重启后仍然有效
+	if err := ts.Revoke(refreshed); err != nil {
+		t.Fatal(err)
+	}
+	restarted := NewTokenService(env.store, env.cfg.JWT)
+	if _, err := restarted.Verify(refreshed); !errors.Is(err, ErrTokenInvalid) {
+		t.Fatalf("重启后已吊销的凭证校验返回 %v", err)
+	}
+}
+
+func TestExpiredAndForgedTokensRejected(t *testing.T) {
+	env := newTestEnv(t)
+	ts := env.tokens
+	now := time.Now()
+	sign := func(secret string, claims TokenClaims) string {
+		t.Helper()
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	claims := func(issuer string, expiresAt time.Time) TokenClaims {
+		return TokenClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        "id",
+				Subject:   "alice",
+				Issuer:    issuer,
+				IssuedAt:  jwt.NewNumericDate(expiresAt.Add(-time.Hour)),
+				ExpiresAt: jwt.NewNumericDate(expiresAt),
+			},
+			IssuedAtNano: expiresAt.Add(-time.Hour).UnixNano(),
+		}
+	}
+
+	expired := sign(env.cfg.JWT.Secret, claims(env.cfg.JWT.Issuer, now.Add(-time.Minute)))
+	if _, err := ts.Verify(expired); !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("过期凭证校验返回 %v", err)
+	}
+	if _, _, _, err := ts.Refresh(expired); !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("刷新过期凭证返回 %v", err)
+	}
+
+	tests := map[string]string{
+		"签名密钥错误": sign("other", claims(env.cfg.JWT.Issuer, now.Add(time.Hour))),
+		"签发者错误":  sign(env.cfg.JWT.Secret, claims("other", now.Add(time.Hour))),
+		"没有有效期":  sign(env.cfg.JWT.Secret, TokenClaims{RegisteredClaims: jwt.RegisteredClaims{ID: "id", Subject: "alice", Issuer: env.cfg.JWT.Issuer}}),
+		"格式错误":   "not-a-token",
+	}
+	for name, token := range tests {
+		if _, err := ts.Verify(token); !errors.Is(err, ErrTokenInvalid) {
+			t.Errorf("%s: 校验返回 %v", name, err)
+		}
+	}
+}
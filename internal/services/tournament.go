@@ -0,0 +1,842 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"aigames/internal/config"
+	"aigames/internal/models"
+	"aigames/internal/repository"
+	"aigames/pkg/logger"
+	"aigames/pkg/protocol"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// bucketTournaments 比赛存储桶 key: 比赛ID
+	bucketTournaments = "tournaments"
+	// bucketTournamentRounds 比赛轮次存储桶 key: 比赛ID+"\x00"+轮次(4位)
+	bucketTournamentRounds = "tournament_rounds"
+)
+
+// TournamentService 比赛服务
+// 每轮为选手编桌并通过RoomService创建多局对战房间，定期从房间收集已结束的对战结果
+type TournamentService struct {
+	store       repository.Store
+	roomService *RoomService
+	gameService *GameService
+	presence    *PresenceService
+
+	tournaments map[string]*models.Tournament
+	rounds      map[string][]*models.TournamentRound // key: 比赛ID
+	mutex       sync.Mutex                           // 保护tournaments和rounds
+
+	checkInterval time.Duration
+	stopOnce      sync.Once
+	done          chan struct{}
+}
+
+// NewTournamentService 创建比赛服务实例并加载已有比赛
+func NewTournamentService(store repository.Store, roomService *RoomService, gameService *GameService,
+	presence *PresenceService, cfg config.GameConfig) *TournamentService {
+	checkInterval := time.Duration(cfg.TournamentCheckInterval) * time.Second
+	if checkInterval <= 0 {
+		checkInterval = 5 * time.Second
+	}
+
+	service := &TournamentService{
+		store:         store,
+		roomService:   roomService,
+		gameService:   gameService,
+		presence:      presence,
+		tournaments:   make(map[string]*models.Tournament),
+		rounds:        make(map[string][]*models.TournamentRound),
+		checkInterval: checkInterval,
+		done:          make(chan struct{}),
+	}
+	if err := service.load(); err != nil {
+		logger.Error("加载比赛失败: %v", err)
+	}
+	return service
+}
+
+// load 从数据库加载比赛和轮次
+func (ts *TournamentService) load() error {
+	return ts.store.View(func(tx repository.Tx) error {
+		tournaments, err := tx.Bucket(bucketTournaments)
+		if err != nil {
+			return err
+		}
+		err = tournaments.Scan(nil, func(k, v []byte) error {
+			var tournament models.Tournament
+			if err := json.Unmarshal(v, &tournament); err != nil {
+				logger.Warn("无法解析比赛 %s: %v", k, err)
+				return nil
+			}
+			ts.tournaments[tournament.ID] = &tournament
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// 键按比赛ID和轮次排序，同一比赛的轮次按顺序加载
+		rounds, err := tx.Bucket(bucketTournamentRounds)
+		if err != nil {
+			return err
+		}
+		return rounds.Scan(nil, func(k, v []byte) error {
+			var round models.TournamentRound
+			if err := json.Unmarshal(v, &round); err != nil {
+				logger.Warn("无法解析比赛轮次 %s: %v", k, err)
+				return nil
+			}
+			id := string(k[:len(k)-5])
+			ts.rounds[id] = append(ts.rounds[id], &round)
+			return nil
+		})
+	})
+}
+
+// Start 启动结果收集，并为尚未开局的桌重新安排开局
+func (ts *TournamentService) Start() {
+	resumed := ts.resumeTables()
+	go ts.run()
+	logger.Info("比赛服务启动: 已加载比赛=%d, 待开局=%d, 检查间隔=%v", len(ts.tournaments), resumed, ts.checkInterval)
+}
+
+// Stop 停止结果收集
+func (ts *TournamentService) Stop() {
+	ts.stopOnce.Do(func() { close(ts.done) })
+}
+
+// run 定期收集各桌结果，停机排空时暂停，不再结算和开始新的一轮
+func (ts *TournamentService) run() {
+	ticker := time.NewTicker(ts.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if ts.roomService.Draining() {
+				continue
+			}
+			ts.collectResults()
+		case <-ts.done:
+			return
+		}
+	}
+}
+
+// Create 创建比赛，创建者为组织者
+func (ts *TournamentService) Create(organizer, name string, format models.TournamentFormat,
+	rounds, handsPerTable, maxPlayers int) (*models.Tournament, error) {
+	if _, ok := models.TournamentFormatNames[format]; !ok {
+		return nil, fmt.Errorf("赛制无效")
+	}
+	if format == models.TournamentFormatSwiss && rounds < 1 {
+		return nil, fmt.Errorf("瑞士制至少需要一轮")
+	}
+	if handsPerTable < 1 {
+		return nil, fmt.Errorf("每桌至少一局")
+	}
+
+	tournament := &models.Tournament{
+		ID:            "tournament_" + uuid.New().String(),
+		Name:          name,
+		Organizer:     organizer,
+		Format:        format,
+		Rounds:        rounds,
+		HandsPerTable: handsPerTable,
+		MaxPlayers:    maxPlayers,
+		Status:        models.TournamentStatusRegistration,
+		Players:       make([]*models.TournamentPlayer, 0),
+		CreatedAt:     time.Now(),
+	}
+	if format == models.TournamentFormatKnockout {
+		tournament.Rounds = 0 // 淘汰制的轮数由人数决定
+	}
+
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	if err := ts.saveTournament(tournament); err != nil {
+		return nil, err
+	}
+	ts.tournaments[tournament.ID] = tournament
+
+	logger.Info("用户 %s 创建比赛 %s（%s）", organizer, name, models.TournamentFormatNames[format])
+	return tournament.Clone(), nil
+}
+
+// Register 报名参赛，签到开始后仍可报名
+func (ts *TournamentService) Register(id, username string) (*models.Tournament, error) {
+	return ts.update(id, func(t *models.Tournament) error {
+		if t.Status != models.TournamentStatusRegistration && t.Status != models.TournamentStatusCheckIn {
+			return fmt.Errorf("报名已截止")
+		}
+		if t.GetPlayer(username) != nil {
+			return fmt.Errorf("已报名该比赛")
+		}
+		if t.MaxPlayers > 0 && len(t.Players) >= t.MaxPlayers {
+			return fmt.Errorf("报名人数已满")
+		}
+
+		t.Players = append(t.Players, &models.TournamentPlayer{
+			Name:         username,
+			RegisteredAt: time.Now(),
+		})
+		return nil
+	})
+}
+
+// Unregister 取消报名，比赛开始后不能取消
+func (ts *TournamentService) Unregister(id, username string) (*models.Tournament, error) {
+	return ts.update(id, func(t *models.Tournament) error {
+		if t.Status != models.TournamentStatusRegistration && t.Status != models.TournamentStatusCheckIn {
+			return fmt.Errorf("比赛已开始")
+		}
+		for i, player := range t.Players {
+			if player.Name == username {
+				t.Players = append(t.Players[:i], t.Players[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("未报名该比赛")
+	})
+}
+
+// OpenCheckIn 组织者开放签到
+func (ts *TournamentService) OpenCheckIn(id, operator string) (*models.Tournament, error) {
+	tournament, err := ts.update(id, func(t *models.Tournament) error {
+		if t.Organizer != operator {
+			return fmt.Errorf("只有组织者可以操作")
+		}
+		if t.Status != models.TournamentStatusRegistration {
+			return fmt.Errorf("比赛状态不正确")
+		}
+		t.Status = models.TournamentStatusCheckIn
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ts.broadcast(tournament, protocol.RouteTournamentCheckIn, protocol.NewTournamentData(tournament))
+	return tournament, nil
+}
+
+// CheckIn 选手签到，只有签到的选手会被编桌
+func (ts *TournamentService) CheckIn(id, username string) (*models.Tournament, error) {
+	return ts.update(id, func(t *models.Tournament) error {
+		if t.Status != models.TournamentStatusCheckIn {
+			return fmt.Errorf("现在不能签到")
+		}
+		player := t.GetPlayer(username)
+		if player == nil {
+			return fmt.Errorf("未报名该比赛")
+		}
+		player.CheckedIn = true
+		return nil
+	})
+}
+
+// StartTournament 组织者开始比赛，未签到的选手不参赛
+func (ts *TournamentService) StartTournament(id, operator string) (*models.Tournament, error) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	t, exists := ts.tournaments[id]
+	if !exists {
+		return nil, fmt.Errorf("比赛不存在")
+	}
+	if t.Organizer != operator {
+		return nil, fmt.Errorf("只有组织者可以操作")
+	}
+	if t.Status != models.TournamentStatusCheckIn {
+		return nil, fmt.Errorf("比赛状态不正确")
+	}
+	if len(t.ActivePlayers()) < 2 {
+		return nil, fmt.Errorf("签到人数不足")
+	}
+
+	// 在副本上开始第一轮，失败时比赛保持签到状态，可以重新开始
+	started := t.Clone()
+	now := time.Now()
+	started.Status = models.TournamentStatusRunning
+	started.StartedAt = &now
+	if err := ts.startRound(started); err != nil {
+		return nil, err
+	}
+	ts.tournaments[id] = started
+
+	logger.Info("比赛 %s 开始，参赛 %d 人", started.Name, len(started.ActivePlayers()))
+	return started.Clone(), nil
+}
+
+// Cancel 组织者取消比赛，已创建的房间由房间回收器清理
+func (ts *TournamentService) Cancel(id, operator string) (*models.Tournament, error) {
+	tournament, err := ts.update(id, func(t *models.Tournament) error {
+		if t.Organizer != operator {
+			return fmt.Errorf("只有组织者可以操作")
+		}
+		if t.Status == models.TournamentStatusFinished || t.Status == models.TournamentStatusCancelled {
+			return fmt.Errorf("比赛状态不正确")
+		}
+		now := time.Now()
+		t.Status = models.TournamentStatusCancelled
+		t.FinishedAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, rounds, err := ts.Get(id); err == nil {
+		ts.broadcast(tournament, protocol.RouteTournamentStandings, protocol.NewTournamentDetailData(tournament, rounds))
+	}
+	return tournament, nil
+}
+
+// Get 获取比赛及各轮编桌和成绩
+func (ts *TournamentService) Get(id string) (*models.Tournament, []*models.TournamentRound, error) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	t, exists := ts.tournaments[id]
+	if !exists {
+		return nil, nil, fmt.Errorf("比赛不存在")
+	}
+
+	return t.Clone(), ts.roundsOf(id), nil
+}
+
+// roundsOf 获取比赛各轮的快照，调用方需持有锁
+func (ts *TournamentService) roundsOf(id string) []*models.TournamentRound {
+	rounds := make([]*models.TournamentRound, len(ts.rounds[id]))
+	for i, round := range ts.rounds[id] {
+		rounds[i] = round.Clone()
+	}
+	return rounds
+}
+
+// List 获取所有比赛，按创建时间倒序
+func (ts *TournamentService) List() []*models.Tournament {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	tournaments := make([]*models.Tournament, 0, len(ts.tournaments))
+	for _, t := range ts.tournaments {
+		tournaments = append(tournaments, t.Clone())
+	}
+	sort.Slice(tournaments, func(i, j int) bool {
+		if !tournaments[i].CreatedAt.Equal(tournaments[j].CreatedAt) {
+			return tournaments[i].CreatedAt.After(tournaments[j].CreatedAt)
+		}
+		return tournaments[i].ID < tournaments[j].ID
+	})
+	return tournaments
+}
+
+// update 在锁内修改比赛并保存，返回修改后的快照
+func (ts *TournamentService) update(id string, fn func(t *models.Tournament) error) (*models.Tournament, error) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	t, exists := ts.tournaments[id]
+	if !exists {
+		return nil, fmt.Errorf("比赛不存在")
+	}
+
+	// 在副本上修改，保存失败时不影响内存中的比赛
+	modified := t.Clone()
+	if err := fn(modified); err != nil {
+		return nil, err
+	}
+	if err := ts.saveTournament(modified); err != nil {
+		return nil, err
+	}
+	ts.tournaments[id] = modified
+	return modified.Clone(), nil
+}
+
+// startRound 为下一轮编桌并创建房间，调用方需持有锁
+// 失败时关闭本轮已创建的房间，比赛保持原来的轮次
+func (ts *TournamentService) startRound(t *models.Tournament) error {
+	players := t.ActivePlayers()
+	var seating [][]string
+	if t.Format == models.TournamentFormatSwiss {
+		seating = swissSeating(players, ts.rounds[t.ID])
+	} else {
+		seating = knockoutSeating(players)
+	}
+
+	round := &models.TournamentRound{
+		Number:    t.CurrentRound + 1,
+		StartedAt: time.Now(),
+	}
+	for i, names := range seating {
+		table, err := ts.openTable(t, round.Number, i+1, names)
+		if err != nil {
+			ts.closeTables(round)
+			return fmt.Errorf("创建第 %d 桌失败: %w", i+1, err)
+		}
+		round.Tables = append(round.Tables, table)
+	}
+
+	t.CurrentRound = round.Number
+	if err := ts.saveProgress(t, round); err != nil {
+		t.CurrentRound = round.Number - 1
+		ts.closeTables(round)
+		return err
+	}
+	ts.rounds[t.ID] = append(ts.rounds[t.ID], round)
+
+	for _, table := range round.Tables {
+		data := protocol.TournamentTableData{
+			TournamentID: t.ID,
+			Round:        round.Number,
+			Table:        table.Number,
+			RoomID:       table.RoomID,
+			RoomName:     tableRoomName(t, round.Number, table.Number),
+		}
+		for _, name := range table.Players {
+			if name != "" {
+				ts.presence.Push(name, protocol.RouteTournamentTable, data)
+			}
+		}
+	}
+
+	logger.Info("比赛 %s 第 %d 轮开始，共 %d 桌", t.Name, round.Number, len(round.Tables))
+	return nil
+}
+
+// openTable 为一桌选手创建多局对战房间，空座位由AI补齐，倒计时后自动开局
+// 失败时关闭已创建的房间
+func (ts *TournamentService) openTable(t *models.Tournament, roundNumber, tableNumber int, names []string) (table models.TournamentTable, err error) {
+	table.Number = tableNumber
+
+	room, err := ts.roomService.CreateRoom(tableRoomName(t, roundNumber, tableNumber), names[0], models.RoomTypePrivate,
+		uuid.New().String(), 3-len(names), t.HandsPerTable, models.NoStake, 0)
+	if err != nil {
+		return table, err
+	}
+	table.RoomID = room.ID
+	defer func() {
+		if err != nil {
+			ts.closeTable(table)
+		}
+	}()
+
+	for _, name := range names {
+		// 选手还在其他房间时先离开
+		if roomID, found := ts.roomService.FindPlayerRoom(name); found {
+			if _, closed, _ := ts.roomService.LeaveRoom(roomID, name); closed {
+				ts.gameService.StopAIControllers(roomID)
+			}
+		}
+		if _, err := ts.roomService.joinRoom(room.ID, name, "", true); err != nil {
+			return table, err
+		}
+		if err := ts.roomService.SetPlayerReady(room.ID, name, true); err != nil {
+			return table, err
+		}
+	}
+
+	// 按实际座位记录选手
+	snapshot, err := ts.roomService.GetRoom(room.ID)
+	if err != nil {
+		return table, err
+	}
+	for i, player := range snapshot.CurrentGame.Players {
+		if player != nil && !player.IsAI {
+			table.Players[i] = player.UserName
+		}
+	}
+
+	ts.scheduleTable(t.ID, roundNumber, table)
+	return table, nil
+}
+
+// scheduleTable 倒计时后为一桌开局
+func (ts *TournamentService) scheduleTable(id string, roundNumber int, table models.TournamentTable) {
+	time.AfterFunc(ts.roomService.NextHandDelay(), func() {
+		ts.startTable(id, roundNumber, table)
+	})
+}
+
+// startTable 为一桌开局，开局失败的桌作废
+// 停机排空时不开局也不作废，重启后由 resumeTables 重新安排
+func (ts *TournamentService) startTable(id string, roundNumber int, table models.TournamentTable) {
+	if ts.roomService.Draining() {
+		logger.Info("停机排空中，比赛 %s 第 %d 轮第 %d 桌重启后开局", id, roundNumber, table.Number)
+		return
+	}
+	if _, err := ts.gameService.StartGame(table.RoomID); err != nil {
+		// 检查之后才进入排空状态时，开局会因维护被拒绝
+		if ts.roomService.Draining() {
+			logger.Info("停机排空中，比赛 %s 第 %d 轮第 %d 桌重启后开局", id, roundNumber, table.Number)
+			return
+		}
+		logger.Error("比赛 %s 第 %d 轮第 %d 桌开局失败: %v", id, roundNumber, table.Number, err)
+		ts.abortTable(id, roundNumber, table.RoomID, "开局失败")
+	}
+}
+
+// resumeTables 为当前轮中还没有开局的桌重新安排开局，用于重启后恢复
+// 开局定时器不会保存，停机前尚未触发或因排空跳过的桌在这里补上
+func (ts *TournamentService) resumeTables() int {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	resumed := 0
+	for _, t := range ts.tournaments {
+		rounds := ts.rounds[t.ID]
+		if t.Status != models.TournamentStatusRunning || len(rounds) == 0 {
+			continue
+		}
+		round := rounds[len(rounds)-1]
+		for _, table := range round.Tables {
+			if table.Finished {
+				continue
+			}
+			// 房间不存在时由结果收集作废
+			room, err := ts.roomService.GetRoom(table.RoomID)
+			if err != nil || room.Session != nil {
+				continue
+			}
+			ts.scheduleTable(t.ID, round.Number, table)
+			resumed++
+		}
+	}
+	return resumed
+}
+
+// closeTables 关闭一轮中已创建的房间，用于开始一轮失败时回滚
+func (ts *TournamentService) closeTables(round *models.TournamentRound) {
+	for _, table := range round.Tables {
+		ts.closeTable(table)
+	}
+}
+
+// closeTable 关闭一桌的房间
+func (ts *TournamentService) closeTable(table models.TournamentTable) {
+	if _, err := ts.roomService.CloseRoom(table.RoomID); err != nil {
+		logger.Warn("关闭比赛房间 %s 失败: %v", table.RoomID, err)
+	}
+}
+
+// tableRoomName 比赛房间名称
+func tableRoomName(t *models.Tournament, roundNumber, tableNumber int) string {
+	return fmt.Sprintf("%s 第%d轮 第%d桌", t.Name, roundNumber, tableNumber)
+}
+
+// swissSeating 瑞士制编桌：按积分从高到低，尽量避免与之前同桌过的选手再次同桌
+func swissSeating(players []*models.TournamentPlayer, rounds []*models.TournamentRound) [][]string {
+	ordered := append([]*models.TournamentPlayer(nil), players...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Points != ordered[j].Points {
+			return ordered[i].Points > ordered[j].Points
+		}
+		return ordered[i].TableWins > ordered[j].TableWins
+	})
+
+	// 之前同桌过的选手
+	met := make(map[string]bool)
+	for _, round := range rounds {
+		for _, table := range round.Tables {
+			for _, a := range table.Players {
+				for _, b := range table.Players {
+					if a != "" && b != "" && a != b {
+						met[a+"\x00"+b] = true
+					}
+				}
+			}
+		}
+	}
+
+	seated := make([]bool, len(ordered))
+	var tables [][]string
+	for i := range ordered {
+		if seated[i] {
+			continue
+		}
+		seated[i] = true
+		table := []string{ordered[i].Name}
+
+		// 先找没同桌过的，找不到时按积分顺序补齐
+		for _, allowRematch := range []bool{false, true} {
+			for j := i + 1; j < len(ordered) && len(table) < 3; j++ {
+				if seated[j] {
+					continue
+				}
+				candidate := ordered[j].Name
+				fresh := true
+				for _, name := range table {
+					if met[name+"\x00"+candidate] {
+						fresh = false
+					}
+				}
+				if fresh || allowRematch {
+					seated[j] = true
+					table = append(table, candidate)
+				}
+			}
+		}
+		tables = append(tables, table)
+	}
+	return tables
+}
+
+// knockoutSeating 淘汰制编桌：按积分排序后蛇形分配，避免强手过早相遇
+func knockoutSeating(players []*models.TournamentPlayer) [][]string {
+	ordered := append([]*models.TournamentPlayer(nil), players...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Points > ordered[j].Points
+	})
+
+	count := (len(ordered) + 2) / 3
+	tables := make([][]string, count)
+	for i, player := range ordered {
+		index := i % count
+		if (i/count)%2 == 1 {
+			index = count - 1 - index
+		}
+		tables[index] = append(tables[index], player.Name)
+	}
+	return tables
+}
+
+// collectResults 从房间收集已结束的各桌结果，一轮全部结束后开始下一轮或结束比赛
+func (ts *TournamentService) collectResults() {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	for _, t := range ts.tournaments {
+		if t.Status != models.TournamentStatusRunning {
+			continue
+		}
+		rounds := ts.rounds[t.ID]
+		if len(rounds) == 0 {
+			continue
+		}
+		round := rounds[len(rounds)-1]
+
+		// 上一轮已结算但下一轮没能开始，重试开始下一轮
+		if round.FinishedAt != nil {
+			if err := ts.startRound(t); err != nil {
+				logger.Error("比赛 %s 开始第 %d 轮失败: %v", t.Name, round.Number+1, err)
+			}
+			continue
+		}
+
+		changed := false
+		for i := range round.Tables {
+			table := &round.Tables[i]
+			if table.Finished {
+				continue
+			}
+
+			room, err := ts.roomService.GetRoom(table.RoomID)
+			if err != nil {
+				table.Finished = true
+				table.Void = true
+				table.Note = "房间已关闭"
+				changed = true
+				continue
+			}
+			if room.Session == nil || room.Session.IsActive() {
+				continue
+			}
+
+			table.Scores = room.Session.Totals
+			if room.Session.EndReason != "已完成全部对局" {
+				table.Note = room.Session.EndReason
+			}
+			// 一局都没有完成时没有成绩，不能按全零的得分决出第一名
+			table.Void = room.Session.HandsPlayed == 0
+			table.Finished = true
+			changed = true
+		}
+
+		if !changed {
+			continue
+		}
+		if err := ts.saveRound(t.ID, round); err != nil {
+			logger.Error("保存比赛轮次失败: %v", err)
+			continue
+		}
+		if round.IsFinished() {
+			ts.finishRound(t, round)
+		}
+	}
+}
+
+// abortTable 开局失败的桌直接结束并作废，不计分、不淘汰
+// 按房间查找该桌，开始一轮失败回滚后同一轮次重新编桌时不会误伤新的桌
+func (ts *TournamentService) abortTable(id string, roundNumber int, roomID, note string) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	rounds := ts.rounds[id]
+	if len(rounds) < roundNumber {
+		return
+	}
+	round := rounds[roundNumber-1]
+	var table *models.TournamentTable
+	for i := range round.Tables {
+		if round.Tables[i].RoomID == roomID {
+			table = &round.Tables[i]
+		}
+	}
+	if table == nil || table.Finished {
+		return
+	}
+	table.Finished = true
+	table.Void = true
+	table.Note = note
+	if err := ts.saveRound(id, round); err != nil {
+		logger.Error("保存比赛轮次失败: %v", err)
+	}
+}
+
+// finishRound 结算一轮：累计得分、记录桌第一，淘汰制淘汰非第一名，然后开始下一轮或结束比赛
+// 作废的桌不计分，淘汰制中该桌选手全部晋级；下一轮开始失败时由collectResults重试
+// 调用方需持有锁
+func (ts *TournamentService) finishRound(t *models.Tournament, round *models.TournamentRound) {
+	now := time.Now()
+	round.FinishedAt = &now
+
+	for i := range round.Tables {
+		table := &round.Tables[i]
+		if table.Void {
+			continue
+		}
+
+		best := -1
+		for seat, name := range table.Players {
+			if name == "" {
+				continue
+			}
+			if player := t.GetPlayer(name); player != nil {
+				player.Points += table.Scores[seat]
+			}
+			if best < 0 || table.Scores[seat] > table.Scores[best] {
+				best = seat
+			}
+		}
+		if best < 0 {
+			continue
+		}
+
+		table.Winner = table.Players[best]
+		if winner := t.GetPlayer(table.Winner); winner != nil {
+			winner.TableWins++
+		}
+
+		if t.Format == models.TournamentFormatKnockout {
+			for _, name := range table.Players {
+				if player := t.GetPlayer(name); player != nil && name != table.Winner {
+					player.Eliminated = true
+					player.EliminatedIn = round.Number
+				}
+			}
+		}
+	}
+
+	remaining := t.ActivePlayers()
+	finished := false
+	switch t.Format {
+	case models.TournamentFormatSwiss:
+		finished = round.Number >= t.Rounds
+	case models.TournamentFormatKnockout:
+		finished = len(remaining) <= 1
+	}
+
+	if finished {
+		t.Status = models.TournamentStatusFinished
+		t.FinishedAt = &now
+		if standings := t.Standings(); len(standings) > 0 {
+			t.Champion = standings[0].Name
+		}
+		logger.Info("比赛 %s 结束，冠军: %s", t.Name, t.Champion)
+	}
+
+	if err := ts.saveRound(t.ID, round); err != nil {
+		logger.Error("保存比赛轮次失败: %v", err)
+	}
+	if err := ts.saveTournament(t); err != nil {
+		logger.Error("保存比赛失败: %v", err)
+	}
+
+	ts.broadcast(t, protocol.RouteTournamentStandings, protocol.NewTournamentDetailData(t, ts.roundsOf(t.ID)))
+
+	if !finished {
+		if err := ts.startRound(t); err != nil {
+			logger.Error("比赛 %s 开始第 %d 轮失败，稍后重试: %v", t.Name, round.Number+1, err)
+		}
+	}
+}
+
+// broadcast 向比赛的所有选手推送消息
+func (ts *TournamentService) broadcast(t *models.Tournament, route string, v interface{}) {
+	for _, player := range t.Players {
+		ts.presence.Push(player.Name, route, v)
+	}
+}
+
+// saveTournament 保存比赛
+func (ts *TournamentService) saveTournament(t *models.Tournament) error {
+	return ts.store.Update(func(tx repository.Tx) error {
+		return putTournament(tx, t)
+	})
+}
+
+// saveRound 保存比赛轮次
+func (ts *TournamentService) saveRound(id string, round *models.TournamentRound) error {
+	return ts.store.Update(func(tx repository.Tx) error {
+		return putRound(tx, id, round)
+	})
+}
+
+// saveProgress 在一个事务中保存比赛和新的一轮
+func (ts *TournamentService) saveProgress(t *models.Tournament, round *models.TournamentRound) error {
+	return ts.store.Update(func(tx repository.Tx) error {
+		if err := putRound(tx, t.ID, round); err != nil {
+			return err
+		}
+		return putTournament(tx, t)
+	})
+}
+
+// putTournament 在事务中写入比赛
+func putTournament(tx repository.Tx, t *models.Tournament) error {
+	b, err := tx.Bucket(bucketTournaments)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("序列化比赛失败: %w", err)
+	}
+	return b.Put([]byte(t.ID), encoded)
+}
+
+// putRound 在事务中写入比赛轮次
+func putRound(tx repository.Tx, id string, round *models.TournamentRound) error {
+	b, err := tx.Bucket(bucketTournamentRounds)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := json.Marshal(round)
+	if err != nil {
+		return fmt.Errorf("序列化比赛轮次失败: %w", err)
+	}
+	return b.Put([]byte(fmt.Sprintf("%s\x00%04d", id, round.Number)), encoded)
+}
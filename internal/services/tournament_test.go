@@ -0,0 +1,288 @@
+package services
+
+import (
+	"testing"
+
+	"aigames/internal/models"
+)
+
+// checkedInTournament 创建比赛，选手全部报名并签到
+func checkedInTournament(t *testing.T, ts *TournamentService, format models.TournamentFormat, players ...string) string {
+	t.Helper()
+	tournament, err := ts.Create("organizer", "test", format, 2, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ts.OpenCheckIn(tournament.ID, "organizer"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range players {
+		if _, err := ts.Register(tournament.ID, name); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ts.CheckIn(tournament.ID, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tournament.ID
+}
+
+// finishTable 结束一桌的多局对战并设置各座位的累计得分
+func finishTable(t *testing.T, rs *RoomService, table models.TournamentTable, totals [3]int) {
+	t.Helper()
+	err := rs.Dispatch(table.RoomID, "finish_session", func(room *models.Room) error {
+		room.Session = &models.RoomSession{
+			Status:      models.SessionStatusFinished,
+			HandsPlayed: 1,
+			Totals:      totals,
+			EndReason:   "已完成全部对局",
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// winTable 结束一桌，winner 得2分，其他选手各扣1分
+func winTable(t *testing.T, rs *RoomService, table models.TournamentTable, winner string) {
+	t.Helper()
+	var totals [3]int
+	for seat, name := range table.Players {
+		switch name {
+		case "":
+		case winner:
+			totals[seat] = 2
+		default:
+			totals[seat] = -1
+		}
+	}
+	finishTable(t, rs, table, totals)
+}
+
+func TestStartTournamentRollsBackOnFailure(t *testing.T) {
+	env := newTestEnv(t)
+	ts, rs := env.tournaments, env.rooms
+	id := checkedInTournament(t, ts, models.TournamentFormatSwiss, "a", "b", "c")
+
+	rs.SetDraining(true)
+	if _, err := ts.StartTournament(id, "organizer"); err == nil {
+		t.Fatal("无法创建房间时比赛开始了")
+	}
+	tournament, rounds, err := ts.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tournament.Status != models.TournamentStatusCheckIn || tournament.StartedAt != nil || len(rounds) != 0 {
+		t.Fatalf("开始失败后比赛状态为 %v，轮次 %d", tournament.Status, len(rounds))
+	}
+	if rooms := rs.Snapshots(); len(rooms) != 0 {
+		t.Fatalf("开始失败后留下了 %d 个房间", len(rooms))
+	}
+
+	rs.SetDraining(false)
+	tournament, err = ts.StartTournament(id, "organizer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tournament.Status != models.TournamentStatusRunning || tournament.CurrentRound != 1 {
+		t.Fatalf("重新开始后比赛状态为 %v，轮次 %d", tournament.Status, tournament.CurrentRound)
+	}
+}
+
+func TestKnockoutVoidTableAdvancesEveryone(t *testing.T) {
+	env := newTestEnv(t)
+	ts, rs := env.tournaments, env.rooms
+	id := checkedInTournament(t, ts, models.TournamentFormatKnockout, "a", "b", "c", "d")
+	if _, err := ts.StartTournament(id, "organizer"); err != nil {
+		t.Fatal(err)
+	}
+	_, rounds, err := ts.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rounds[0].Tables) != 2 {
+		t.Fatalf("第一轮有 %d 桌", len(rounds[0].Tables))
+	}
+
+	// 第一桌房间被关闭，第二桌正常结束
+	closed, played := rounds[0].Tables[0], rounds[0].Tables[1]
+	if _, err := rs.CloseRoom(closed.RoomID); err != nil {
+		t.Fatal(err)
+	}
+	winner := played.Players[0]
+	if winner == "" {
+		winner = played.Players[1]
+	}
+	winTable(t, rs, played, winner)
+
+	// 下一轮开始失败时保持进行中，之后重试
+	rs.SetDraining(true)
+	ts.collectResults()
+	tournament, rounds, err := ts.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tournament.Status != models.TournamentStatusRunning || len(rounds) != 1 || rounds[0].FinishedAt == nil {
+		t.Fatalf("第一轮结算后比赛状态为 %v，轮次 %d", tournament.Status, len(rounds))
+	}
+	if !rounds[0].Tables[0].Void || rounds[0].Tables[0].Winner != "" {
+		t.Fatalf("关闭的桌没有作废: %+v", rounds[0].Tables[0])
+	}
+
+	for _, name := range closed.Players {
+		if name != "" && tournament.GetPlayer(name).Eliminated {
+			t.Fatalf("作废桌的选手 %s 被淘汰", name)
+		}
+	}
+	for _, name := range played.Players {
+		if name != "" && tournament.GetPlayer(name).Eliminated != (name != winner) {
+			t.Fatalf("选手 %s 的淘汰状态错误", name)
+		}
+	}
+
+	rs.SetDraining(false)
+	ts.collectResults()
+	tournament, rounds, err = ts.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tournament.CurrentRound != 2 || len(rounds) != 2 || len(rounds[1].Tables) != 1 {
+		t.Fatalf("重试后比赛轮次为 %d", tournament.CurrentRound)
+	}
+}
+
+func TestDrainingDoesNotAbortTables(t *testing.T) {
+	env := newTestEnv(t)
+	ts, rs := env.tournaments, env.rooms
+	id := checkedInTournament(t, ts, models.TournamentFormatSwiss, "a", "b", "c")
+	if _, err := ts.StartTournament(id, "organizer"); err != nil {
+		t.Fatal(err)
+	}
+	_, rounds, err := ts.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := rounds[0].Tables[0]
+
+	rs.SetDraining(true)
+	ts.startTable(id, 1, table)
+	_, rounds, err = ts.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rounds[0].Tables[0].Finished || rounds[0].Tables[0].Void {
+		t.Fatalf("排空时开局被拒绝的桌被作废: %+v", rounds[0].Tables[0])
+	}
+
+	// 重启后为还没有开局的桌重新安排开局
+	if resumed := ts.resumeTables(); resumed != 1 {
+		t.Fatalf("重新安排了 %d 桌", resumed)
+	}
+}
+
+func TestKnockoutEliminatesAllButTableWinners(t *testing.T) {
+	env := newTestEnv(t)
+	ts, rs := env.tournaments, env.rooms
+	id := checkedInTournament(t, ts, models.TournamentFormatKnockout, "a", "b", "c", "d")
+	// 报名但没有签到的选手不参加比赛
+	if _, err := ts.Register(id, "e"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ts.StartTournament(id, "organizer"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, rounds, err := ts.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var winners []string
+	for _, table := range rounds[0].Tables {
+		for _, name := range table.Players {
+			if name == "e" {
+				t.Fatal("没有签到的选手被编入桌")
+			}
+		}
+		winner := table.Players[0]
+		if winner == "" {
+			winner = table.Players[1]
+		}
+		winners = append(winners, winner)
+		winTable(t, rs, table, winner)
+	}
+	ts.collectResults()
+
+	tournament, rounds, err := ts.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tournament.CurrentRound != 2 || len(rounds) != 2 || len(rounds[1].Tables) != 1 {
+		t.Fatalf("第一轮结束后比赛轮次为 %d", tournament.CurrentRound)
+	}
+	for _, player := range tournament.Players {
+		won := player.Name == winners[0] || player.Name == winners[1]
+		if player.Name != "e" && player.Eliminated == won {
+			t.Fatalf("选手 %s 的淘汰状态为 %v", player.Name, player.Eliminated)
+		}
+		if player.Eliminated && player.EliminatedIn != 1 {
+			t.Fatalf("选手 %s 在第 %d 轮被淘汰", player.Name, player.EliminatedIn)
+		}
+	}
+	if active := tournament.ActivePlayers(); len(active) != 2 {
+		t.Fatalf("第二轮有 %d 名选手", len(active))
+	}
+
+	// 决赛的第一名成为冠军，另一名选手在第二轮被淘汰
+	winTable(t, rs, rounds[1].Tables[0], winners[1])
+	ts.collectResults()
+	tournament, _, err = ts.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tournament.Status != models.TournamentStatusFinished || tournament.Champion != winners[1] {
+		t.Fatalf("比赛状态为 %v，冠军为 %s", tournament.Status, tournament.Champion)
+	}
+	if runnerUp := tournament.GetPlayer(winners[0]); !runnerUp.Eliminated || runnerUp.EliminatedIn != 2 {
+		t.Fatalf("决赛负者的淘汰状态为 %v，轮次 %d", runnerUp.Eliminated, runnerUp.EliminatedIn)
+	}
+	standings := tournament.Standings()
+	if len(standings) != 4 || standings[0].Name != winners[1] || standings[1].Name != winners[0] {
+		t.Fatalf("排名错误: %s, %s", standings[0].Name, standings[1].Name)
+	}
+}
+
+func TestTableWithoutHandsIsVoid(t *testing.T) {
+	env := newTestEnv(t)
+	ts, rs := env.tournaments, env.rooms
+	id := checkedInTournament(t, ts, models.TournamentFormatKnockout, "a", "b", "c")
+	if _, err := ts.StartTournament(id, "organizer"); err != nil {
+		t.Fatal(err)
+	}
+	_, rounds, err := ts.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 对战结束但一局都没有完成，不能按全零的得分淘汰选手
+	err = rs.Dispatch(rounds[0].Tables[0].RoomID, "finish_session", func(room *models.Room) error {
+		room.Session = &models.RoomSession{Status: models.SessionStatusFinished, EndReason: "玩家离开"}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts.collectResults()
+
+	tournament, rounds, err := ts.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := rounds[0].Tables[0]
+	if !table.Void || table.Winner != "" || table.Note != "玩家离开" {
+		t.Fatalf("没有完成对局的桌: %+v", table)
+	}
+	if active := tournament.ActivePlayers(); len(active) != 3 {
+		t.Fatalf("作废桌之后剩余 %d 名选手", len(active))
+	}
+}
@@ -2,58 +2,115 @@ package services
 
 import (
 	"crypto/md5"
-	"encoding/json"
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"time"
 
+	"aigames/internal/config"
 	"aigames/internal/models"
+	"aigames/internal/repository"
 
-	"go.etcd.io/bbolt"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 密码哈希算法
+const (
+	PasswordAlgoMD5    = "md5"    // 旧版无盐MD5，仅用于校验和迁移
+	PasswordAlgoBcrypt = "bcrypt" // 当前使用的算法
+
+	// PasswordVersion 当前密码哈希方案版本
+	PasswordVersion = 1
+)
+
+// 用户操作错误
+var (
+	ErrUserNotFound      = repository.ErrUserNotFound
+	ErrPasswordIncorrect = errors.New("原密码错误")
 )
 
 // UserService 用户服务结构体
 type UserService struct {
-	db *bbolt.DB
+	store      repository.Store
+	users      repository.UserRepository
+	bcryptCost int
+	lockout    lockoutPolicy // 登录失败锁定策略
+	addresses  *addressGuard // 来源地址登录失败统计
+
+	deleteHooks []func(tx repository.Tx, name, alias string) error // 注销用户时清理其他数据的回调
 }
 
 // NewUserService 创建用户服务实例
-func NewUserService(db *bbolt.DB) *UserService {
-	return &UserService{db: db}
+func NewUserService(store repository.Store, security config.SecurityConfig) *UserService {
+	cost := security.BcryptCost
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &UserService{
+		store:      store,
+		users:      store.Users(),
+		bcryptCost: cost,
+		lockout:    newLockoutPolicy(security),
+		addresses:  newAddressGuard(),
+	}
+}
+
+// HashPassword 使用bcrypt加密密码
+func (s *UserService) HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), s.bcryptCost)
+	if err != nil {
+		return "", fmt.Errorf("密码加密失败: %w", err)
+	}
+	return string(hash), nil
 }
 
-// HashPassword 加密密码
-func (s *UserService) HashPassword(password string) string {
-	hash := md5.Sum([]byte(password))
-	return fmt.Sprintf("%x", hash)
+// SetPassword 设置用户密码，同时记录哈希算法和版本
+func (s *UserService) SetPassword(user *models.User, password string) error {
+	hash, err := s.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	user.Password = hash
+	user.PasswordAlgo = PasswordAlgoBcrypt
+	user.PasswordVersion = PasswordVersion
+	return nil
 }
 
-// SaveUser 保存用户到数据库
+// VerifyPassword 校验密码，比较过程耗时与密码内容无关
+func (s *UserService) VerifyPassword(user *models.User, password string) bool {
+	switch user.PasswordAlgo {
+	case PasswordAlgoBcrypt:
+		return bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) == nil
+	case "", PasswordAlgoMD5:
+		hash := md5.Sum([]byte(password))
+		legacy := fmt.Sprintf("%x", hash)
+		return subtle.ConstantTimeCompare([]byte(user.Password), []byte(legacy)) == 1
+	default:
+		return false
+	}
+}
+
+// NeedsRehash 检查密码哈希是否需要升级（旧算法、旧版本或强度变化）
+func (s *UserService) NeedsRehash(user *models.User) bool {
+	if user.PasswordAlgo != PasswordAlgoBcrypt || user.PasswordVersion != PasswordVersion {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(user.Password))
+	return err != nil || cost != s.bcryptCost
+}
+
+// SaveUser 保存用户到数据库，没有ID的用户会分配新的UUID
 func (s *UserService) SaveUser(user *models.User) error {
-	return s.db.Update(func(tx *bbolt.Tx) error {
-		b := tx.Bucket([]byte("users"))
-		userJSON, err := json.Marshal(user)
-		if err != nil {
-			return err
-		}
-		return b.Put([]byte(user.Name), userJSON)
-	})
+	if user.ID == "" {
+		user.ID = uuid.New().String()
+	}
+	return s.users.Save(user)
 }
 
 // GetUser 从数据库获取用户
 func (s *UserService) GetUser(name string) (*models.User, error) {
-	var user models.User
-	err := s.db.View(func(tx *bbolt.Tx) error {
-		b := tx.Bucket([]byte("users"))
-		userData := b.Get([]byte(name))
-		if userData == nil {
-			return fmt.Errorf("用户不存在")
-		}
-		return json.Unmarshal(userData, &user)
-	})
-	if err != nil {
-		return nil, err
-	}
-	return &user, nil
+	return s.users.Get(name)
 }
 
 // UserExists 检查用户是否存在
@@ -71,3 +128,141 @@ func (s *UserService) UpdateLastLogin(name string) error {
 	user.LastLoginAt = time.Now()
 	return s.SaveUser(user)
 }
+
+// ChangePassword 校验原密码后设置新密码
+func (s *UserService) ChangePassword(name, oldPassword, newPassword string) error {
+	user, err := s.GetUser(name)
+	if err != nil {
+		return err
+	}
+	if !s.VerifyPassword(user, oldPassword) {
+		return ErrPasswordIncorrect
+	}
+
+	// 哈希计算较慢，放在事务之外
+	if err := s.SetPassword(user, newPassword); err != nil {
+		return err
+	}
+	return s.updateUser(name, func(stored *models.User) error {
+		stored.Password = user.Password
+		stored.PasswordAlgo = user.PasswordAlgo
+		stored.PasswordVersion = user.PasswordVersion
+		return nil
+	})
+}
+
+// ResetPassword 不校验原密码直接设置新密码并解除登录锁定，供运维使用
+func (s *UserService) ResetPassword(name, newPassword string) error {
+	user, err := s.GetUser(name)
+	if err != nil {
+		return err
+	}
+	if err := s.SetPassword(user, newPassword); err != nil {
+		return err
+	}
+	return s.updateUser(name, func(stored *models.User) error {
+		stored.Password = user.Password
+		stored.PasswordAlgo = user.PasswordAlgo
+		stored.PasswordVersion = user.PasswordVersion
+		stored.FailedLogins = 0
+		stored.LastFailedAt = nil
+		stored.LockCount = 0
+		stored.LockedUntil = nil
+		return nil
+	})
+}
+
+// Ban 封禁用户，封禁后不能登录，供运维使用
+func (s *UserService) Ban(name, reason string) error {
+	return s.updateUser(name, func(user *models.User) error {
+		now := time.Now()
+		user.Banned = true
+		user.BanReason = reason
+		user.BannedAt = &now
+		return nil
+	})
+}
+
+// Unban 解除用户封禁，供运维使用
+func (s *UserService) Unban(name string) error {
+	return s.updateUser(name, func(user *models.User) error {
+		user.Banned = false
+		user.BanReason = ""
+		user.BannedAt = nil
+		return nil
+	})
+}
+
+// UpdateProfile 修改用户资料，参数为空表示保持不变
+func (s *UserService) UpdateProfile(name string, nickname, avatar *string, age *int) (*models.User, error) {
+	var updated models.User
+	err := s.updateUser(name, func(user *models.User) error {
+		if nickname != nil {
+			user.Nickname = *nickname
+		}
+		if avatar != nil {
+			user.Avatar = *avatar
+		}
+		if age != nil {
+			user.Age = *age
+		}
+		updated = *user
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// OnDelete 注册注销用户时的回调，用于删除或匿名化其他服务保存的该用户数据，只在启动时注册
+// 回调在注销用户的事务中执行，参数为事务、用户名和替换用的匿名名称，返回错误时注销失败、所有修改回滚
+func (s *UserService) OnDelete(fn func(tx repository.Tx, name, alias string) error) {
+	s.deleteHooks = append(s.deleteHooks, fn)
+}
+
+// DeleteUser 在一个事务中删除用户，历史对局和其他服务保存的数据中的用户名替换为匿名名称，返回该匿名名称
+func (s *UserService) DeleteUser(name string) (string, error) {
+	var alias string
+	err := s.store.Update(func(tx repository.Tx) error {
+		user, err := tx.GetUser(name)
+		if err != nil {
+			return err
+		}
+		if user.ID == "" {
+			user.ID = uuid.New().String()
+		}
+		alias = models.AnonymousName(user.ID)
+
+		if err := tx.DeleteUser(name); err != nil {
+			return err
+		}
+		if _, err := tx.UpdateGames(func(game *models.Game) bool {
+			return anonymizeGame(game, name, alias)
+		}); err != nil {
+			return fmt.Errorf("匿名化历史对局失败: %w", err)
+		}
+		for _, fn := range s.deleteHooks {
+			if err := fn(tx, name, alias); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return alias, nil
+}
+
+// anonymizeGame 将对局中的用户名替换为匿名名称，返回是否有修改
+func anonymizeGame(game *models.Game, name, alias string) bool {
+	changed := false
+	for _, player := range game.Players {
+		if player != nil && player.UserName == name {
+			player.UserName = alias
+			changed = true
+		}
+	}
+	return changed
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"aigames/internal/models"
+	"aigames/internal/repository"
+)
+
+func TestSaveUserAssignsIDAndReturnsCopies(t *testing.T) {
+	us := newTestEnv(t).users
+	if err := us.SaveUser(&models.User{Name: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := us.GetUser("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID == "" {
+		t.Fatal("保存用户时没有分配ID")
+	}
+	user.Nickname = "changed"
+
+	again, _ := us.GetUser("alice")
+	if again.Nickname != "" {
+		t.Fatal("修改读取到的用户影响了已保存的用户")
+	}
+	if _, err := us.GetUser("bob"); err == nil || err.Error() != "用户不存在" {
+		t.Fatalf("获取不存在的用户返回 %v", err)
+	}
+	if err := us.Ban("bob", "test"); err == nil || err.Error() != "用户不存在" {
+		t.Fatalf("封禁不存在的用户返回 %v", err)
+	}
+}
+
+func TestDeleteUserAnonymizesGames(t *testing.T) {
+	env := newTestEnv(t)
+	us, store := env.users, env.store
+	if err := us.SaveUser(&models.User{Name: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	user, _ := us.GetUser("alice")
+	now := time.Now()
+	game := &models.Game{ID: "g1", StartedAt: &now}
+	game.Players[0] = &models.GamePlayer{UserName: "alice"}
+	game.Players[1] = &models.GamePlayer{UserName: "bob"}
+	if err := store.Games().Save(game); err != nil {
+		t.Fatal(err)
+	}
+
+	var hooked []string
+	us.OnDelete(func(tx repository.Tx, name, alias string) error {
+		hooked = append(hooked, name, alias)
+		return nil
+	})
+
+	alias, err := us.DeleteUser("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alias != models.AnonymousName(user.ID) {
+		t.Fatalf("匿名名称为 %s，应为 %s", alias, models.AnonymousName(user.ID))
+	}
+	if len(hooked) != 2 || hooked[0] != "alice" || hooked[1] != alias {
+		t.Fatalf("注销回调的参数为 %v", hooked)
+	}
+	if us.UserExists("alice") {
+		t.Fatal("注销后用户仍然存在")
+	}
+
+	archived, err := store.Games().Get("g1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archived.Players[0].UserName != alias || archived.Players[1].UserName != "bob" {
+		t.Fatalf("历史对局中的玩家为 %s, %s", archived.Players[0].UserName, archived.Players[1].UserName)
+	}
+}
+
+func TestDeleteUserRollsBackWhenHookFails(t *testing.T) {
+	env := newTestEnv(t)
+	us, store := env.users, env.store
+	if err := us.SaveUser(&models.User{Name: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	game := &models.Game{ID: "g1"}
+	game.Players[0] = &models.GamePlayer{UserName: "alice"}
+	if err := store.Games().Save(game); err != nil {
+		t.Fatal(err)
+	}
+
+	us.OnDelete(func(tx repository.Tx, name, alias string) error {
+		return fmt.Errorf("清理失败")
+	})
+	if _, err := us.DeleteUser("alice"); err == nil {
+		t.Fatal("回调失败时注销应该失败")
+	}
+
+	if !us.UserExists("alice") {
+		t.Fatal("注销失败后用户被删除")
+	}
+	archived, _ := store.Games().Get("g1")
+	if archived.Players[0].UserName != "alice" {
+		t.Fatalf("注销失败后历史对局被修改为 %s", archived.Players[0].UserName)
+	}
+}
+
+func TestDeleteUserHooksCommitTogether(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUsers(t, "alice", "bob")
+	env.makeFriends(t, "alice", "bob")
+	if _, _, err := env.coins.ClaimDailyGrant("alice"); err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := env.tokens.Issue("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 最后一个回调失败时，凭证、好友关系和金币流水随注销一起回滚
+	fail := true
+	env.users.OnDelete(func(tx repository.Tx, name, alias string) error {
+		if fail {
+			return fmt.Errorf("清理失败")
+		}
+		return nil
+	})
+	if _, err := env.users.DeleteUser("alice"); err == nil {
+		t.Fatal("回调失败时注销应该失败")
+	}
+	if _, err := env.tokens.Verify(token); err != nil {
+		t.Fatalf("注销失败后凭证校验返回 %v", err)
+	}
+	if !env.friends.AreFriends("alice", "bob") {
+		t.Fatal("注销失败后好友关系被删除")
+	}
+	if _, total, err := env.coins.Ledger("alice", 0, 10); err != nil || total != 1 {
+		t.Fatalf("注销失败后流水为 %d 条, %v", total, err)
+	}
+
+	fail = false
+	if _, err := env.users.DeleteUser("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.tokens.Verify(token); !errors.Is(err, ErrTokenInvalid) {
+		t.Fatalf("注销后凭证校验返回 %v", err)
+	}
+	if env.friends.AreFriends("alice", "bob") {
+		t.Fatal("注销后好友关系仍然存在")
+	}
+	if _, total, err := env.coins.Ledger("alice", 0, 10); err != nil || total != 0 {
+		t.Fatalf("注销后流水为 %d 条, %v", total, err)
+	}
+}
+
+func TestChangePasswordErrors(t *testing.T) {
+	us := newTestEnv(t).users
+	user := &models.User{Name: "alice"}
+	if err := us.SetPassword(user, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := us.SaveUser(user); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := us.ChangePassword("alice", "wrong", "new"); !errors.Is(err, ErrPasswordIncorrect) {
+		t.Fatalf("原密码错误时返回 %v", err)
+	}
+	if err := us.ChangePassword("bob", "secret", "new"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("用户不存在时返回 %v", err)
+	}
+	if err := us.ChangePassword("alice", "secret", "new"); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := us.GetUser("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !us.VerifyPassword(changed, "new") {
+		t.Fatal("新密码无法通过校验")
+	}
+}
@@ -1,17 +1,29 @@
 package main
 
 import (
+	"aigames/internal/admin"
 	"aigames/internal/config"
 	"aigames/internal/database"
 	"aigames/internal/handlers"
+	"aigames/internal/health"
+	"aigames/internal/models"
+	"aigames/internal/repository"
 	"aigames/internal/services"
 	"aigames/pkg/logger"
+	"aigames/pkg/metrics"
 	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 
 	"github.com/lonng/nano"
+	"github.com/lonng/nano/cluster"
 	"github.com/lonng/nano/component"
+	"github.com/lonng/nano/pipeline"
+	"github.com/lonng/nano/scheduler"
 	jsonSerializer "github.com/lonng/nano/serialize/json"
+	"github.com/lonng/nano/session"
 )
 
 func main() {
@@ -34,6 +46,7 @@ func main() {
 	} else {
 		logger.SetLevel(logger.INFO)
 	}
+	logger.SetRecentErrorSize(cfg.Admin.ErrorLogSize)
 
 	logger.Info("启动AI游戏服务器...")
 	logger.Info("配置信息: 模式=%s, 端口=%d", cfg.Server.Mode, cfg.Server.Port)
@@ -50,37 +63,187 @@ func main() {
 	}
 	defer db.Close()
 
-	// 创建服务实例
-	userService := services.NewUserService(db.GetBoltDB())
-	roomService := services.NewRoomService(db.GetBoltDB())
-	gameService := services.NewGameService(db.GetBoltDB(), roomService)
+	// 升级旧版本的数据，迁移前自动备份
+	report, err := db.Migrate(cfg.Database.BackupDir, false)
+	if err != nil {
+		logger.Fatal("数据迁移失败: %v", err)
+	}
+	if report.From != report.To {
+		for _, result := range report.Results {
+			logger.Info("数据迁移到版本 %d（%s）: 修改 %d 条记录", result.Version, result.Description, len(result.Changes))
+		}
+		logger.Info("数据已从版本 %d 迁移到 %d，迁移前的备份: %s", report.From, report.To, report.Backup)
+	}
+
+	// 创建存储和服务实例
+	store := repository.NewBoltStore(db.GetBoltDB())
+	userService := services.NewUserService(store, cfg.Security)
+	tokenService := services.NewTokenService(store, cfg.JWT)
+	roomService := services.NewRoomService(store, cfg.Game)
+	aiPool := services.NewAIWorkerPool(cfg.AI)
+	aiPool.Start()
+	defer aiPool.Stop()
+	gameService := services.NewGameService(store, roomService, aiPool)
+	presence := services.NewPresenceService()
+	friendService := services.NewFriendService(store, presence)
+	roomService.SetFriendCheck(friendService.AreFriends)
+	coinService := services.NewCoinService(store, presence, cfg.Economy)
+	roomService.SetBalanceSource(coinService.BalanceOf)
+	userService.OnDelete(tokenService.RevokeDeletedUser)
+	userService.OnDelete(friendService.RemoveUser)
+	userService.OnDelete(coinService.DeleteAccount)
+	achievementService, err := services.NewAchievementService(store, coinService, presence, cfg.Game)
+	if err != nil {
+		logger.Fatal("加载成就和任务失败: %v", err)
+	}
+	userService.OnDelete(achievementService.DeleteProgress)
+	gameService.OnGameFinished(func(room *models.Room, game *models.Game) {
+		changes, err := coinService.Settle(room, game)
+		if err != nil {
+			logger.Error("游戏 %s 金币结算失败: %v", game.ID, err)
+		}
+		achievementService.Record(room, game, changes)
+	})
+	// 恢复上次停机或崩溃时进行中的游戏，需要在房间回收前执行
+	gameRecovery := services.NewGameRecovery(roomService, gameService, presence, cfg.Game)
+	gameRecovery.Start()
+	defer gameRecovery.Stop()
+	roomReaper := services.NewRoomReaper(roomService, gameService, presence, cfg.Game)
+	roomReaper.Start()
+	defer roomReaper.Stop()
+	tournamentService := services.NewTournamentService(store, roomService, gameService, presence, cfg.Game)
+	tournamentService.Start()
+	defer tournamentService.Stop()
+	rateLimiter := services.NewRateLimiter(cfg.RateLimit)
+	rateLimiter.Start()
+	defer rateLimiter.Stop()
+	adminServer := admin.NewServer(cfg.Admin, userService, tokenService, roomService, gameService, roomReaper, presence)
+	adminServer.Start()
+	defer adminServer.Stop()
+	services.RegisterMetrics(roomService, gameService, presence)
+	db.RegisterMetrics()
+	handlerMetrics := handlers.NewHandlerMetrics()
+
+	// 就绪检查：数据库可写、nano端口正在接受连接、未处于排空状态
+	healthChecker := health.NewChecker()
+	healthChecker.Add("bolt", db.CheckWritable)
+	healthChecker.Add("nano", health.DialCheck("127.0.0.1:"+strconv.Itoa(cfg.Server.Port)))
+
+	// 停机排空：拒绝新的房间和游戏，就绪检查不再通过
+	drainService := services.NewDrainService(roomService, gameService, presence, cfg.Server)
+	drainService.OnDrain(func() { healthChecker.SetDraining(true) })
 
 	// 启动静态文件服务器为前端页面提供服务
 	go func() {
 		http.Handle("/", http.FileServer(http.Dir("./web/")))
+		healthChecker.Register(http.DefaultServeMux)
 		logger.Info("静态文件服务器启动在 http://localhost:8080")
 		if err := http.ListenAndServe(":8080", nil); err != nil {
-			logger.Fatal("静态文件服务器启动失败:", err)
+			logger.Fatal("静态文件服务器启动失败: %v", err)
 		}
 	}()
 
+	// 监控指标不需要认证，单独监听，不与对外的Web服务共用端口
+	if cfg.Server.MetricsAddr != "" {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", metrics.Handler())
+			logger.Info("监控指标启动在 http://%s/metrics", cfg.Server.MetricsAddr)
+			if err := http.ListenAndServe(cfg.Server.MetricsAddr, mux); err != nil {
+				logger.Error("监控指标服务启动失败: %v", err)
+			}
+		}()
+	}
+
 	// 创建组件容器并注册处理器
 	components := &component.Components{}
-	components.Register(handlers.NewUser(userService),
+	components.Register(handlers.NewUser(userService, tokenService, roomService, friendService, presence),
 		component.WithName("user"),
 	)
-	components.Register(handlers.NewRoom(roomService, gameService),
+	components.Register(handlers.NewRoom(roomService, gameService, friendService, presence),
 		component.WithName("room"),
 	)
 	components.Register(handlers.NewGame(gameService, roomService),
 		component.WithName("game"),
 	)
+	components.Register(handlers.NewFriend(friendService),
+		component.WithName("friend"),
+	)
+	components.Register(handlers.NewTournament(tournamentService),
+		component.WithName("tournament"),
+	)
+	components.Register(handlers.NewCoin(coinService),
+		component.WithName("coin"),
+	)
+	components.Register(handlers.NewAchievement(achievementService),
+		component.WithName("achievement"),
+	)
+
+	// 连接断开时用户下线
+	session.Lifetime.OnClosed(func(s *session.Session) {
+		presence.SetOffline(s.String("username"), s)
+		handlerMetrics.SessionClosed(s)
+	})
+
+	// 所有请求先经过指标统计和限流管道，响应经过指标统计管道
+	pip := pipeline.New()
+	pip.Inbound().PushBack(handlerMetrics.Inbound())
+	pip.Inbound().PushBack(handlers.RateLimit(rateLimiter))
+	pip.Outbound().PushBack(handlerMetrics.Outbound())
 
 	// 启动nano WebSocket服务器
-	nano.Listen(":"+strconv.Itoa(cfg.Server.Port),
+	node, err := startNano(":"+strconv.Itoa(cfg.Server.Port),
+		nano.WithPipeline(pip),
 		nano.WithIsWebsocket(true),
 		nano.WithComponents(components),
 		nano.WithSerializer(jsonSerializer.NewSerializer()),
 		nano.WithWSPath("/nano"),
 	)
+	if err != nil {
+		logger.Fatal("nano服务器启动失败: %v", err)
+	}
+
+	// 收到退出信号后先排空，再次收到信号时不再等待进行中的游戏
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-signals
+	logger.Info("收到退出信号 %v，进入停机排空", sig)
+
+	force := make(chan struct{})
+	go func() {
+		<-signals
+		close(force)
+	}()
+	drainService.Drain(force)
+
+	// 停止处理请求后保存房间，之后按注册的相反顺序停止各服务并关闭数据库
+	node.Shutdown()
+	scheduler.Close()
+	drainService.Finish()
+	logger.Info("nano服务器已停止，关闭各服务和数据库")
+}
+
+// startNano 启动nano节点，不监听退出信号
+// nano.Listen 收到退出信号后立即关闭节点并停止调度器，无法先排空再停机，所以由main自行处理信号
+func startNano(addr string, opts ...nano.Option) (*cluster.Node, error) {
+	opt := cluster.Options{
+		Components: &component.Components{},
+	}
+	for _, option := range opts {
+		option(&opt)
+	}
+	// 单机模式，客户端地址即监听地址
+	if opt.ClientAddr == "" {
+		opt.ClientAddr = addr
+	}
+
+	node := &cluster.Node{
+		Options:     opt,
+		ServiceAddr: addr,
+	}
+	if err := node.Startup(); err != nil {
+		return nil, err
+	}
+	go scheduler.Sched()
+	return node, nil
 }
@@ -93,6 +93,11 @@ func (l *Logger) log(level Level, format string, args ...interface{}) {
 		message := l.formatMessage(level, format, args...)
 		l.logger.Println(message)
 
+		// 错误日志同时保留在内存中，供运维接口查看
+		if level >= ERROR {
+			recentErrors.add(Entry{Time: time.Now(), Level: levelStrings[level], Message: fmt.Sprintf(format, args...)})
+		}
+
 		// 如果是FATAL级别，程序退出
 		if level == FATAL {
 			if l.file != nil {
@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// Entry 一条日志记录
+type Entry struct {
+	Time    time.Time `json:"time"`    // 时间
+	Level   string    `json:"level"`   // 级别
+	Message string    `json:"message"` // 内容
+}
+
+// recentLog 最近的错误日志环形缓冲区，供运维接口查看
+type recentLog struct {
+	mutex   sync.Mutex
+	entries []Entry
+	next    int  // 下一条写入的位置
+	full    bool // 是否已写满一圈
+}
+
+// 默认保留最近100条错误日志
+var recentErrors = &recentLog{entries: make([]Entry, 100)}
+
+// add 记录一条日志，写满后覆盖最旧的记录
+func (r *recentLog) add(entry Entry) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if len(r.entries) == 0 {
+		return
+	}
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// list 按时间倒序返回最多limit条记录，limit不大于0表示全部
+func (r *recentLog) list(limit int) []Entry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	count := r.next
+	if r.full {
+		count = len(r.entries)
+	}
+	if limit <= 0 || limit > count {
+		limit = count
+	}
+
+	result := make([]Entry, 0, limit)
+	for i := 0; i < limit; i++ {
+		index := (r.next - 1 - i + len(r.entries)) % len(r.entries)
+		result = append(result, r.entries[index])
+	}
+	return result
+}
+
+// SetRecentErrorSize 设置保留的最近错误日志条数，已有记录会被清空
+func SetRecentErrorSize(size int) {
+	if size < 0 {
+		size = 0
+	}
+	recentErrors.mutex.Lock()
+	defer recentErrors.mutex.Unlock()
+
+	recentErrors.entries = make([]Entry, size)
+	recentErrors.next = 0
+	recentErrors.full = false
+}
+
+// RecentErrors 获取最近的错误日志，按时间倒序，limit不大于0表示全部
+func RecentErrors(limit int) []Entry {
+	return recentErrors.list(limit)
+}
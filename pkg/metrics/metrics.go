@@ -0,0 +1,395 @@
+// Package metrics 运行指标，以Prometheus文本格式输出
+//
+// 指标在启动时创建并注册到默认注册表，同名指标后注册的覆盖先注册的。
+// 带标签的指标在记录时按定义顺序传入标签值，数量不一致属于编程错误，会直接panic。
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefBuckets 默认的耗时直方图分桶(秒)
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// collector 可输出的指标
+type collector interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// registry 指标注册表
+type registry struct {
+	mutex      sync.RWMutex
+	collectors map[string]collector
+}
+
+var defaultRegistry = &registry{collectors: make(map[string]collector)}
+
+// register 注册指标，同名指标覆盖
+func (r *registry) register(c collector) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.collectors[c.name()] = c
+}
+
+// write 按指标名称顺序输出所有指标
+func (r *registry) write(w *bufio.Writer) {
+	r.mutex.RLock()
+	collectors := make([]collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		collectors = append(collectors, c)
+	}
+	r.mutex.RUnlock()
+
+	sort.Slice(collectors, func(i, j int) bool {
+		return collectors[i].name() < collectors[j].name()
+	})
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// Handler 输出所有指标的HTTP处理器
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		buf := bufio.NewWriter(w)
+		defaultRegistry.write(buf)
+		buf.Flush()
+	})
+}
+
+// desc 指标的名称、说明和标签名
+type desc struct {
+	fqName string
+	help   string
+	labels []string
+}
+
+func (d *desc) name() string {
+	return d.fqName
+}
+
+// key 将标签值拼接为序列的键
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("指标 %s 需要 %d 个标签值，实际为 %d 个", d.fqName, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// writeHeader 输出指标的说明和类型
+func (d *desc) writeHeader(w *bufio.Writer, kind string) {
+	help := strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(d.help)
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.fqName, help, d.fqName, kind)
+}
+
+// writeSample 输出一个样本，extra 为附加的标签名和标签值，如直方图的 le
+func writeSample(w *bufio.Writer, name string, labels, values []string, value float64, extra ...string) {
+	w.WriteString(name)
+	if len(labels) > 0 || len(extra) > 0 {
+		w.WriteByte('{')
+		n := 0
+		pair := func(label, value string) {
+			if n > 0 {
+				w.WriteByte(',')
+			}
+			n++
+			w.WriteString(label)
+			w.WriteString(`="`)
+			w.WriteString(escapeLabel(value))
+			w.WriteByte('"')
+		}
+		for i, label := range labels {
+			pair(label, values[i])
+		}
+		for i := 0; i+1 < len(extra); i += 2 {
+			pair(extra[i], extra[i+1])
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatValue(value))
+	w.WriteByte('\n')
+}
+
+// escapeLabel 转义标签值中的反斜杠、双引号和换行
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// formatValue 格式化样本值
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// series 一组标签值对应的样本
+type series struct {
+	values []string
+	value  float64
+}
+
+// sortedKeys 按键排序，保证输出顺序稳定
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Counter 只增不减的计数器
+type Counter struct {
+	desc
+	mutex  sync.Mutex
+	series map[string]*series
+}
+
+// NewCounter 创建并注册计数器
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{
+		desc:   desc{fqName: name, help: help, labels: labels},
+		series: make(map[string]*series),
+	}
+	defaultRegistry.register(c)
+	return c
+}
+
+// Inc 计数加一
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add 计数增加 delta，delta 为负数时忽略
+func (c *Counter) Add(delta float64, values ...string) {
+	if delta < 0 {
+		return
+	}
+	key := c.key(values)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		c.series[key] = s
+	}
+	s.value += delta
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.writeHeader(w, "counter")
+	for _, key := range sortedKeys(c.series) {
+		s := c.series[key]
+		writeSample(w, c.fqName, c.labels, s.values, s.value)
+	}
+}
+
+// Histogram 分桶统计的直方图，常用于耗时
+type Histogram struct {
+	desc
+	buckets []float64
+	mutex   sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+// histogramSeries 一组标签值对应的分桶计数
+type histogramSeries struct {
+	values []string
+	counts []uint64 // 各分桶的计数，不累加
+	sum    float64
+	count  uint64
+}
+
+// NewHistogram 创建并注册直方图，buckets 为空时使用 DefBuckets
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	h := &Histogram{
+		desc:    desc{fqName: name, help: help, labels: labels},
+		buckets: sorted,
+		series:  make(map[string]*histogramSeries),
+	}
+	defaultRegistry.register(h)
+	return h
+}
+
+// Observe 记录一个观测值
+func (h *Histogram) Observe(value float64, values ...string) {
+	key := h.key(values)
+	index := sort.SearchFloat64s(h.buckets, value)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{
+			values: append([]string(nil), values...),
+			counts: make([]uint64, len(h.buckets)),
+		}
+		h.series[key] = s
+	}
+	if index < len(h.buckets) {
+		s.counts[index]++
+	}
+	s.sum += value
+	s.count++
+}
+
+// Since 记录从 start 到现在的耗时(秒)
+func (h *Histogram) Since(start time.Time, values ...string) {
+	h.Observe(time.Since(start).Seconds(), values...)
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.writeHeader(w, "histogram")
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(w, h.fqName+"_bucket", h.labels, s.values, float64(cumulative), "le", formatValue(upper))
+		}
+		writeSample(w, h.fqName+"_bucket", h.labels, s.values, float64(s.count), "le", "+Inf")
+		writeSample(w, h.fqName+"_sum", h.labels, s.values, s.sum)
+		writeSample(w, h.fqName+"_count", h.labels, s.values, float64(s.count))
+	}
+}
+
+// Sample 由函数指标返回的一个样本
+type Sample struct {
+	Values []string // 标签值，顺序与定义时的标签名一致
+	Value  float64
+}
+
+// funcCollector 输出时调用函数取值的指标，用于从服务状态中读取的数据
+type funcCollector struct {
+	desc
+	kind string
+	fn   func() []Sample
+}
+
+// GaugeFunc 注册输出时取值的仪表盘指标
+func GaugeFunc(name, help string, labels []string, fn func() []Sample) {
+	defaultRegistry.register(&funcCollector{
+		desc: desc{fqName: name, help: help, labels: labels},
+		kind: "gauge",
+		fn:   fn,
+	})
+}
+
+// CounterFunc 注册输出时取值的计数器指标，fn 返回的值应只增不减
+func CounterFunc(name, help string, labels []string, fn func() []Sample) {
+	defaultRegistry.register(&funcCollector{
+		desc: desc{fqName: name, help: help, labels: labels},
+		kind: "counter",
+		fn:   fn,
+	})
+}
+
+func (f *funcCollector) write(w *bufio.Writer) {
+	samples := f.fn()
+	sort.SliceStable(samples, func(i, j int) bool {
+		return strings.Join(samples[i].Values, "\xff") < strings.Join(samples[j].Values, "\xff")
+	})
+
+	f.writeHeader(w, f.kind)
+	for _, sample := range samples {
+		f.key(sample.Values)
+		writeSample(w, f.fqName, f.labels, sample.Values, sample.Value)
+	}
+}
+
+// Meter 事件计数器，同时输出累计次数(name_total)和最近一分钟的次数(name_per_minute)
+type Meter struct {
+	total  *desc
+	minute *desc
+
+	mutex  sync.Mutex
+	count  uint64
+	slots  [60]uint64 // 按秒分槽的计数
+	stamps [60]int64  // 各槽对应的秒，过期的槽不计入
+}
+
+// NewMeter 创建并注册事件计数器
+func NewMeter(name, help string) *Meter {
+	m := &Meter{
+		total:  &desc{fqName: name + "_total", help: help + "，累计"},
+		minute: &desc{fqName: name + "_per_minute", help: help + "，最近一分钟"},
+	}
+	defaultRegistry.register(m)
+	return m
+}
+
+// Mark 记录一次事件
+func (m *Meter) Mark() {
+	now := time.Now().Unix()
+	slot := now % int64(len(m.slots))
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.stamps[slot] != now {
+		m.stamps[slot] = now
+		m.slots[slot] = 0
+	}
+	m.slots[slot]++
+	m.count++
+}
+
+// PerMinute 最近一分钟的事件次数
+func (m *Meter) PerMinute() uint64 {
+	now := time.Now().Unix()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var sum uint64
+	for i, stamp := range m.stamps {
+		if now-stamp < int64(len(m.slots)) {
+			sum += m.slots[i]
+		}
+	}
+	return sum
+}
+
+func (m *Meter) name() string {
+	return m.total.fqName
+}
+
+func (m *Meter) write(w *bufio.Writer) {
+	perMinute := m.PerMinute()
+	m.mutex.Lock()
+	count := m.count
+	m.mutex.Unlock()
+
+	m.total.writeHeader(w, "counter")
+	writeSample(w, m.total.fqName, nil, nil, float64(count))
+	m.minute.writeHeader(w, "gauge")
+	writeSample(w, m.minute.fqName, nil, nil, float64(perMinute))
+}
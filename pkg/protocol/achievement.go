@@ -0,0 +1,138 @@
+package protocol
+
+import (
+	"time"
+
+	"aigames/internal/models"
+)
+
+// 成就和任务相关的请求和响应结构体
+
+// 服务器推送的成就和任务消息路由
+const (
+	RouteAchievementUnlocked = "onAchievementUnlocked" // 获得成就
+	RouteMissionCompleted    = "onMissionCompleted"    // 完成每日或每周任务
+)
+
+// 任务类型
+const (
+	GoalKindAchievement = "achievement" // 成就
+	GoalKindDaily       = "daily"       // 每日任务
+	GoalKindWeekly      = "weekly"      // 每周任务
+)
+
+// AchievementListRequest 获取全部成就和任务定义请求
+type AchievementListRequest struct {
+	BaseRequest
+}
+
+// AchievementProgressRequest 获取自己的成就和任务进度请求
+type AchievementProgressRequest struct {
+	BaseRequest
+}
+
+// GoalData 成就或任务定义数据
+type GoalData struct {
+	ID          string `json:"id"`          // 唯一标识
+	Name        string `json:"name"`        // 名称
+	Description string `json:"description"` // 描述
+	Event       string `json:"event"`       // 统计的事件
+	EventName   string `json:"event_name"`  // 事件名称
+	Target      int64  `json:"target"`      // 目标次数
+	Reward      int64  `json:"reward"`      // 完成奖励(金币)
+}
+
+// GoalListData 全部成就和任务定义数据
+type GoalListData struct {
+	Achievements []GoalData `json:"achievements"` // 成就
+	Daily        []GoalData `json:"daily"`        // 每日任务
+	Weekly       []GoalData `json:"weekly"`       // 每周任务
+}
+
+// GoalProgressData 成就或任务进度数据
+type GoalProgressData struct {
+	GoalData
+	Progress    int64      `json:"progress"`               // 当前进度，不超过目标
+	Completed   bool       `json:"completed"`              // 是否已完成
+	CompletedAt *time.Time `json:"completed_at,omitempty"` // 完成时间
+}
+
+// AchievementProgressData 玩家的成就和任务进度数据
+type AchievementProgressData struct {
+	Achievements []GoalProgressData `json:"achievements"`  // 成就
+	Daily        []GoalProgressData `json:"daily"`         // 每日任务
+	Weekly       []GoalProgressData `json:"weekly"`        // 每周任务
+	DailyPeriod  string             `json:"daily_period"`  // 每日任务周期
+	WeeklyPeriod string             `json:"weekly_period"` // 每周任务周期
+	Counters     map[string]int64   `json:"counters"`      // 各事件的终身累计次数
+}
+
+// GoalRewardData 获得成就或完成任务的推送数据
+type GoalRewardData struct {
+	Kind    string   `json:"kind"`    // 类型：achievement/daily/weekly
+	Goal    GoalData `json:"goal"`    // 成就或任务
+	Balance int64    `json:"balance"` // 发放奖励后的金币余额
+}
+
+// NewGoalData 根据定义创建成就或任务数据
+func NewGoalData(goal models.Goal) GoalData {
+	return GoalData{
+		ID:          goal.ID,
+		Name:        goal.Name,
+		Description: goal.Description,
+		Event:       goal.Event,
+		EventName:   models.EventNames[goal.Event],
+		Target:      goal.Target,
+		Reward:      goal.Reward,
+	}
+}
+
+// newGoalListData 批量转换定义
+func newGoalListData(goals []models.Goal) []GoalData {
+	data := make([]GoalData, 0, len(goals))
+	for _, goal := range goals {
+		data = append(data, NewGoalData(goal))
+	}
+	return data
+}
+
+// newGoalProgressData 根据累计次数和完成时间创建进度数据
+func newGoalProgressData(goals []models.Goal, counters map[string]int64, completed map[string]time.Time) []GoalProgressData {
+	data := make([]GoalProgressData, 0, len(goals))
+	for _, goal := range goals {
+		item := GoalProgressData{GoalData: NewGoalData(goal), Progress: min(counters[goal.Event], goal.Target)}
+		if at, ok := completed[goal.ID]; ok {
+			item.Completed = true
+			item.Progress = goal.Target
+			item.CompletedAt = &at
+		}
+		data = append(data, item)
+	}
+	return data
+}
+
+// NewAchievementProgressData 根据定义和玩家进度创建进度数据
+func NewAchievementProgressData(goals *models.GoalSet, progress *models.PlayerProgress) AchievementProgressData {
+	return AchievementProgressData{
+		Achievements: newGoalProgressData(goals.Achievements, progress.Counters, progress.Unlocked),
+		Daily:        newGoalProgressData(goals.Daily, progress.Daily.Progress, progress.Daily.Completed),
+		Weekly:       newGoalProgressData(goals.Weekly, progress.Weekly.Progress, progress.Weekly.Completed),
+		DailyPeriod:  progress.Daily.Period,
+		WeeklyPeriod: progress.Weekly.Period,
+		Counters:     progress.Counters,
+	}
+}
+
+// AchievementListSuccess 成就和任务定义成功响应
+func AchievementListSuccess(goals *models.GoalSet) BaseResponse {
+	return Success(GoalListData{
+		Achievements: newGoalListData(goals.Achievements),
+		Daily:        newGoalListData(goals.Daily),
+		Weekly:       newGoalListData(goals.Weekly),
+	})
+}
+
+// AchievementProgressSuccess 成就和任务进度成功响应
+func AchievementProgressSuccess(goals *models.GoalSet, progress *models.PlayerProgress) BaseResponse {
+	return Success(NewAchievementProgressData(goals, progress))
+}
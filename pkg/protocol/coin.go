@@ -0,0 +1,108 @@
+package protocol
+
+import (
+	"time"
+
+	"aigames/internal/models"
+)
+
+// 金币相关的请求和响应结构体
+
+// 服务器推送的金币消息路由
+const (
+	RouteCoinsGranted = "onCoinsGranted" // 系统发放金币，如每日登录赠送
+	RouteCoinsSettled = "onCoinsSettled" // 一局结束后的金币结算
+)
+
+// BalanceRequest 查询金币余额请求
+type BalanceRequest struct {
+	BaseRequest
+}
+
+// LedgerRequest 查询金币流水请求
+type LedgerRequest struct {
+	PageRequest
+}
+
+// BalanceData 金币余额数据
+type BalanceData struct {
+	Balance int64 `json:"balance"` // 余额
+}
+
+// CoinGrantData 金币发放推送数据
+type CoinGrantData struct {
+	Type     models.LedgerType `json:"type"`      // 流水类型
+	TypeName string            `json:"type_name"` // 流水类型名称
+	Amount   int64             `json:"amount"`    // 发放金额
+	Balance  int64             `json:"balance"`   // 发放后的余额
+}
+
+// CoinSettlementData 一局金币结算推送数据
+type CoinSettlementData struct {
+	RoomID     string              `json:"room_id"`    // 房间ID
+	GameID     string              `json:"game_id"`    // 游戏ID
+	BaseStake  int64               `json:"base_stake"` // 底注
+	Multiplier int                 `json:"multiplier"` // 倍数
+	Spring     bool                `json:"spring"`     // 是否春天或反春
+	Changes    []models.CoinChange `json:"changes"`    // 各玩家的金币变化
+}
+
+// LedgerEntryData 金币流水数据
+type LedgerEntryData struct {
+	ID          uint64            `json:"id"`           // 流水号
+	Type        models.LedgerType `json:"type"`         // 类型
+	TypeName    string            `json:"type_name"`    // 类型名称
+	From        string            `json:"from"`         // 付款方，为空表示系统
+	To          string            `json:"to"`           // 收款方
+	Amount      int64             `json:"amount"`       // 金额
+	FromBalance int64             `json:"from_balance"` // 付款方转账后的余额
+	ToBalance   int64             `json:"to_balance"`   // 收款方转账后的余额
+	RoomID      string            `json:"room_id"`      // 房间ID
+	GameID      string            `json:"game_id"`      // 游戏ID
+	Note        string            `json:"note"`         // 备注
+	CreatedAt   time.Time         `json:"created_at"`   // 时间
+}
+
+// NewCoinSettlementData 根据对局和金币变化创建结算数据
+func NewCoinSettlementData(room *models.Room, game *models.Game, changes []models.CoinChange) CoinSettlementData {
+	return CoinSettlementData{
+		RoomID:     room.ID,
+		GameID:     game.ID,
+		BaseStake:  room.BaseStake,
+		Multiplier: game.Multiplier,
+		Spring:     game.Spring,
+		Changes:    changes,
+	}
+}
+
+// NewLedgerEntryData 根据流水创建流水数据
+func NewLedgerEntryData(entry models.LedgerEntry) LedgerEntryData {
+	return LedgerEntryData{
+		ID:          entry.ID,
+		Type:        entry.Type,
+		TypeName:    models.LedgerTypeNames[entry.Type],
+		From:        entry.From,
+		To:          entry.To,
+		Amount:      entry.Amount,
+		FromBalance: entry.FromBalance,
+		ToBalance:   entry.ToBalance,
+		RoomID:      entry.RoomID,
+		GameID:      entry.GameID,
+		Note:        entry.Note,
+		CreatedAt:   entry.CreatedAt,
+	}
+}
+
+// BalanceSuccess 金币余额成功响应
+func BalanceSuccess(balance int64) BaseResponse {
+	return Success(BalanceData{Balance: balance})
+}
+
+// LedgerSuccess 金币流水成功响应
+func LedgerSuccess(entries []models.LedgerEntry, total, page, size int) PageResponse {
+	data := make([]LedgerEntryData, 0, len(entries))
+	for _, entry := range entries {
+		data = append(data, NewLedgerEntryData(entry))
+	}
+	return SuccessPage(data, total, page, size)
+}
@@ -0,0 +1,100 @@
+package protocol
+
+import (
+	"time"
+
+	"aigames/internal/models"
+)
+
+// 好友相关的请求和响应结构体
+
+// 服务器推送的好友和邀请消息路由
+const (
+	RouteFriendRequest  = "onFriendRequest"  // 收到好友请求
+	RouteFriendAccepted = "onFriendAccepted" // 好友请求被接受
+	RouteFriendPresence = "onFriendPresence" // 好友上线或下线
+	RouteRoomInvite     = "onRoomInvite"     // 收到房间邀请
+)
+
+// FriendRequest 针对某个用户的好友操作请求（添加、接受、删除、屏蔽、取消屏蔽）
+type FriendRequest struct {
+	BaseRequest
+	Name string `json:"name" validate:"required,min=1,max=50"` // 对方用户名
+}
+
+// ListFriendsRequest 获取好友列表请求
+type ListFriendsRequest struct {
+	BaseRequest
+}
+
+// InviteRequest 邀请好友加入房间请求
+type InviteRequest struct {
+	BaseRequest
+	RoomID string `json:"room_id" validate:"required"`             // 房间ID
+	Friend string `json:"friend" validate:"required,min=1,max=50"` // 被邀请的好友
+}
+
+// FriendData 好友数据
+type FriendData struct {
+	Name       string              `json:"name"`        // 用户名
+	Status     models.FriendStatus `json:"status"`      // 关系状态
+	StatusName string              `json:"status_name"` // 关系状态名称
+	Online     bool                `json:"online"`      // 是否在线，只对好友可见
+	Since      time.Time           `json:"since"`       // 关系建立时间
+}
+
+// FriendListData 好友列表数据
+type FriendListData struct {
+	Friends []FriendData `json:"friends"` // 好友、好友请求和屏蔽列表
+}
+
+// FriendNameData 推送中的对方用户名
+type FriendNameData struct {
+	Name string `json:"name"` // 用户名
+}
+
+// FriendPresenceData 好友在线状态推送数据
+type FriendPresenceData struct {
+	Name   string `json:"name"`   // 用户名
+	Online bool   `json:"online"` // 是否在线
+}
+
+// InvitationData 房间邀请数据
+type InvitationData struct {
+	InviteID  string    `json:"invite_id"`  // 邀请ID，加入房间时携带
+	RoomID    string    `json:"room_id"`    // 房间ID
+	RoomName  string    `json:"room_name"`  // 房间名称
+	From      string    `json:"from"`       // 邀请人
+	ExpiresAt time.Time `json:"expires_at"` // 过期时间
+}
+
+// NewInvitationData 根据邀请创建邀请数据
+func NewInvitationData(invitation *models.Invitation) InvitationData {
+	return InvitationData{
+		InviteID:  invitation.ID,
+		RoomID:    invitation.RoomID,
+		RoomName:  invitation.RoomName,
+		From:      invitation.From,
+		ExpiresAt: invitation.ExpiresAt,
+	}
+}
+
+// FriendActionSuccess 创建好友操作成功响应
+func FriendActionSuccess(name string, status models.FriendStatus, message string) BaseResponse {
+	data := FriendData{
+		Name:       name,
+		Status:     status,
+		StatusName: models.FriendStatusNames[status],
+	}
+	return SuccessWithMessage(data, message)
+}
+
+// FriendListSuccess 创建好友列表成功响应
+func FriendListSuccess(friends []FriendData) BaseResponse {
+	return Success(FriendListData{Friends: friends})
+}
+
+// InviteSuccess 创建邀请成功响应
+func InviteSuccess(invitation *models.Invitation) BaseResponse {
+	return SuccessWithMessage(NewInvitationData(invitation), "邀请已发送")
+}
@@ -1,16 +1,33 @@
 package protocol
 
-import "aigames/internal/models"
+import (
+	"time"
+
+	"aigames/internal/models"
+)
+
+// 房间推送路由
+const (
+	RouteRoomKicked       = "onRoomKicked"        // 被房主踢出房间
+	RouteRoomOwnerChanged = "onRoomOwnerChanged"  // 房主变更
+	RouteRoomClosed       = "onRoomClosed"        // 房间被回收
+	RouteGameTerminated   = "onGameTerminated"    // 游戏被运维结束或中止
+	RouteMaintenance      = "onServerMaintenance" // 服务器即将停机维护
+	RouteGameResumed      = "onGameResumed"       // 重新上线时所在房间有进行中的游戏
+)
 
 // 房间相关的请求和响应
 
 // CreateRoomRequest 创建房间请求
 type CreateRoomRequest struct {
 	BaseRequest
-	Name     string          `json:"name" validate:"required,min=1,max=50"` // 房间名称
-	Type     models.RoomType `json:"type"`                                  // 房间类型
-	Password string          `json:"password,omitempty" validate:"max=20"`  // 房间密码（可选）
-	AICount  int             `json:"ai_count" validate:"min=0,max=2"`       // AI玩家数量
+	Name       string          `json:"name" validate:"required,min=1,max=50"`     // 房间名称
+	Type       models.RoomType `json:"type"`                                      // 房间类型
+	Password   string          `json:"password,omitempty" validate:"max=20"`      // 房间密码（可选）
+	AICount    int             `json:"ai_count" validate:"min=0,max=2"`           // AI玩家数量
+	Hands      int             `json:"hands" validate:"min=-1,max=50"`            // 每次对战的局数，0为单局，-1为不限局数
+	BaseStake  int64           `json:"base_stake" validate:"min=-1,max=100000"`   // 底注，0为默认底注，-1为不结算金币
+	MinBalance int64           `json:"min_balance" validate:"min=0,max=10000000"` // 入场金币，0为按底注计算
 }
 
 // JoinRoomRequest 加入房间请求
@@ -18,6 +35,14 @@ type JoinRoomRequest struct {
 	BaseRequest
 	RoomID   string `json:"room_id" validate:"required"` // 房间ID
 	Password string `json:"password,omitempty"`          // 房间密码（如果需要）
+	InviteID string `json:"invite_id,omitempty"`         // 房间邀请ID，携带时无需密码
+}
+
+// JoinByCodeRequest 通过房间码加入房间请求
+type JoinByCodeRequest struct {
+	BaseRequest
+	Code     string `json:"code" validate:"required,len=6"` // 房间码，不区分大小写
+	Password string `json:"password,omitempty"`             // 房间密码（如果需要）
 }
 
 // LeaveRoomRequest 离开房间请求
@@ -27,9 +52,17 @@ type LeaveRoomRequest struct {
 }
 
 // GetRoomListRequest 获取房间列表请求
+// 过滤条件省略时不过滤，type 省略时只列出公开房间；携带 cursor 时忽略 page，从上一页最后一个房间之后继续
 type GetRoomListRequest struct {
 	PageRequest
-	Type models.RoomType `json:"type,omitempty"` // 房间类型过滤
+	Type        *models.RoomType   `json:"type,omitempty"`                      // 房间类型过滤，默认公开房间
+	Status      *models.RoomStatus `json:"status,omitempty"`                    // 房间状态过滤
+	OpenSeats   int                `json:"open_seats" validate:"min=0,max=3"`   // 至少空余的座位数
+	HasPassword *bool              `json:"has_password,omitempty"`              // 是否有密码
+	Keyword     string             `json:"keyword,omitempty" validate:"max=50"` // 房间名称关键字
+	SortBy      string             `json:"sort_by,omitempty"`                   // 排序字段：created_at（默认）、player_count
+	Order       string             `json:"order,omitempty"`                     // 排序方向：desc（默认）、asc
+	Cursor      string             `json:"cursor,omitempty" validate:"max=512"` // 分页游标
 }
 
 // SetReadyRequest 设置准备状态请求
@@ -51,6 +84,39 @@ type DeleteRoomRequest struct {
 	RoomID string `json:"room_id" validate:"required"` // 房间ID
 }
 
+// KickPlayerRequest 房主踢出玩家请求
+type KickPlayerRequest struct {
+	BaseRequest
+	RoomID string `json:"room_id" validate:"required"` // 房间ID
+	Player string `json:"player" validate:"required"`  // 被踢出的玩家
+}
+
+// TransferOwnerRequest 转让房主请求
+type TransferOwnerRequest struct {
+	BaseRequest
+	RoomID string `json:"room_id" validate:"required"` // 房间ID
+	Player string `json:"player" validate:"required"`  // 新房主
+}
+
+// AddAIRequest 添加AI座位请求
+type AddAIRequest struct {
+	BaseRequest
+	RoomID string `json:"room_id" validate:"required"` // 房间ID
+}
+
+// RemoveAIRequest 移除AI座位请求
+type RemoveAIRequest struct {
+	BaseRequest
+	RoomID string `json:"room_id" validate:"required"` // 房间ID
+	Player string `json:"player" validate:"required"`  // AI玩家名称
+}
+
+// EndSessionRequest 结束多局对战请求
+type EndSessionRequest struct {
+	BaseRequest
+	RoomID string `json:"room_id" validate:"required"` // 房间ID
+}
+
 // 游戏相关的请求和响应
 
 // CallLandlordRequest 叫地主请求
@@ -90,6 +156,7 @@ type GetPlayerHandRequest struct {
 // RoomData 房间数据
 type RoomData struct {
 	ID          string            `json:"id"`           // 房间ID
+	Code        string            `json:"code"`         // 房间码
 	Name        string            `json:"name"`         // 房间名称
 	Owner       string            `json:"owner"`        // 房主
 	Type        models.RoomType   `json:"type"`         // 房间类型
@@ -98,6 +165,9 @@ type RoomData struct {
 	StatusName  string            `json:"status_name"`  // 房间状态名称
 	MaxPlayers  int               `json:"max_players"`  // 最大玩家数
 	PlayerCount int               `json:"player_count"` // 当前玩家数
+	Hands       int               `json:"hands"`        // 每次对战的局数，0为单局，-1为不限局数
+	BaseStake   int64             `json:"base_stake"`   // 底注，0为不结算金币
+	MinBalance  int64             `json:"min_balance"`  // 入场金币
 	HasPassword bool              `json:"has_password"` // 是否有密码
 	CreatedAt   string            `json:"created_at"`   // 创建时间
 	UpdatedAt   string            `json:"updated_at"`   // 更新时间
@@ -124,17 +194,65 @@ type PlayerHandData struct {
 	Cards []models.Card `json:"cards"` // 手牌
 }
 
+// KickPlayerData 踢出玩家数据
+type KickPlayerData struct {
+	Player      string `json:"player"`       // 被踢出的玩家
+	RejoinAfter string `json:"rejoin_after"` // 可重新加入的时间
+}
+
+// RoomKickedData 被踢出房间推送数据
+type RoomKickedData struct {
+	RoomID      string `json:"room_id"`      // 房间ID
+	By          string `json:"by"`           // 操作的房主
+	RejoinAfter string `json:"rejoin_after"` // 可重新加入的时间
+}
+
+// RoomOwnerData 房主变更数据
+type RoomOwnerData struct {
+	RoomID string `json:"room_id"` // 房间ID
+	Owner  string `json:"owner"`   // 新房主
+}
+
+// RoomClosedData 房间关闭推送数据
+type RoomClosedData struct {
+	RoomID string `json:"room_id"` // 房间ID
+	Reason string `json:"reason"`  // 关闭原因
+}
+
+// GameTerminatedData 游戏被运维结束或中止的推送数据
+type GameTerminatedData struct {
+	RoomID       string `json:"room_id"`       // 房间ID
+	GameID       string `json:"game_id"`       // 游戏ID
+	Reason       string `json:"reason"`        // 原因
+	SessionEnded bool   `json:"session_ended"` // 多局对战是否一并结束
+}
+
+// GameResumedData 重新上线时所在房间有进行中游戏的推送数据
+type GameResumedData struct {
+	RoomID   string `json:"room_id"`   // 房间ID
+	RoomName string `json:"room_name"` // 房间名称
+	GameID   string `json:"game_id"`   // 游戏ID
+}
+
+// MaintenanceData 服务器即将停机维护的推送数据
+type MaintenanceData struct {
+	Message    string    `json:"message"`     // 提示信息
+	ShutdownAt time.Time `json:"shutdown_at"` // 最迟停机时间，进行中的游戏需在此之前结束
+}
+
 // RoomListData 房间列表数据
 type RoomListData struct {
-	Rooms []RoomData `json:"rooms"` // 房间列表
+	Rooms      []RoomData `json:"rooms"`                 // 房间列表
+	NextCursor string     `json:"next_cursor,omitempty"` // 下一页游标，没有更多房间时为空
 }
 
 // 快捷响应方法
 
-// CreateRoomSuccess 创建房间成功响应
-func CreateRoomSuccess(room *models.Room) BaseResponse {
-	data := RoomData{
+// NewRoomData 根据房间创建房间数据
+func NewRoomData(room *models.Room) RoomData {
+	return RoomData{
 		ID:          room.ID,
+		Code:        room.Code,
 		Name:        room.Name,
 		Owner:       room.Owner,
 		Type:        room.Type,
@@ -143,30 +261,23 @@ func CreateRoomSuccess(room *models.Room) BaseResponse {
 		StatusName:  models.RoomStatusNames[room.Status],
 		MaxPlayers:  room.MaxPlayers,
 		PlayerCount: room.GetPlayerCount(),
+		Hands:       room.Hands,
+		BaseStake:   room.BaseStake,
+		MinBalance:  room.MinBalance,
 		HasPassword: room.Password != "",
 		CreatedAt:   room.CreatedAt.Format("2006-01-02 15:04:05"),
 		UpdatedAt:   room.UpdatedAt.Format("2006-01-02 15:04:05"),
 	}
-	return SuccessWithMessage(data, "创建房间成功")
+}
+
+// CreateRoomSuccess 创建房间成功响应
+func CreateRoomSuccess(room *models.Room) BaseResponse {
+	return SuccessWithMessage(NewRoomData(room), "创建房间成功")
 }
 
 // JoinRoomSuccess 加入房间成功响应
 func JoinRoomSuccess(room *models.Room) BaseResponse {
-	data := RoomData{
-		ID:          room.ID,
-		Name:        room.Name,
-		Owner:       room.Owner,
-		Type:        room.Type,
-		TypeName:    models.RoomTypeNames[room.Type],
-		Status:      room.Status,
-		StatusName:  models.RoomStatusNames[room.Status],
-		MaxPlayers:  room.MaxPlayers,
-		PlayerCount: room.GetPlayerCount(),
-		HasPassword: room.Password != "",
-		CreatedAt:   room.CreatedAt.Format("2006-01-02 15:04:05"),
-		UpdatedAt:   room.UpdatedAt.Format("2006-01-02 15:04:05"),
-	}
-	return SuccessWithMessage(data, "加入房间成功")
+	return SuccessWithMessage(NewRoomData(room), "加入房间成功")
 }
 
 // LeaveRoomSuccess 离开房间成功响应
@@ -175,26 +286,13 @@ func LeaveRoomSuccess() BaseResponse {
 }
 
 // RoomListSuccess 房间列表成功响应
-func RoomListSuccess(rooms []*models.Room, total, page, size int) PageResponse {
+func RoomListSuccess(rooms []*models.Room, total, page, size int, nextCursor string) PageResponse {
 	roomDataList := make([]RoomData, len(rooms))
 	for i, room := range rooms {
-		roomDataList[i] = RoomData{
-			ID:          room.ID,
-			Name:        room.Name,
-			Owner:       room.Owner,
-			Type:        room.Type,
-			TypeName:    models.RoomTypeNames[room.Type],
-			Status:      room.Status,
-			StatusName:  models.RoomStatusNames[room.Status],
-			MaxPlayers:  room.MaxPlayers,
-			PlayerCount: room.GetPlayerCount(),
-			HasPassword: room.Password != "",
-			CreatedAt:   room.CreatedAt.Format("2006-01-02 15:04:05"),
-			UpdatedAt:   room.UpdatedAt.Format("2006-01-02 15:04:05"),
-		}
+		roomDataList[i] = NewRoomData(room)
 	}
 
-	data := RoomListData{Rooms: roomDataList}
+	data := RoomListData{Rooms: roomDataList, NextCursor: nextCursor}
 
 	return PageResponse{
 		BaseResponse: SuccessWithMessage(data, "获取房间列表成功"),
@@ -219,6 +317,38 @@ func DeleteRoomSuccess() BaseResponse {
 	return SuccessWithMessage(nil, "删除房间成功")
 }
 
+// KickPlayerSuccess 踢出玩家成功响应
+func KickPlayerSuccess(player string, rejoinAfter time.Time) BaseResponse {
+	return SuccessWithMessage(KickPlayerData{
+		Player:      player,
+		RejoinAfter: rejoinAfter.Format("2006-01-02 15:04:05"),
+	}, "已踢出玩家")
+}
+
+// TransferOwnerSuccess 转让房主成功响应
+func TransferOwnerSuccess(room *models.Room) BaseResponse {
+	return SuccessWithMessage(NewRoomData(room), "房主已转让")
+}
+
+// AddAISuccess 添加AI座位成功响应
+func AddAISuccess(room *models.Room) BaseResponse {
+	return SuccessWithMessage(NewRoomData(room), "已添加AI玩家")
+}
+
+// RemoveAISuccess 移除AI座位成功响应
+func RemoveAISuccess(room *models.Room) BaseResponse {
+	return SuccessWithMessage(NewRoomData(room), "已移除AI玩家")
+}
+
+// EndSessionSuccess 结束多局对战成功响应
+func EndSessionSuccess(room *models.Room) BaseResponse {
+	message := "对战已结束"
+	if room.Session.IsActive() {
+		message = "本局结束后对战结束"
+	}
+	return SuccessWithMessage(NewRoomData(room), message)
+}
+
 // CallLandlordSuccess 叫地主成功响应
 func CallLandlordSuccess() BaseResponse {
 	return SuccessWithMessage(nil, "操作成功")
@@ -1,5 +1,10 @@
 package protocol
 
+import (
+	"fmt"
+	"time"
+)
+
 // 通用状态码定义
 const (
 	// 成功状态码
@@ -28,6 +33,7 @@ const (
 	StatusUserLocked        = 1004 // 用户被锁定
 	StatusTokenExpired      = 1005 // token过期
 	StatusTokenInvalid      = 1006 // token无效
+	StatusUserBanned        = 1007 // 用户被封禁
 
 	// 数据库错误状态码 (2xxx)
 	StatusDatabaseError    = 2001 // 数据库错误
@@ -81,6 +87,7 @@ var StatusMessages = map[int]string{
 	StatusUserLocked:        "用户被锁定",
 	StatusTokenExpired:      "登录已过期",
 	StatusTokenInvalid:      "登录凭证无效",
+	StatusUserBanned:        "账户已被封禁",
 
 	// 数据库错误
 	StatusDatabaseError:    "数据库错误",
@@ -155,6 +162,13 @@ func Conflict(message string) BaseResponse {
 	return Error(StatusConflict, message)
 }
 
+func TooManyRequests(message string) BaseResponse {
+	if message == "" {
+		message = GetStatusMessage(StatusTooManyRequests)
+	}
+	return Error(StatusTooManyRequests, message)
+}
+
 func InternalServerError(message string) BaseResponse {
 	if message == "" {
 		message = GetStatusMessage(StatusInternalServerError)
@@ -162,6 +176,13 @@ func InternalServerError(message string) BaseResponse {
 	return Error(StatusInternalServerError, message)
 }
 
+func ServiceUnavailable(message string) BaseResponse {
+	if message == "" {
+		message = GetStatusMessage(StatusServiceUnavailable)
+	}
+	return Error(StatusServiceUnavailable, message)
+}
+
 // 业务错误快捷方法
 func UserNotFound() BaseResponse {
 	return ErrorWithCode(StatusUserNotFound)
@@ -175,8 +196,27 @@ func PasswordIncorrect() BaseResponse {
 	return ErrorWithCode(StatusPasswordIncorrect)
 }
 
-func UserLocked() BaseResponse {
-	return ErrorWithCode(StatusUserLocked)
+// UserLockedData 用户锁定响应数据
+type UserLockedData struct {
+	UnlockAt time.Time `json:"unlock_at"` // 解锁时间
+}
+
+func UserLocked(unlockAt time.Time) BaseResponse {
+	message := fmt.Sprintf("%s，请于 %s 后重试", GetStatusMessage(StatusUserLocked), unlockAt.Format("2006-01-02 15:04:05"))
+	return ErrorWithData(StatusUserLocked, message, UserLockedData{UnlockAt: unlockAt})
+}
+
+// UserBannedData 用户封禁响应数据
+type UserBannedData struct {
+	Reason string `json:"reason,omitempty"` // 封禁原因
+}
+
+func UserBanned(reason string) BaseResponse {
+	message := GetStatusMessage(StatusUserBanned)
+	if reason != "" {
+		message = fmt.Sprintf("%s：%s", message, reason)
+	}
+	return ErrorWithData(StatusUserBanned, message, UserBannedData{Reason: reason})
 }
 
 func TokenExpired() BaseResponse {
@@ -0,0 +1,152 @@
+package protocol
+
+import (
+	"time"
+
+	"aigames/internal/models"
+)
+
+// 比赛相关的请求和响应结构体
+
+// 服务器推送的比赛消息路由
+const (
+	RouteTournamentCheckIn   = "onTournamentCheckIn"   // 比赛开放签到
+	RouteTournamentTable     = "onTournamentTable"     // 新一轮编桌，携带本桌房间
+	RouteTournamentStandings = "onTournamentStandings" // 一轮结束后的排名，或比赛结束、取消
+)
+
+// CreateTournamentRequest 创建比赛请求
+type CreateTournamentRequest struct {
+	BaseRequest
+	Name          string                  `json:"name" validate:"required,min=1,max=50"`   // 比赛名称
+	Format        models.TournamentFormat `json:"format" validate:"required"`              // 赛制：1瑞士制，2淘汰制
+	Rounds        int                     `json:"rounds" validate:"min=0,max=10"`          // 瑞士制的轮数，淘汰制忽略
+	HandsPerTable int                     `json:"hands_per_table" validate:"min=1,max=20"` // 每桌局数
+	MaxPlayers    int                     `json:"max_players" validate:"min=0,max=300"`    // 报名人数上限，0为不限
+}
+
+// TournamentRequest 针对某个比赛的操作请求（报名、取消报名、签到、开放签到、开始、取消、查看）
+type TournamentRequest struct {
+	BaseRequest
+	TournamentID string `json:"tournament_id" validate:"required"` // 比赛ID
+}
+
+// ListTournamentsRequest 获取比赛列表请求
+type ListTournamentsRequest struct {
+	BaseRequest
+}
+
+// TournamentStandingData 选手排名数据
+type TournamentStandingData struct {
+	Rank       int    `json:"rank"`       // 名次
+	Name       string `json:"name"`       // 用户名
+	CheckedIn  bool   `json:"checked_in"` // 是否已签到
+	Points     int    `json:"points"`     // 累计得分
+	TableWins  int    `json:"table_wins"` // 桌第一次数
+	Eliminated bool   `json:"eliminated"` // 是否已淘汰
+}
+
+// TournamentData 比赛数据
+type TournamentData struct {
+	ID            string                   `json:"id"`              // 比赛ID
+	Name          string                   `json:"name"`            // 比赛名称
+	Organizer     string                   `json:"organizer"`       // 组织者
+	Format        models.TournamentFormat  `json:"format"`          // 赛制
+	FormatName    string                   `json:"format_name"`     // 赛制名称
+	Rounds        int                      `json:"rounds"`          // 瑞士制的轮数
+	HandsPerTable int                      `json:"hands_per_table"` // 每桌局数
+	MaxPlayers    int                      `json:"max_players"`     // 报名人数上限
+	Status        models.TournamentStatus  `json:"status"`          // 状态
+	StatusName    string                   `json:"status_name"`     // 状态名称
+	PlayerCount   int                      `json:"player_count"`    // 报名人数
+	CurrentRound  int                      `json:"current_round"`   // 当前轮次
+	Champion      string                   `json:"champion"`        // 冠军
+	Standings     []TournamentStandingData `json:"standings"`       // 排名
+	CreatedAt     time.Time                `json:"created_at"`      // 创建时间
+	StartedAt     *time.Time               `json:"started_at"`      // 开始时间
+	FinishedAt    *time.Time               `json:"finished_at"`     // 结束时间
+}
+
+// TournamentDetailData 比赛详情数据，包括各轮编桌和成绩
+type TournamentDetailData struct {
+	TournamentData
+	RoundList []*models.TournamentRound `json:"round_list"` // 各轮编桌和成绩
+}
+
+// TournamentListData 比赛列表数据
+type TournamentListData struct {
+	Tournaments []TournamentData `json:"tournaments"` // 比赛列表
+}
+
+// TournamentTableData 编桌推送数据
+type TournamentTableData struct {
+	TournamentID string `json:"tournament_id"` // 比赛ID
+	Round        int    `json:"round"`         // 轮次
+	Table        int    `json:"table"`         // 桌号
+	RoomID       string `json:"room_id"`       // 本桌房间，选手已自动入座
+	RoomName     string `json:"room_name"`     // 房间名称
+}
+
+// NewTournamentData 根据比赛创建比赛数据
+func NewTournamentData(t *models.Tournament) TournamentData {
+	data := TournamentData{
+		ID:            t.ID,
+		Name:          t.Name,
+		Organizer:     t.Organizer,
+		Format:        t.Format,
+		FormatName:    models.TournamentFormatNames[t.Format],
+		Rounds:        t.Rounds,
+		HandsPerTable: t.HandsPerTable,
+		MaxPlayers:    t.MaxPlayers,
+		Status:        t.Status,
+		StatusName:    models.TournamentStatusNames[t.Status],
+		PlayerCount:   len(t.Players),
+		CurrentRound:  t.CurrentRound,
+		Champion:      t.Champion,
+		Standings:     make([]TournamentStandingData, 0, len(t.Players)),
+		CreatedAt:     t.CreatedAt,
+		StartedAt:     t.StartedAt,
+		FinishedAt:    t.FinishedAt,
+	}
+	for i, player := range t.Standings() {
+		data.Standings = append(data.Standings, TournamentStandingData{
+			Rank:       i + 1,
+			Name:       player.Name,
+			CheckedIn:  player.CheckedIn,
+			Points:     player.Points,
+			TableWins:  player.TableWins,
+			Eliminated: player.Eliminated,
+		})
+	}
+	return data
+}
+
+// NewTournamentDetailData 根据比赛和各轮创建比赛详情数据
+func NewTournamentDetailData(t *models.Tournament, rounds []*models.TournamentRound) TournamentDetailData {
+	if rounds == nil {
+		rounds = make([]*models.TournamentRound, 0)
+	}
+	return TournamentDetailData{
+		TournamentData: NewTournamentData(t),
+		RoundList:      rounds,
+	}
+}
+
+// TournamentSuccess 比赛操作成功响应
+func TournamentSuccess(t *models.Tournament, message string) BaseResponse {
+	return SuccessWithMessage(NewTournamentData(t), message)
+}
+
+// TournamentDetailSuccess 比赛详情成功响应
+func TournamentDetailSuccess(t *models.Tournament, rounds []*models.TournamentRound) BaseResponse {
+	return Success(NewTournamentDetailData(t, rounds))
+}
+
+// TournamentListSuccess 比赛列表成功响应
+func TournamentListSuccess(tournaments []*models.Tournament) BaseResponse {
+	data := TournamentListData{Tournaments: make([]TournamentData, 0, len(tournaments))}
+	for _, t := range tournaments {
+		data.Tournaments = append(data.Tournaments, NewTournamentData(t))
+	}
+	return Success(data)
+}
@@ -1,7 +1,16 @@
 package protocol
 
+import (
+	"time"
+
+	"aigames/internal/models"
+)
+
 // 用户相关的请求和响应结构体
 
+// RouteAccountBanned 账户被封禁时推送，随后断开连接
+const RouteAccountBanned = "onAccountBanned"
+
 // LoginRequest 登录请求
 type LoginRequest struct {
 	BaseRequest
@@ -11,8 +20,19 @@ type LoginRequest struct {
 
 // LoginResponse 登录响应数据
 type LoginData struct {
-	Name string `json:"name"` // 用户名
-	Age  int    `json:"age"`  // 年龄
+	ProfileData
+	Token     string    `json:"token"`      // 登录凭证
+	ExpiresAt time.Time `json:"expires_at"` // 凭证过期时间
+}
+
+// ProfileData 用户资料
+type ProfileData struct {
+	ID        string    `json:"id"`                 // 用户ID
+	Name      string    `json:"name"`               // 用户名
+	Nickname  string    `json:"nickname,omitempty"` // 昵称
+	Avatar    string    `json:"avatar,omitempty"`   // 头像
+	Age       int       `json:"age"`                // 年龄，0表示未填写
+	CreatedAt time.Time `json:"created_at"`         // 注册时间
 }
 
 // SignupRequest 注册请求
@@ -20,7 +40,7 @@ type SignupRequest struct {
 	BaseRequest
 	Name     string `json:"name" validate:"required,min=1,max=50"`     // 用户名
 	Password string `json:"password" validate:"required,min=6,max=50"` // 密码
-	Age      int    `json:"age" validate:"required,min=1,max=150"`     // 年龄
+	Age      int    `json:"age" validate:"min=0,max=150"`              // 年龄（可选）
 }
 
 // SignupResponse 注册响应数据
@@ -31,7 +51,47 @@ type SignupData struct {
 // RestoreSessionRequest 恢复会话请求
 type RestoreSessionRequest struct {
 	BaseRequest
-	Name string `json:"name" validate:"required,min=1,max=50"` // 用户名
+	Name  string `json:"name,omitempty" validate:"max=50"` // 用户名（可选，需与凭证一致）
+	Token string `json:"token" validate:"required"`        // 登录凭证
+}
+
+// RefreshTokenRequest 刷新凭证请求
+type RefreshTokenRequest struct {
+	BaseRequest
+	Token string `json:"token" validate:"required"` // 当前登录凭证
+}
+
+// LogoutRequest 退出登录请求，吊销当前凭证
+type LogoutRequest struct {
+	BaseRequest
+	Token string `json:"token" validate:"required"` // 当前登录凭证
+}
+
+// ChangePasswordRequest 修改密码请求
+type ChangePasswordRequest struct {
+	BaseRequest
+	OldPassword string `json:"old_password" validate:"required,min=6,max=50"` // 原密码
+	NewPassword string `json:"new_password" validate:"required,min=6,max=50"` // 新密码
+}
+
+// UpdateProfileRequest 修改资料请求，未传的字段保持不变
+type UpdateProfileRequest struct {
+	BaseRequest
+	Nickname *string `json:"nickname,omitempty" validate:"max=50"`   // 昵称
+	Avatar   *string `json:"avatar,omitempty" validate:"max=255"`    // 头像
+	Age      *int    `json:"age,omitempty" validate:"min=0,max=150"` // 年龄，0表示清除
+}
+
+// DeleteAccountRequest 注销账户请求
+type DeleteAccountRequest struct {
+	BaseRequest
+	Password string `json:"password" validate:"required,min=6,max=50"` // 当前密码
+}
+
+// TokenData 凭证响应数据
+type TokenData struct {
+	Token     string    `json:"token"`      // 登录凭证
+	ExpiresAt time.Time `json:"expires_at"` // 凭证过期时间
 }
 
 // NewLoginRequest 创建登录请求
@@ -54,18 +114,32 @@ func NewSignupRequest(name, password string, age int) SignupRequest {
 }
 
 // NewRestoreSessionRequest 创建恢复会话请求
-func NewRestoreSessionRequest(name string) RestoreSessionRequest {
+func NewRestoreSessionRequest(name, token string) RestoreSessionRequest {
 	return RestoreSessionRequest{
 		BaseRequest: NewBaseRequest(),
 		Name:        name,
+		Token:       token,
+	}
+}
+
+// NewProfileData 根据用户创建资料数据，不包含密码等敏感信息
+func NewProfileData(user *models.User) ProfileData {
+	return ProfileData{
+		ID:        user.ID,
+		Name:      user.Name,
+		Nickname:  user.Nickname,
+		Avatar:    user.Avatar,
+		Age:       user.Age,
+		CreatedAt: user.CreatedAt,
 	}
 }
 
 // LoginSuccess 创建登录成功响应
-func LoginSuccess(name string, age int) BaseResponse {
+func LoginSuccess(user *models.User, token string, expiresAt time.Time) BaseResponse {
 	data := LoginData{
-		Name: name,
-		Age:  age,
+		ProfileData: NewProfileData(user),
+		Token:       token,
+		ExpiresAt:   expiresAt,
 	}
 	return SuccessWithMessage(data, "登录成功")
 }
@@ -79,10 +153,44 @@ func SignupSuccess(name string) BaseResponse {
 }
 
 // RestoreSessionSuccess 创建恢复会话成功响应
-func RestoreSessionSuccess(name string, age int) BaseResponse {
+func RestoreSessionSuccess(user *models.User, token string, expiresAt time.Time) BaseResponse {
 	data := LoginData{
-		Name: name,
-		Age:  age,
+		ProfileData: NewProfileData(user),
+		Token:       token,
+		ExpiresAt:   expiresAt,
 	}
 	return SuccessWithMessage(data, "会话恢复成功")
 }
+
+// RefreshTokenSuccess 创建刷新凭证成功响应
+func RefreshTokenSuccess(token string, expiresAt time.Time) BaseResponse {
+	data := TokenData{
+		Token:     token,
+		ExpiresAt: expiresAt,
+	}
+	return SuccessWithMessage(data, "凭证刷新成功")
+}
+
+// ChangePasswordSuccess 创建修改密码成功响应，返回替换旧凭证的新凭证
+func ChangePasswordSuccess(token string, expiresAt time.Time) BaseResponse {
+	data := TokenData{
+		Token:     token,
+		ExpiresAt: expiresAt,
+	}
+	return SuccessWithMessage(data, "密码修改成功")
+}
+
+// UpdateProfileSuccess 创建修改资料成功响应
+func UpdateProfileSuccess(user *models.User) BaseResponse {
+	return SuccessWithMessage(NewProfileData(user), "资料修改成功")
+}
+
+// DeleteAccountSuccess 创建注销账户成功响应
+func DeleteAccountSuccess() BaseResponse {
+	return SuccessWithMessage(nil, "账户已注销")
+}
+
+// LogoutSuccess 创建退出登录成功响应
+func LogoutSuccess() BaseResponse {
+	return SuccessWithMessage(nil, "退出登录成功")
+}
@@ -73,6 +73,17 @@ func (v *Validator) validateField(field reflect.Value, fieldName, validateTag st
 			continue
 		}
 
+		// 指针字段为空表示未传，只检查required；非空时校验指向的值
+		if field.Kind() == reflect.Ptr && rule != "required" {
+			if field.IsNil() {
+				continue
+			}
+			if err := v.applyRule(field.Elem(), fieldName, rule); err != nil {
+				return err
+			}
+			continue
+		}
+
 		if err := v.applyRule(field, fieldName, rule); err != nil {
 			return err
 		}
@@ -14,11 +14,17 @@ const app = createApp({
         const authForm = ref({
             name: '',
             password: '',
-            age: 18
+            age: null
         });
 
         // 房间相关
         const rooms = ref([]);
+        const roomsCursor = ref('');
+        const roomFilter = ref({
+            keyword: '',
+            openSeats: false,
+            sortBy: 'created_at'
+        });
         const currentRoom = ref(null);
         const showCreateRoomModal = ref(false);
         const showJoinRoomModal = ref(false);
@@ -28,9 +34,14 @@ const app = createApp({
             name: '',
             type: '0',
             password: '',
-            ai_count: 0
+            ai_count: 0,
+            hands: 0,
+            base_stake: 10
         });
 
+        // 金币余额
+        const balance = ref(null);
+
         // 游戏相关
         const gameState = ref(null);
         const playerHand = ref([]);
@@ -163,6 +174,7 @@ const app = createApp({
                 }, async function() {
                     console.log('nano连接成功');
                     nanoInitialized.value = true;
+                    registerPushHandlers();
                     
                     // 如果用户已登录，需要重新认证
                     if (currentUser.value) {
@@ -180,6 +192,101 @@ const app = createApp({
             });
         };
 
+        // 服务器推送处理
+        const registerPushHandlers = () => {
+            nano.value.on('onRoomInvite', async (invite) => {
+                if (currentRoom.value) return;
+                if (!confirm(`${invite.from} 邀请你加入房间「${invite.room_name}」，是否加入？`)) return;
+                selectedRoom.value = { id: invite.room_id, name: invite.room_name };
+                joinRoomPassword.value = '';
+                await doJoinRoom(invite.invite_id);
+            });
+            nano.value.on('onRoomKicked', async (data) => {
+                if (!currentRoom.value || currentRoom.value.id !== data.room_id) return;
+                currentRoom.value = null;
+                currentView.value = 'rooms';
+                gameState.value = null;
+                playerHand.value = [];
+                stopGameStatePolling();
+                error.value = `你已被房主 ${data.by} 踢出房间，${data.rejoin_after} 后才能重新加入`;
+                await refreshRooms();
+            });
+            nano.value.on('onGameTerminated', (data) => {
+                if (!currentRoom.value || currentRoom.value.id !== data.room_id) return;
+                error.value = `本局已被结束：${data.reason}`;
+            });
+            nano.value.on('onGameResumed', async (data) => {
+                // 重新上线时所在房间有进行中的游戏，直接回到游戏
+                stopGameStatePolling();
+                currentRoom.value = { id: data.room_id, name: data.room_name };
+                currentView.value = 'game';
+                success.value = '已回到进行中的游戏';
+                await getGameState();
+                startGameStatePolling();
+            });
+            nano.value.on('onServerMaintenance', (data) => {
+                const at = new Date(data.shutdown_at).toLocaleTimeString();
+                error.value = `${data.message}（最迟 ${at} 停机）`;
+            });
+            nano.value.on('onAccountBanned', (data) => {
+                error.value = data.reason ? `账户已被封禁：${data.reason}` : '账户已被封禁';
+            });
+            nano.value.on('onRoomClosed', async (data) => {
+                if (!currentRoom.value || currentRoom.value.id !== data.room_id) return;
+                currentRoom.value = null;
+                currentView.value = 'rooms';
+                gameState.value = null;
+                playerHand.value = [];
+                stopGameStatePolling();
+                error.value = `房间已关闭：${data.reason}`;
+                await refreshRooms();
+            });
+            nano.value.on('onRoomOwnerChanged', (data) => {
+                if (!currentRoom.value || currentRoom.value.id !== data.room_id) return;
+                currentRoom.value = { ...currentRoom.value, owner: data.owner };
+            });
+            nano.value.on('onTournamentTable', async (data) => {
+                // 比赛编桌后已自动入座，直接进入本桌房间
+                stopGameStatePolling();
+                currentRoom.value = { id: data.room_id, name: data.room_name };
+                currentView.value = 'game';
+                success.value = `比赛第 ${data.round} 轮开始，你在第 ${data.table} 桌`;
+                await getGameState();
+                startGameStatePolling();
+            });
+            nano.value.on('onTournamentStandings', (data) => {
+                if (data.status_name !== '进行中') {
+                    success.value = data.champion ? `比赛「${data.name}」结束，冠军：${data.champion}` : `比赛「${data.name}」${data.status_name}`;
+                }
+            });
+            nano.value.on('onCoinsGranted', (data) => {
+                balance.value = data.balance;
+                success.value = `${data.type_name}：获得 ${data.amount} 金币`;
+            });
+            nano.value.on('onCoinsSettled', (data) => {
+                const mine = (data.changes || []).find(change => change.username === currentUser.value?.name);
+                if (!mine) return;
+                balance.value = mine.balance;
+                const spring = data.spring ? '，春天' : '';
+                success.value = `本局 ${data.multiplier} 倍${spring}，金币 ${mine.delta >= 0 ? '+' : ''}${mine.delta}`;
+            });
+            nano.value.on('onAchievementUnlocked', (data) => {
+                balance.value = data.balance;
+                success.value = `获得成就「${data.goal.name}」，奖励 ${data.goal.reward} 金币`;
+            });
+            nano.value.on('onMissionCompleted', (data) => {
+                balance.value = data.balance;
+                const kind = data.kind === 'weekly' ? '每周任务' : '每日任务';
+                success.value = `完成${kind}「${data.goal.name}」，奖励 ${data.goal.reward} 金币`;
+            });
+            nano.value.on('onFriendRequest', (data) => {
+                success.value = `${data.name} 请求添加你为好友`;
+            });
+            nano.value.on('onFriendAccepted', (data) => {
+                success.value = `${data.name} 已成为你的好友`;
+            });
+        };
+
         // 重新认证方法
         const reauthenticate = async () => {
             if (!currentUser.value) return;
@@ -188,11 +295,12 @@ const app = createApp({
             // 这里我们使用一个特殊的请求来恢复会话状态
             try {
                 const response = await request('user.RestoreSession', {
-                    name: currentUser.value.name
+                    token: currentUser.value.token
                 });
                 
                 if (response.code === 200) {
                     console.log('会话恢复成功');
+                    fetchBalance();
                 } else {
                     throw new Error(response.message || '会话恢复失败');
                 }
@@ -239,6 +347,7 @@ const app = createApp({
                         // 保存登录状态到本地存储
                         localStorage.setItem('currentUser', JSON.stringify(response.data));
                         currentView.value = 'rooms';
+                        await fetchBalance();
                         await refreshRooms();
                     } else {
                         success.value = '注册成功，请登录';
@@ -263,7 +372,12 @@ const app = createApp({
         };
 
         const logout = () => {
+            // 通知服务器吊销登录凭证
+            if (currentUser.value && currentUser.value.token && nanoInitialized.value) {
+                request('user.Logout', { token: currentUser.value.token }).catch(() => {});
+            }
             currentUser.value = null;
+            balance.value = null;
             currentView.value = 'login';
             currentRoom.value = null;
             gameState.value = null;
@@ -273,20 +387,25 @@ const app = createApp({
         };
 
         // 房间相关方法
-        const refreshRooms = async () => {
+        const fetchRooms = async (cursor) => {
             loading.value = true;
             try {
                 // 确保nano已经初始化
                 await initNano();
-                
+
                 const response = await request('room.GetRoomList', {
-                    page: 1,
-                    size: 50,
-                    type: 0
+                    size: 20,
+                    type: 0,
+                    keyword: roomFilter.value.keyword,
+                    open_seats: roomFilter.value.openSeats ? 1 : 0,
+                    sort_by: roomFilter.value.sortBy,
+                    cursor
                 });
 
                 if (response.code === 200) {
-                    rooms.value = response.data.rooms || [];
+                    const page = response.data.rooms || [];
+                    rooms.value = cursor ? [...rooms.value, ...page] : page;
+                    roomsCursor.value = response.data.next_cursor || '';
                 } else {
                     error.value = response.message || '获取房间列表失败';
                 }
@@ -297,6 +416,22 @@ const app = createApp({
             }
         };
 
+        const refreshRooms = () => fetchRooms('');
+
+        // 查询金币余额
+        const fetchBalance = async () => {
+            try {
+                const response = await request('coin.Balance', {});
+                if (response.code === 200) {
+                    balance.value = response.data.balance;
+                }
+            } catch (err) {
+                console.error('查询金币余额失败:', err);
+            }
+        };
+
+        const loadMoreRooms = () => fetchRooms(roomsCursor.value);
+
         const createRoom = async () => {
             if (!createRoomForm.value.name) {
                 error.value = '请输入房间名称';
@@ -314,14 +449,16 @@ const app = createApp({
                     name: createRoomForm.value.name,
                     type: parseInt(createRoomForm.value.type),
                     password: createRoomForm.value.password,
-                    ai_count: parseInt(createRoomForm.value.ai_count)
+                    ai_count: parseInt(createRoomForm.value.ai_count),
+                    hands: parseInt(createRoomForm.value.hands),
+                    base_stake: parseInt(createRoomForm.value.base_stake)
                 });
 
                 if (response.code === 200) {
                     currentRoom.value = response.data;
                     currentView.value = 'game';
                     showCreateRoomModal.value = false;
-                    createRoomForm.value = { name: '', type: '0', password: '', ai_count: 0 };
+                    createRoomForm.value = { name: '', type: '0', password: '', ai_count: 0, hands: 0, base_stake: 10 };
                     await getGameState();
                     startGameStatePolling();
                 } else {
@@ -374,7 +511,7 @@ const app = createApp({
             }
         };
 
-        const doJoinRoom = async () => {
+        const doJoinRoom = async (inviteId) => {
             loading.value = true;
             error.value = '';
 
@@ -384,7 +521,9 @@ const app = createApp({
                 
                 const response = await request('room.JoinRoom', {
                     room_id: selectedRoom.value.id,
-                    password: joinRoomPassword.value
+                    password: joinRoomPassword.value,
+                    // 凭好友邀请加入时无需密码
+                    invite_id: typeof inviteId === 'string' ? inviteId : ''
                 });
 
                 if (response.code === 200) {
@@ -403,6 +542,40 @@ const app = createApp({
             }
         };
 
+        // 通过房间码加入房间，私人房间再询问密码
+        const joinByCode = async () => {
+            const input = prompt('请输入6位房间码');
+            if (!input) return;
+            const code = input.trim().toUpperCase();
+
+            loading.value = true;
+            error.value = '';
+
+            try {
+                await initNano();
+
+                let response = await request('room.JoinByCode', { code });
+                if (response.code === 401) {
+                    const password = prompt('该房间需要密码');
+                    if (password === null) return;
+                    response = await request('room.JoinByCode', { code, password });
+                }
+
+                if (response.code === 200) {
+                    currentRoom.value = response.data;
+                    currentView.value = 'game';
+                    await getGameState();
+                    startGameStatePolling();
+                } else {
+                    error.value = response.message || '加入房间失败';
+                }
+            } catch (err) {
+                error.value = '网络错误：' + err.message;
+            } finally {
+                loading.value = false;
+            }
+        };
+
         const leaveRoom = async () => {
             if (!currentRoom.value) return;
 
@@ -424,6 +597,60 @@ const app = createApp({
             }
         };
 
+        // 房主操作：踢人、转让房主、增减AI座位
+        const ownerAction = async (route, params, failMessage) => {
+            try {
+                await initNano();
+
+                const response = await request(route, {
+                    room_id: currentRoom.value.id,
+                    ...params
+                });
+
+                if (response.code === 200) {
+                    if (response.data?.id) {
+                        currentRoom.value = response.data;
+                    }
+                    await getGameState();
+                } else {
+                    error.value = response.message || failMessage;
+                }
+            } catch (err) {
+                error.value = '网络错误：' + err.message;
+            }
+        };
+
+        const kickPlayer = async (player) => {
+            if (!confirm(`确定要将 ${player} 踢出房间吗？`)) return;
+            await ownerAction('room.KickPlayer', { player }, '踢出玩家失败');
+        };
+
+        const transferOwner = async (player) => {
+            if (!confirm(`确定要将房主转给 ${player} 吗？`)) return;
+            await ownerAction('room.TransferOwner', { player }, '转让房主失败');
+        };
+
+        const endSession = async () => {
+            if (!confirm('确定要结束对战吗？正在进行的一局会打完')) return;
+            await ownerAction('room.EndSession', {}, '结束对战失败');
+        };
+
+        // 多局对战下一局倒计时（秒），随游戏状态轮询刷新
+        const now = ref(Date.now());
+        const nextHandCountdown = computed(() => {
+            const at = gameState.value?.session?.next_hand_at;
+            if (!at) return 0;
+            return Math.max(0, Math.ceil((new Date(at) - now.value) / 1000));
+        });
+
+        const addAI = async () => {
+            await ownerAction('room.AddAI', {}, '添加AI玩家失败');
+        };
+
+        const removeAI = async (player) => {
+            await ownerAction('room.RemoveAI', { player }, '移除AI玩家失败');
+        };
+
         // 游戏相关方法
         const getGameState = async () => {
             if (!currentRoom.value) return;
@@ -655,6 +882,7 @@ const app = createApp({
         const startGameStatePolling = () => {
             if (gameStateInterval) clearInterval(gameStateInterval);
             gameStateInterval = setInterval(() => {
+                now.value = Date.now();
                 if (currentView.value === 'game' && currentRoom.value) {
                     getGameState();
                 }
@@ -692,8 +920,12 @@ const app = createApp({
             success,
             isLogin,
             currentUser,
+            balance,
             authForm,
             rooms,
+            roomsCursor,
+            roomFilter,
+            loadMoreRooms,
             currentRoom,
             showCreateRoomModal,
             showJoinRoomModal,
@@ -719,6 +951,13 @@ const app = createApp({
             deleteRoom,
             joinRoom,
             doJoinRoom,
+            joinByCode,
+            kickPlayer,
+            transferOwner,
+            addAI,
+            removeAI,
+            endSession,
+            nextHandCountdown,
             leaveRoom,
             toggleReady,
             startGame,